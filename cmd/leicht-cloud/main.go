@@ -1,13 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"net/http"
 	"os"
 	"os/signal"
+	"time"
 
 	gchttp "github.com/leicht-cloud/leicht-cloud/pkg/http"
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/notify"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/quota"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/search"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/shared"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/trash"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/versioning"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/sqlite"
@@ -63,18 +71,54 @@ func main() {
 	}
 	defer pluginManager.Close()
 
+	logrus.Infof("Initializing file info providers")
+	fileinfo, err := config.FileInfo.CreateProvider(pluginManager, prom)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	defer fileinfo.Close()
+
+	// stops everything running in the background for the storage once we exit
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
 	logrus.Infof("Initializing storage provider %s", config.Storage.Provider)
-	storage, err := prom.WrapStorage(config.Storage.CreateProvider(pluginManager))
+	storage, err := prom.WrapStorage(config.Storage.CreateProvider(pluginManager, db))
 	if err != nil {
 		logrus.Fatal(err)
 	}
 
-	logrus.Infof("Initializing file info providers")
-	fileinfo, err := config.FileInfo.CreateProvider(pluginManager, prom)
+	logrus.Info("Calculating storage usage")
+	storage, err = quota.Quota(storage, db)
 	if err != nil {
 		logrus.Fatal(err)
 	}
-	defer fileinfo.Close()
+
+	if config.Storage.Versioning.Enabled {
+		storage = versioning.Versioning(storage, config.Storage.Versioning)
+	}
+
+	if config.Storage.Trash.Enabled {
+		trashed := trash.Trash(storage, config.Storage.Trash)
+		// nobody would ever restore or purge the trash of the shared folders
+		trashed.Bypass(shared.IsOwner)
+		go trashed.Purger(ctx, db, time.Hour)
+		storage = trashed
+	}
+
+	if config.Storage.Search.Enabled {
+		indexed, err := search.Index(storage, db, fileinfo, config.Storage.Search)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		go indexed.Indexer(ctx)
+		storage = indexed
+	}
+
+	// the shared folders are stored through all of the above, so they get versioned and indexed as well
+	storage = shared.Shared(storage, db)
+
+	storage = notify.Notify(storage)
 
 	logrus.Infof("Initializing apps")
 	apps, err := config.Apps.CreateProvider(pluginManager, storage, prom)
@@ -7,12 +7,15 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 
 	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -21,6 +24,9 @@ type Provider struct {
 	DB         *gorm.DB
 	publicKey  ed25519.PublicKey
 	privateKey ed25519.PrivateKey
+
+	// throttle limits the failed basic auth logins per account and per address
+	throttle *Throttle
 }
 
 type Config struct {
@@ -46,6 +52,7 @@ func (c *Config) Create(db *gorm.DB) (*Provider, error) {
 			DB:         db,
 			publicKey:  publicKey,
 			privateKey: privateKey,
+			throttle:   NewThrottle(),
 		}, nil
 	}
 
@@ -55,6 +62,7 @@ func (c *Config) Create(db *gorm.DB) (*Provider, error) {
 		DB:         db,
 		publicKey:  privateKey.Public().(ed25519.PublicKey),
 		privateKey: privateKey,
+		throttle:   NewThrottle(),
 	}, nil
 }
 
@@ -105,3 +113,75 @@ func (p *Provider) VerifyFromRequest(r *http.Request) (*models.User, error) {
 
 	return p.verifyCookie(cookie.Value)
 }
+
+// errInvalidLogin is the same for unknown emails and wrong passwords, so it doesn't tell which accounts exist
+var errInvalidLogin = errors.New("Invalid email or password")
+
+var (
+	dummyOnce sync.Once
+	dummyHash []byte
+)
+
+// compareDummy spends as much time on password as checking it against an actual hash would take,
+// so unknown emails can't be told apart by how long it takes to turn them down
+func compareDummy(password string) {
+	dummyOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not the password you're looking for"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// VerifyBasicAuth checks the basic auth credentials of r, which are the email of the user along with either
+// their own password or one of their app passwords. After too many failed attempts for either the account or
+// the address of the client, it fails with ErrThrottled without checking anything
+func (p *Provider) VerifyBasicAuth(r *http.Request) (*models.User, error) {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, errors.New("No credentials")
+	}
+
+	accountKey := "account:" + strings.ToLower(email)
+	addressKey := "address:" + ClientAddress(r)
+	if !p.throttle.Allowed(accountKey, maxAccountFailures) || !p.throttle.Allowed(addressKey, maxAddressFailures) {
+		return nil, ErrThrottled
+	}
+
+	user, err := p.verifyPassword(r, email, password)
+	if errors.Is(err, errInvalidLogin) {
+		p.throttle.Fail(accountKey, addressKey)
+	} else if err == nil {
+		p.throttle.Reset(accountKey)
+	}
+	return user, err
+}
+
+func (p *Provider) verifyPassword(r *http.Request, email, password string) (*models.User, error) {
+	var user models.User
+	result := p.DB.WithContext(r.Context()).Limit(1).Find(&user, "email = ?", email)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		compareDummy(password)
+		return nil, errInvalidLogin
+	}
+
+	// app passwords are cheap to check, so we try those before the actual password
+	var count int64
+	result = p.DB.WithContext(r.Context()).
+		Model(&models.AppPassword{}).
+		Where("user_id = ? AND hash = ?", user.ID, models.HashAppPassword(password)).
+		Count(&count)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if count > 0 {
+		return &user, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(password))
+	if err != nil {
+		return nil, errInvalidLogin
+	}
+	return &user, nil
+}
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"fmt"
+	"net/http/httptest"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -76,3 +80,88 @@ func TestVerify(t *testing.T) {
 		assert.Equal(t, user.Email, verifiedUser.Email)
 	}
 }
+
+func TestVerifyBasicAuth(t *testing.T) {
+	db, provider := setupProvider(t)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "test@test.com", PasswordHash: hash}
+	other := &models.User{Email: "other@test.com"}
+	assert.NoError(t, db.Create(user).Error)
+	assert.NoError(t, db.Create(other).Error)
+	assert.NoError(t, db.Create(&models.AppPassword{UserID: user.ID, Name: "phone", Hash: models.HashAppPassword("app")}).Error)
+
+	for _, test := range []struct {
+		email, password string
+		ok              bool
+	}{
+		{"test@test.com", "password", true},
+		{"test@test.com", "app", true},
+		{"test@test.com", "wrong", false},
+		{"other@test.com", "app", false},
+		{"missing@test.com", "password", false},
+	} {
+		req := httptest.NewRequest("GET", "/dav/", nil)
+		req.SetBasicAuth(test.email, test.password)
+		verifiedUser, err := provider.VerifyBasicAuth(req)
+		if test.ok {
+			assert.NoError(t, err, test.email)
+			if assert.NotNil(t, verifiedUser, test.email) {
+				assert.Equal(t, user.ID, verifiedUser.ID)
+			}
+		} else {
+			assert.Error(t, err, test.email)
+			assert.Nil(t, verifiedUser, test.email)
+		}
+	}
+
+	_, err = provider.VerifyBasicAuth(httptest.NewRequest("GET", "/dav/", nil))
+	assert.Error(t, err)
+}
+
+func TestThrottleBasicAuth(t *testing.T) {
+	db, provider := setupProvider(t)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider.throttle.clock = func() time.Time {
+		return now
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, db.Create(&models.User{Email: "test@test.com", PasswordHash: hash}).Error)
+	assert.NoError(t, db.Create(&models.User{Email: "other@test.com", PasswordHash: hash}).Error)
+
+	verify := func(address, email, password string) error {
+		req := httptest.NewRequest("GET", "/dav/", nil)
+		req.RemoteAddr = address + ":1234"
+		req.SetBasicAuth(email, password)
+		_, err := provider.VerifyBasicAuth(req)
+		return err
+	}
+
+	t.Run("Account", func(t *testing.T) {
+		for i := 0; i < maxAccountFailures; i++ {
+			assert.ErrorIs(t, verify(fmt.Sprintf("10.0.0.%d", i), "test@test.com", "wrong"), errInvalidLogin)
+		}
+		// even the right password is refused now, from anywhere
+		assert.ErrorIs(t, verify("10.0.1.1", "test@test.com", "password"), ErrThrottled)
+		assert.NoError(t, verify("10.0.1.1", "other@test.com", "password"))
+
+		now = now.Add(throttleWindow)
+		assert.NoError(t, verify("10.0.1.1", "test@test.com", "password"))
+	})
+
+	t.Run("Address", func(t *testing.T) {
+		// unknown emails count just as well, and fail just like wrong passwords do
+		for i := 0; i < maxAddressFailures; i++ {
+			assert.ErrorIs(t, verify("10.0.2.1", fmt.Sprintf("missing%d@test.com", i), "wrong"), errInvalidLogin)
+		}
+		assert.ErrorIs(t, verify("10.0.2.1", "other@test.com", "password"), ErrThrottled)
+		assert.NoError(t, verify("10.0.2.2", "other@test.com", "password"))
+	})
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrThrottled is returned for logins after too many failed ones, until the window they happened in is over
+var ErrThrottled = errors.New("Too many failed logins, try again later")
+
+const (
+	// throttleWindow is how long failed logins are remembered
+	throttleWindow = 15 * time.Minute
+	// maxAccountFailures is how many failed logins a single account gets within the window
+	maxAccountFailures = 5
+	// maxAddressFailures is how many failed logins a single address gets within the window, this is a bit
+	// higher than for accounts as a couple of users may be behind the same address
+	maxAddressFailures = 20
+)
+
+type failures struct {
+	count int
+	since time.Time
+}
+
+// Throttle counts the failed logins per key, so passwords can't be guessed by just trying. Keys are
+// up to the caller, like the account or the address of the client, each with a limit of its own
+type Throttle struct {
+	mutex    sync.Mutex
+	failures map[string]*failures
+
+	clock func() time.Time
+}
+
+func NewThrottle() *Throttle {
+	return &Throttle{
+		failures: make(map[string]*failures),
+		clock:    time.Now,
+	}
+}
+
+// Allowed returns whether key had less than max failed logins within the window
+func (t *Throttle) Allowed(key string, max int) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	entry, ok := t.failures[key]
+	if !ok || t.clock().Sub(entry.since) >= throttleWindow {
+		return true
+	}
+	return entry.count < max
+}
+
+// Fail records a failed login for every one of keys
+func (t *Throttle) Fail(keys ...string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := t.clock()
+	for key, entry := range t.failures {
+		if now.Sub(entry.since) >= throttleWindow {
+			delete(t.failures, key)
+		}
+	}
+
+	for _, key := range keys {
+		entry, ok := t.failures[key]
+		if !ok {
+			entry = &failures{since: now}
+			t.failures[key] = entry
+		}
+		entry.count++
+	}
+}
+
+// Reset forgets the failed logins of key, once it logged in successfully
+func (t *Throttle) Reset(key string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.failures, key)
+}
+
+// ClientAddress returns the address of the client making r, without the port
+func ClientAddress(r *http.Request) string {
+	address, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return address
+}
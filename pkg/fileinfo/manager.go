@@ -230,6 +230,15 @@ func (m *Manager) FileInfo(filename string, file storage.File, opts *Options, re
 	return out, nil
 }
 
+// MimeType only determines the mime type of file, without running any of the providers
+func (m *Manager) MimeType(filename string, file io.Reader) (types.MimeType, error) {
+	mime, _, err := m.readMime(filename, io.LimitReader(file, m.mimeTypeProvider.MinimumBytes()))
+	if err != nil {
+		return types.MimeType{}, err
+	}
+	return *mime, nil
+}
+
 func (m *Manager) readMime(filename string, reader io.Reader) (*types.MimeType, io.Reader, error) {
 	buf, err := ioutil.ReadAll(reader)
 	if err != nil {
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/shared"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/utils"
+	"gorm.io/gorm"
+)
+
+type groupHandler struct {
+	StaticHandler http.Handler
+	DB            *gorm.DB
+	Storage       storage.StorageProvider
+}
+
+type groupTemplateData struct {
+	Navbar template.NavbarData
+	Error  string
+
+	Group   models.Group
+	Members []*models.GroupMember
+	Folders []*models.SharedFolder
+	// Users are all users that aren't a member yet
+	Users []*models.User
+}
+
+func (h *groupHandler) GetIntendedGroup(r *http.Request) (*models.Group, error) {
+	rawID := r.URL.Query().Get("id")
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	group := &models.Group{}
+	tx := h.DB.First(group, id)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	return group, nil
+}
+
+// deleteFolder removes folder along with its files
+func (h *groupHandler) deleteFolder(ctx context.Context, folder *models.SharedFolder) error {
+	// the shared folders may be wrapped in other layers, so we have to go looking for them
+	for store := h.Storage; store != nil; store = storage.Unwrap(store) {
+		if s, ok := store.(*shared.SharedStorageProvider); ok {
+			err := s.DeleteFolder(ctx, folder)
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return h.DB.Delete(folder).Error
+}
+
+// handlePost applies the action of the submitted form, it returns whether the group got deleted
+func (h *groupHandler) handlePost(r *http.Request, group *models.Group) (bool, error) {
+	err := r.ParseForm()
+	if err != nil {
+		return false, err
+	}
+
+	switch r.FormValue("action") {
+	case "rename":
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" {
+			return false, fmt.Errorf("The group needs a name")
+		}
+		return false, h.DB.Model(group).Update("name", name).Error
+
+	case "add_member", "set_role":
+		role := models.GroupRole(r.FormValue("role"))
+		if !role.Valid() {
+			return false, fmt.Errorf("Unknown role %q", role)
+		}
+		userID, err := strconv.ParseUint(r.FormValue("user_id"), 10, 64)
+		if err != nil {
+			return false, err
+		}
+
+		if r.FormValue("action") == "set_role" {
+			return false, h.DB.Model(&models.GroupMember{}).
+				Where("group_id = ? AND user_id = ?", group.ID, userID).
+				Update("role", role).Error
+		}
+		return false, h.DB.Create(&models.GroupMember{GroupID: group.ID, UserID: userID, Role: role}).Error
+
+	case "remove_member":
+		userID, err := strconv.ParseUint(r.FormValue("user_id"), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		return false, h.DB.Where("group_id = ? AND user_id = ?", group.ID, userID).Delete(&models.GroupMember{}).Error
+
+	case "add_folder":
+		name := strings.TrimSpace(r.FormValue("name"))
+		if name == "" || strings.Contains(name, "/") {
+			return false, fmt.Errorf("Invalid folder name %q, it can't be empty or contain a /", name)
+		}
+		err = utils.ValidatePath(name)
+		if err != nil {
+			return false, err
+		}
+		return false, h.DB.Create(&models.SharedFolder{GroupID: group.ID, Name: name}).Error
+
+	case "delete_folder":
+		folderID, err := strconv.ParseUint(r.FormValue("folder_id"), 10, 64)
+		if err != nil {
+			return false, err
+		}
+		folder := &models.SharedFolder{}
+		tx := h.DB.First(folder, "id = ? AND group_id = ?", folderID, group.ID)
+		if tx.Error != nil {
+			return false, tx.Error
+		}
+		return false, h.deleteFolder(r.Context(), folder)
+
+	case "delete_group":
+		var folders []*models.SharedFolder
+		tx := h.DB.Find(&folders, "group_id = ?", group.ID)
+		if tx.Error != nil {
+			return false, tx.Error
+		}
+		for _, folder := range folders {
+			err = h.deleteFolder(r.Context(), folder)
+			if err != nil {
+				return false, err
+			}
+		}
+		tx = h.DB.Where("group_id = ?", group.ID).Delete(&models.GroupMember{})
+		if tx.Error != nil {
+			return false, tx.Error
+		}
+		return true, h.DB.Delete(group).Error
+	}
+
+	return false, errors.New("Unknown action")
+}
+
+func (h *groupHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	data := groupTemplateData{
+		Navbar: template.NavbarData{
+			Admin: user.Admin,
+		},
+	}
+
+	group, err := h.GetIntendedGroup(r)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		deleted, err := h.handlePost(r, group)
+		if err != nil {
+			data.Error = err.Error()
+		} else if deleted {
+			http.Redirect(w, r, "/admin/grouplist", http.StatusSeeOther)
+			return
+		}
+	}
+	data.Group = *group
+
+	tx := h.DB.Preload("User").Find(&data.Members, "group_id = ?", group.ID)
+	if tx.Error == nil {
+		tx = h.DB.Order("name").Find(&data.Folders, "group_id = ?", group.ID)
+	}
+	if tx.Error == nil {
+		tx = h.DB.Where("id NOT IN (?)", h.DB.Model(&models.GroupMember{}).Select("user_id").Where("group_id = ?", group.ID)).
+			Order("email").
+			Find(&data.Users)
+	}
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// internal rewrite to admin page, so we render that
+	r.URL.Path = "/admin.group.gohtml"
+
+	ctx := template.AttachTemplateData(r.Context(), data)
+
+	h.StaticHandler.ServeHTTP(w, r.WithContext(ctx))
+}
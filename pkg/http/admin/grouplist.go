@@ -0,0 +1,72 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"gorm.io/gorm"
+)
+
+type grouplistHandler struct {
+	StaticHandler http.Handler
+	DB            *gorm.DB
+}
+
+type grouplistTemplateData struct {
+	Navbar template.NavbarData
+	Error  string
+
+	Groups []*models.Group
+}
+
+func (h *grouplistHandler) handlePost(r *http.Request) (*models.Group, error) {
+	err := r.ParseForm()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		return nil, fmt.Errorf("The group needs a name")
+	}
+
+	group := &models.Group{Name: name}
+	tx := h.DB.Create(group)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	return group, nil
+}
+
+func (h *grouplistHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	data := grouplistTemplateData{
+		Navbar: template.NavbarData{
+			Admin: user.Admin,
+		},
+	}
+
+	if r.Method == http.MethodPost {
+		group, err := h.handlePost(r)
+		if err == nil {
+			http.Redirect(w, r, fmt.Sprintf("/admin/group?id=%d", group.ID), http.StatusSeeOther)
+			return
+		}
+		data.Error = err.Error()
+	}
+
+	tx := h.DB.Order("name").Find(&data.Groups)
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// internal rewrite to admin page, so we render that
+	r.URL.Path = "/admin.grouplist.gohtml"
+
+	ctx := template.AttachTemplateData(r.Context(), data)
+
+	h.StaticHandler.ServeHTTP(w, r.WithContext(ctx))
+}
@@ -5,13 +5,16 @@ import (
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
 	"github.com/leicht-cloud/leicht-cloud/pkg/plugin"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 	"gorm.io/gorm"
 )
 
-func Init(mux *http.ServeMux, auth *auth.Provider, templateHandler http.Handler, pluginManager *plugin.Manager, db *gorm.DB) {
+func Init(mux *http.ServeMux, auth *auth.Provider, templateHandler http.Handler, pluginManager *plugin.Manager, db *gorm.DB, storage storage.StorageProvider) {
 	mux.Handle("/admin/", Middleware(auth, &rootHandler{StaticHandler: templateHandler}))
 	mux.Handle("/admin/userlist", Middleware(auth, &userlistHandler{StaticHandler: templateHandler, DB: db}))
 	mux.Handle("/admin/user", Middleware(auth, &userHandler{StaticHandler: templateHandler, DB: db}))
+	mux.Handle("/admin/grouplist", Middleware(auth, &grouplistHandler{StaticHandler: templateHandler, DB: db}))
+	mux.Handle("/admin/group", Middleware(auth, &groupHandler{StaticHandler: templateHandler, DB: db, Storage: storage}))
 	mux.Handle("/admin/plugin", Middleware(auth, &pluginHandler{StaticHandler: templateHandler}))
 	mux.Handle("/admin/plugin/stdout", Middleware(auth, &pluginStdoutHandler{PluginManager: pluginManager}))
 }
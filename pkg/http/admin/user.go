@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -32,6 +33,12 @@ type userTemplateData struct {
 		Number int64
 		Metric string
 	}
+
+	Quota      models.Quota
+	QuotaHuman struct {
+		Number int64
+		Metric string
+	}
 }
 
 func (d *userTemplateData) FillUploadLimit(db *gorm.DB) error {
@@ -70,6 +77,23 @@ func (d *userTemplateData) FillDownloadLimit(db *gorm.DB) error {
 	return nil
 }
 
+func (d *userTemplateData) FillQuota(db *gorm.DB) error {
+	db.First(&d.Quota, "user_id = ?", d.User.ID)
+
+	if d.Quota.Limit > 0 && !d.Quota.Unlimited {
+		megabytes := d.Quota.Limit / (1024 * 1024)
+		if megabytes >= 1024 && megabytes%1024 == 0 {
+			d.QuotaHuman.Number = megabytes / 1024
+			d.QuotaHuman.Metric = "gb"
+		} else {
+			d.QuotaHuman.Number = megabytes
+			d.QuotaHuman.Metric = "mb"
+		}
+	}
+
+	return nil
+}
+
 func (h *userHandler) handlePost(r *http.Request) error {
 	user, err := h.GetIntendedUser(r)
 	if err != nil {
@@ -143,6 +167,39 @@ func (h *userHandler) handlePost(r *http.Request) error {
 		}
 	}
 
+	if r.Form.Has("quota_number") && r.Form.Has("quota_metric") {
+		number, err := strconv.ParseInt(r.FormValue("quota_number"), 10, 64)
+		if err != nil {
+			return err
+		}
+		if number < 0 {
+			return errors.New("The quota can't be negative")
+		}
+		switch r.FormValue("quota_metric") {
+		case "mb":
+			number *= 1024 * 1024
+		case "gb":
+			number *= 1024 * 1024 * 1024
+		case "unlimited":
+			number = 0
+		}
+
+		quota := models.Quota{
+			UserID:    user.ID,
+			User:      user,
+			Unlimited: number == 0,
+			Limit:     number,
+		}
+
+		tx := h.DB.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			UpdateAll: true,
+		}).Create(&quota)
+		if tx.Error != nil {
+			return tx.Error
+		}
+	}
+
 	return nil
 }
 
@@ -186,6 +243,7 @@ func (h *userHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Re
 	err = multierr.Combine(
 		data.FillUploadLimit(h.DB),
 		data.FillDownloadLimit(h.DB),
+		data.FillQuota(h.DB),
 	)
 
 	if err != nil {
@@ -149,27 +149,43 @@ $(document).ready(function () {
         datatable.button(0).enable(selectedRows > 0);
     });
 
-    $.get("/webapi/list?dir=" + dir)
-        .fail(function (xhr, status, error) {
-            alert(xhr.responseText, 'danger');
-        })
-        .done(function (data) {
-            data = data.split('\n');
-            data.forEach(function (data) {
-                try {
-                    json = JSON.parse(data);
-                    datatable.row.add([
-                        [json.name, json.directory],
-                        json.created_at,
-                        json.size
-                    ]).draw(false);
-                } catch (e) {
-                    console.log(e);
-                }
-            });
+    function loadDirectory() {
+        $.get("/webapi/list?dir=" + dir)
+            .fail(function (xhr, status, error) {
+                alert(xhr.responseText, 'danger');
+            })
+            .done(function (data) {
+                datatable.clear();
+                data = data.split('\n');
+                data.forEach(function (data) {
+                    try {
+                        json = JSON.parse(data);
+                        datatable.row.add([
+                            [json.name, json.directory],
+                            json.created_at,
+                            json.size
+                        ]).draw(false);
+                    } catch (e) {
+                        console.log(e);
+                    }
+                });
 
-            datatable.draw();
-        });
+                datatable.draw();
+            });
+    };
+    loadDirectory();
+
+    // reload the listing whenever something in this directory changes, a burst of changes only results in a single reload
+    var reloadTimeout = null;
+    var events = new WebSocket("ws://" + document.location.host + "/webapi/events?dir=" + dir);
+    events.onmessage = function (event) {
+        if (reloadTimeout === null) {
+            reloadTimeout = setTimeout(function () {
+                reloadTimeout = null;
+                loadDirectory();
+            }, 250);
+        }
+    };
 
     // our whole tus party starts here..
     var upload = null
@@ -0,0 +1,97 @@
+package dav
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/helper/limiter"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/webdav"
+	"gorm.io/gorm"
+)
+
+// Prefix is where the files of the logged in user are served over WebDAV
+const Prefix = "/dav/"
+
+func Init(mux *http.ServeMux, db *gorm.DB, authProvider *auth.Provider, store storage.StorageProvider) {
+	mux.Handle(Prefix, newHandler(db, authProvider, store))
+}
+
+type handler struct {
+	Auth    *auth.Provider
+	Storage storage.StorageProvider
+
+	serve auth.AuthHandlerInterface
+
+	// locks are kept per user, as they're on paths and those are only unique per user
+	mutex sync.Mutex
+	locks map[uint64]webdav.LockSystem
+}
+
+func newHandler(db *gorm.DB, authProvider *auth.Provider, store storage.StorageProvider) *handler {
+	out := &handler{
+		Auth:    authProvider,
+		Storage: store,
+		locks:   make(map[uint64]webdav.LockSystem),
+	}
+	// whatever gets uploaded or downloaded, the limits of the user apply
+	out.serve = limiter.UploadMiddleware(db, limiter.DownloadMiddleware(db, out))
+	return out
+}
+
+// ServeHTTP requires a login, either through the regular cookie or through basic auth as that's
+// what WebDAV clients use. The password for basic auth is either the one of the user or an app password
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user := auth.GetUserFromRequest(r)
+	if user == nil {
+		var err error
+		user, err = h.Auth.VerifyBasicAuth(r)
+		if errors.Is(err, auth.ErrThrottled) {
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+			return
+		} else if err != nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="leicht-cloud", charset="UTF-8"`)
+			http.Error(w, "Invalid login", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	h.serve.Serve(user, w, r)
+}
+
+func (h *handler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	fs := &fileSystem{Storage: h.Storage, User: user}
+	if r.Method == http.MethodPut {
+		fs.Upload = &upload{ReadCloser: r.Body, length: r.ContentLength}
+		r.Body = fs.Upload
+	}
+
+	dav := &webdav.Handler{
+		Prefix:     strings.TrimSuffix(Prefix, "/"),
+		FileSystem: fs,
+		LockSystem: h.lockSystem(user),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				logrus.Debugf("WebDAV %s %s: %s", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	dav.ServeHTTP(w, r)
+}
+
+func (h *handler) lockSystem(user *models.User) webdav.LockSystem {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	out, ok := h.locks[user.ID]
+	if !ok {
+		out = webdav.NewMemLS()
+		h.locks[user.ID] = out
+	}
+	return out
+}
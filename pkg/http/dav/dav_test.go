@@ -0,0 +1,195 @@
+package dav
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setup(t *testing.T) (*handler, *memory.StorageProvider) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authProvider, err := (&auth.Config{}).Create(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Email: "test@test.com", PasswordHash: hash}
+	assert.NoError(t, db.Create(user).Error)
+	assert.NoError(t, db.Create(&models.AppPassword{UserID: user.ID, Name: "phone", Hash: models.HashAppPassword("app")}).Error)
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/folder"] = struct{}{}
+	memfs.Data["/folder/test.data"] = []byte("0123456789")
+
+	return newHandler(db, authProvider, memfs), memfs
+}
+
+func request(handler http.Handler, method, target string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.SetBasicAuth("test@test.com", "app")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuth(t *testing.T) {
+	handler, _ := setup(t)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("PROPFIND", "/dav/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, rr.Result().Status)
+	assert.Contains(t, rr.Header().Get("WWW-Authenticate"), "Basic")
+
+	for password, status := range map[string]int{
+		"password": http.StatusOK,
+		"app":      http.StatusOK,
+		"wrong":    http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/dav/folder/test.data", nil)
+		req.SetBasicAuth("test@test.com", password)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, status, rr.Code, password)
+	}
+}
+
+func TestWebDAV(t *testing.T) {
+	handler, memfs := setup(t)
+
+	t.Run("Options", func(t *testing.T) {
+		rr := request(handler, http.MethodOptions, "/dav/", nil, nil)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "1, 2", rr.Header().Get("DAV"))
+	})
+
+	t.Run("Propfind", func(t *testing.T) {
+		rr := request(handler, "PROPFIND", "/dav/folder/", nil, map[string]string{"Depth": "1"})
+		assert.Equal(t, http.StatusMultiStatus, rr.Code, rr.Result().Status)
+		assert.Contains(t, rr.Body.String(), "<D:href>/dav/folder/test.data</D:href>")
+		assert.Contains(t, rr.Body.String(), "<D:getcontentlength>10</D:getcontentlength>")
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		rr := request(handler, http.MethodGet, "/dav/folder/test.data", nil, nil)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "0123456789", rr.Body.String())
+
+		// the memory provider doesn't support seeking, so this reads up to the range
+		rr = request(handler, http.MethodGet, "/dav/folder/test.data", nil, map[string]string{"Range": "bytes=4-6"})
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, "456", rr.Body.String())
+	})
+
+	t.Run("Mkcol", func(t *testing.T) {
+		rr := request(handler, "MKCOL", "/dav/new", nil, nil)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Contains(t, memfs.Dirs, "/new")
+
+		rr = request(handler, "MKCOL", "/dav/new", nil, nil)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code, rr.Result().Status)
+		rr = request(handler, "MKCOL", "/dav/missing/new", nil, nil)
+		assert.Equal(t, http.StatusConflict, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Put", func(t *testing.T) {
+		rr := request(handler, http.MethodPut, "/dav/new/put.data", strings.NewReader("put"), nil)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("put"), memfs.Data["/new/put.data"])
+
+		rr = request(handler, http.MethodPut, "/dav/new/empty.data", nil, nil)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Contains(t, memfs.Data, "/new/empty.data")
+	})
+
+	t.Run("Interrupted", func(t *testing.T) {
+		// the client promised more than it sent, so what was there before has to stay
+		req := httptest.NewRequest(http.MethodPut, "/dav/new/put.data", strings.NewReader("short"))
+		req.ContentLength = 100
+		req.SetBasicAuth("test@test.com", "app")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.NotEqual(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("put"), memfs.Data["/new/put.data"])
+
+		rr = request(handler, http.MethodPut, "/dav/new/put.data", io.MultiReader(strings.NewReader("broken"), iotest.ErrReader(io.ErrUnexpectedEOF)), nil)
+		assert.NotEqual(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("put"), memfs.Data["/new/put.data"])
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		rr := request(handler, "COPY", "/dav/new/put.data", nil, map[string]string{"Destination": "/dav/folder/copy.data"})
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("put"), memfs.Data["/folder/copy.data"])
+		assert.Equal(t, []byte("put"), memfs.Data["/new/put.data"])
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		rr := request(handler, "MOVE", "/dav/new/put.data", nil, map[string]string{"Destination": "/dav/folder/moved.data"})
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("put"), memfs.Data["/folder/moved.data"])
+		assert.NotContains(t, memfs.Data, "/new/put.data")
+
+		rr = request(handler, "MOVE", "/dav/folder/copy.data", nil, map[string]string{"Destination": "/dav/folder/moved.data", "Overwrite": "F"})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		body := `<?xml version="1.0" encoding="utf-8"?>
+<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockinfo>`
+		rr := request(handler, "LOCK", "/dav/folder/moved.data", strings.NewReader(body), nil)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		token := rr.Header().Get("Lock-Token")
+		assert.NotEmpty(t, token)
+
+		rr = request(handler, http.MethodPut, "/dav/folder/moved.data", strings.NewReader("locked"), nil)
+		assert.Equal(t, http.StatusLocked, rr.Code, rr.Result().Status)
+		rr = request(handler, http.MethodPut, "/dav/folder/moved.data", strings.NewReader("unlocked"), map[string]string{"If": "(" + token + ")"})
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("unlocked"), memfs.Data["/folder/moved.data"])
+
+		rr = request(handler, "UNLOCK", "/dav/folder/moved.data", nil, map[string]string{"Lock-Token": token})
+		assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rr := request(handler, http.MethodDelete, "/dav/new", nil, nil)
+		assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+		_, err := memfs.Stat(context.Background(), nil, "/new/empty.data")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+
+		rr = request(handler, http.MethodDelete, "/dav/new", nil, nil)
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+		rr = request(handler, http.MethodDelete, "/dav/", nil, nil)
+		assert.NotEqual(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+		assert.Contains(t, memfs.Data, "/folder/test.data")
+	})
+}
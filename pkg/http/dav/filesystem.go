@@ -0,0 +1,320 @@
+package dav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"golang.org/x/net/webdav"
+)
+
+// toOSError turns the storage errors into what webdav.Handler checks for, as it uses os.IsNotExist and the like
+func toOSError(op, name string, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, storage.ErrNotExist):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	case errors.Is(err, storage.ErrExist):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrExist}
+	case errors.Is(err, storage.ErrReadOnly):
+		return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+	}
+	return err
+}
+
+// fileSystem is the webdav.FileSystem of User
+type fileSystem struct {
+	Storage storage.StorageProvider
+	User    *models.User
+	// Upload is the body of a PUT request, files written to are only kept if all of it made it
+	Upload *upload
+}
+
+// upload keeps track of how much of the body of a PUT request got read, as webdav.Handler closes the
+// file it's writing to regardless of whether it was able to copy everything
+type upload struct {
+	io.ReadCloser
+	// length is the Content-Length of the request, or -1 if it's unknown
+	length int64
+	read   int64
+	err    error
+}
+
+func (u *upload) Read(p []byte) (int, error) {
+	n, err := u.ReadCloser.Read(p)
+	u.read += int64(n)
+	if err != nil && err != io.EOF {
+		u.err = err
+	}
+	return n, err
+}
+
+// complete returns whether the body was read entirely without errors
+func (u *upload) complete() bool {
+	return u.err == nil && (u.length < 0 || u.read == u.length)
+}
+
+// Mkdir only creates a single directory, unlike storage.StorageProvider it fails if the parent is missing
+// or the directory exists already
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	name = path.Clean("/" + name)
+	_, err := fs.Storage.Stat(ctx, fs.User, name)
+	if err == nil {
+		return toOSError("mkdir", name, fmt.Errorf("%w: %s", storage.ErrExist, name))
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	parent, err := fs.Storage.Stat(ctx, fs.User, path.Dir(name))
+	if err != nil {
+		return toOSError("mkdir", name, err)
+	}
+	if !parent.Directory {
+		return toOSError("mkdir", name, fmt.Errorf("%w: %s", storage.ErrNotExist, path.Dir(name)))
+	}
+
+	return toOSError("mkdir", name, fs.Storage.Mkdir(ctx, fs.User, name))
+}
+
+// OpenFile opens name for either reading or writing. Anything opened for writing gets truncated, which is all
+// that webdav.Handler ever does
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	name = path.Clean("/" + name)
+	out := &file{ctx: ctx, fs: fs, name: name}
+
+	info, err := fs.Storage.Stat(ctx, fs.User, name)
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		if err != nil {
+			return nil, toOSError("open", name, err)
+		}
+		out.info = info
+		if !info.Directory {
+			out.reader, err = storage.ReadSeeker(ctx, fs.Storage, fs.User, name, int64(info.Size))
+			if err != nil {
+				return nil, toOSError("open", name, err)
+			}
+		}
+		return out, nil
+	}
+
+	if err == nil && info.Directory {
+		return nil, toOSError("open", name, fmt.Errorf("%w: %s is a directory", storage.ErrExist, name))
+	} else if err == nil && flag&os.O_EXCL != 0 {
+		return nil, toOSError("open", name, fmt.Errorf("%w: %s", storage.ErrExist, name))
+	} else if errors.Is(err, storage.ErrNotExist) {
+		if flag&os.O_CREATE == 0 {
+			return nil, toOSError("open", name, err)
+		}
+		parent, err := fs.Storage.Stat(ctx, fs.User, path.Dir(name))
+		if err != nil {
+			return nil, toOSError("open", name, err)
+		}
+		if !parent.Directory {
+			return nil, toOSError("open", name, fmt.Errorf("%w: %s", storage.ErrNotExist, path.Dir(name)))
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	out.writer, err = fs.Storage.File(ctx, fs.User, name)
+	if err != nil {
+		return nil, toOSError("open", name, err)
+	}
+	out.info = storage.FileInfo{Name: path.Base(name), FullPath: name, UpdatedAt: time.Now()}
+	return out, nil
+}
+
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return toOSError("remove", name, fmt.Errorf("%w: %s", storage.ErrReadOnly, name))
+	}
+	return toOSError("remove", name, fs.Storage.DeleteTree(ctx, fs.User, name))
+}
+
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldName = path.Clean("/" + oldName)
+	newName = path.Clean("/" + newName)
+	if oldName == "/" || newName == "/" {
+		return toOSError("rename", oldName, fmt.Errorf("%w: %s", storage.ErrReadOnly, "/"))
+	}
+	return toOSError("rename", oldName, fs.Storage.Move(ctx, fs.User, oldName, newName))
+}
+
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	name = path.Clean("/" + name)
+	info, err := fs.Storage.Stat(ctx, fs.User, name)
+	if err != nil {
+		return nil, toOSError("stat", name, err)
+	}
+	return fileInfo{info}, nil
+}
+
+// fileInfo is a storage.FileInfo as an os.FileInfo, it provides its own ETag to webdav.Handler if it has one
+type fileInfo struct {
+	info storage.FileInfo
+}
+
+func (i fileInfo) Name() string {
+	if i.info.FullPath == "/" {
+		return "/"
+	}
+	return i.info.Name
+}
+
+func (i fileInfo) Size() int64 {
+	return int64(i.info.Size)
+}
+
+func (i fileInfo) Mode() os.FileMode {
+	if i.info.Directory {
+		return i.info.Mode | os.ModeDir
+	}
+	return i.info.Mode
+}
+
+func (i fileInfo) ModTime() time.Time {
+	return i.info.UpdatedAt
+}
+
+func (i fileInfo) IsDir() bool {
+	return i.info.Directory
+}
+
+func (i fileInfo) Sys() interface{} {
+	return nil
+}
+
+func (i fileInfo) ETag(ctx context.Context) (string, error) {
+	if i.info.ETag == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return fmt.Sprintf("%q", i.info.ETag), nil
+}
+
+// ContentType goes by the extension, webdav.Handler falls back to looking at the contents for anything else
+func (i fileInfo) ContentType(ctx context.Context) (string, error) {
+	contentType := mime.TypeByExtension(path.Ext(i.info.Name))
+	if contentType == "" {
+		return "", webdav.ErrNotImplemented
+	}
+	return contentType, nil
+}
+
+// file is a webdav.File on top of either a storage.ReadSeeker or a storage.File it writes to, directories
+// have neither
+type file struct {
+	ctx  context.Context
+	fs   *fileSystem
+	name string
+	info storage.FileInfo
+
+	reader io.ReadSeekCloser
+	// writer only ever gets written from the start
+	writer  storage.File
+	written bool
+	failed  bool
+
+	// the entries of a directory that haven't been returned by Readdir yet
+	entries []os.FileInfo
+	listed  bool
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, fmt.Errorf("%s isn't opened for reading", f.name)
+	}
+	n, err := f.reader.Read(p)
+	return n, toOSError("read", f.name, err)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if f.reader == nil {
+		return 0, fmt.Errorf("%s isn't opened for reading", f.name)
+	}
+	return f.reader.Seek(offset, whence)
+}
+
+func (f *file) Write(p []byte) (int, error) {
+	if f.writer == nil {
+		return 0, toOSError("write", f.name, fmt.Errorf("%w: %s isn't opened for writing", storage.ErrReadOnly, f.name))
+	}
+
+	f.written = true
+	n, err := f.writer.Write(p)
+	f.info.Size += uint64(n)
+	if err != nil {
+		f.failed = true
+	}
+	return n, toOSError("write", f.name, err)
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.info.Directory {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotDirectory, f.name)
+	}
+	if !f.listed {
+		files, err := f.fs.Storage.ListDirectory(f.ctx, f.fs.User, f.name)
+		if err != nil {
+			return nil, toOSError("readdir", f.name, err)
+		}
+		for info := range files {
+			f.entries = append(f.entries, fileInfo{info})
+		}
+		f.listed = true
+	}
+
+	if count <= 0 {
+		out := f.entries
+		f.entries = nil
+		return out, nil
+	}
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	out := f.entries[:count]
+	f.entries = f.entries[count:]
+	return out, nil
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return fileInfo{f.info}, nil
+}
+
+// Close creates empty files for anything opened for writing that never got written to, as providers
+// only create files on the first write. Files of an upload that got cut short are aborted instead, so
+// they don't replace what was there before
+func (f *file) Close() error {
+	if f.reader != nil {
+		return f.reader.Close()
+	}
+	if f.writer == nil {
+		return nil
+	}
+	if f.failed || (f.fs.Upload != nil && !f.fs.Upload.complete()) {
+		err := storage.Abort(f.writer)
+		if err != nil {
+			return toOSError("close", f.name, err)
+		}
+		return fmt.Errorf("Upload of %s got interrupted after %d bytes", f.name, f.info.Size)
+	}
+	if !f.written {
+		_, err := f.writer.Write(nil)
+		if err != nil {
+			storage.Abort(f.writer)
+			return toOSError("close", f.name, err)
+		}
+	}
+	return toOSError("close", f.name, f.writer.Close())
+}
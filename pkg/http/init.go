@@ -7,6 +7,8 @@ import (
 	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
 	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/admin"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/dav"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/share"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/webapi"
 	"github.com/leicht-cloud/leicht-cloud/pkg/plugin"
@@ -37,13 +39,15 @@ func InitHttpServer(
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/", &rootHandler{DB: db, StaticHandler: templateHandler})
+	mux.Handle("/", &rootHandler{DB: db, Storage: storage, StaticHandler: templateHandler})
 	mux.Handle("/login", &loginHandler{DB: db, Auth: authProvider, StaticHandler: templateHandler})
 	mux.Handle("/signup", &signupHandler{Assets: assets, DB: db, Storage: storage})
 	mux.Handle("/apps/embed/", auth.AuthHandler(apps))
 	mux.Handle("/apps/", auth.AuthHandler(&appsHandler{Apps: apps, StaticHandler: templateHandler}))
 	webapi.Init(mux, db, storage, fileinfo, apps)
-	admin.Init(mux, authProvider, templateHandler, pluginManager, db)
+	share.Init(mux, db, storage, templateHandler)
+	dav.Init(mux, db, authProvider, storage)
+	admin.Init(mux, authProvider, templateHandler, pluginManager, db, storage)
 
 	out := &http.Server{
 		Addr: addr,
@@ -6,17 +6,22 @@ import (
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/quota"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 type rootHandler struct {
 	DB            *gorm.DB
+	Storage       storage.StorageProvider
 	StaticHandler http.Handler
 }
 
 type folderTemplateData struct {
 	Navbar template.NavbarData
 	Dir    []string
+	Usage  *quota.Usage
 }
 
 func (h *rootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -49,12 +54,27 @@ func (h *rootHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	ctx := template.AttachTemplateData(r.Context(), folderTemplateData{
+	data := folderTemplateData{
 		Navbar: template.NavbarData{
 			Admin: user.Admin,
 		},
 		Dir: dirSplit,
-	})
+	}
+
+	// the quota may be wrapped in other layers, so we have to go looking for it
+	for store := h.Storage; store != nil; store = storage.Unwrap(store) {
+		if q, ok := store.(*quota.QuotaStorageProvider); ok {
+			usage, err := q.Usage(user)
+			if err != nil {
+				logrus.Error(err)
+			} else {
+				data.Usage = &usage
+			}
+			break
+		}
+	}
+
+	ctx := template.AttachTemplateData(r.Context(), data)
 
 	h.StaticHandler.ServeHTTP(w, r.WithContext(ctx))
 }
@@ -0,0 +1,297 @@
+package share
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/helper/limiter"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/webapi"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/firewall"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/utils"
+	"gorm.io/gorm"
+)
+
+// DropPrefix is where the drop links are served, followed by their token
+const DropPrefix = "/d/"
+
+// maxNameAttempts is how many numbered names are tried for an upload before giving up
+const maxNameAttempts = 1000
+
+type dropKey int
+
+var dropKeyValue dropKey
+
+type dropHandler struct {
+	DB            *gorm.DB
+	Storage       storage.StorageProvider
+	StaticHandler http.Handler
+
+	upload auth.AuthHandlerInterface
+
+	// the files currently being uploaded, these don't show up in the storage until they're done
+	mutex   sync.Mutex
+	pending map[string]bool
+	// how many uploads are in progress per link, these take up one of the MaxFiles until they're done
+	active map[uint64]int64
+}
+
+func newDropHandler(db *gorm.DB, store storage.StorageProvider, templateHandler http.Handler) *dropHandler {
+	out := &dropHandler{
+		DB:            db,
+		Storage:       store,
+		StaticHandler: templateHandler,
+		pending:       make(map[string]bool),
+		active:        make(map[uint64]int64),
+	}
+	// the upload limits of the owner apply, as it's their bandwidth being used
+	out.upload = limiter.UploadMiddleware(db, webapi.NewUploadHandler(store, out.open))
+	return out
+}
+
+type dropTemplateData struct {
+	Token string
+	Name  string
+	// MaxFileSize and Remaining are 0 if they're unlimited
+	MaxFileSize int64
+	Remaining   int64
+}
+
+func (h *dropHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.URL.Path, DropPrefix)
+	if strings.Contains(token, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	link := &models.DropLink{}
+	tx := h.DB.WithContext(r.Context()).First(link, "token = ?", token)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if tx.Error != nil {
+		shareError(w, tx.Error)
+		return
+	}
+	if link.Expired(time.Now()) {
+		http.Error(w, "This link has expired", http.StatusGone)
+		return
+	}
+
+	owner := &models.User{}
+	tx = h.DB.WithContext(r.Context()).First(owner, link.UserID)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if tx.Error != nil {
+		shareError(w, tx.Error)
+		return
+	}
+
+	// there's deliberately no way to see what's in the directory
+	if r.Method == http.MethodGet {
+		data := dropTemplateData{
+			Token:       link.Token,
+			Name:        path.Base(link.Path),
+			MaxFileSize: link.MaxFileSize,
+		}
+		if link.MaxFiles > 0 {
+			data.Remaining = link.MaxFiles - link.Uploads
+			if data.Remaining <= 0 {
+				http.Error(w, "This link doesn't accept any more files", http.StatusGone)
+				return
+			}
+		}
+
+		// internal rewrite to the drop page, so we render that
+		r.URL.Path = "/drop.gohtml"
+
+		ctx := template.AttachTemplateData(r.Context(), data)
+
+		h.StaticHandler.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	h.upload.Serve(owner, w, r.WithContext(context.WithValue(r.Context(), dropKeyValue, link)))
+}
+
+// open is where the upload handler gets its files from, every upload ends up right in the directory of the
+// link under a name that isn't taken yet
+func (h *dropHandler) open(r *http.Request, owner *models.User, filename string, length int64) (storage.File, error) {
+	link, ok := r.Context().Value(dropKeyValue).(*models.DropLink)
+	if !ok {
+		return nil, errors.New("Upload without a drop link")
+	}
+
+	// only the name is used, the uploader doesn't get to pick the directory
+	name := path.Base(path.Clean("/" + filename))
+	if name == "/" {
+		return nil, fmt.Errorf("%w: %q", storage.ErrInvalidPath, filename)
+	}
+	err := utils.ValidatePath(name)
+	if err != nil {
+		return nil, err
+	}
+	if link.MaxFileSize > 0 && length > link.MaxFileSize {
+		return nil, fmt.Errorf("%w: %s is larger than the %d bytes accepted by this link", storage.ErrQuotaExceeded, name, link.MaxFileSize)
+	}
+
+	releaseSlot, err := h.slot(r.Context(), link)
+	if err != nil {
+		return nil, err
+	}
+
+	store := firewall.Firewall(h.Storage, link.Path)
+	name, releaseName, err := h.reserve(r.Context(), store, owner, link, name)
+	if err != nil {
+		releaseSlot()
+		return nil, err
+	}
+
+	file, err := store.File(r.Context(), owner, "/"+name)
+	if err != nil {
+		releaseName()
+		releaseSlot()
+		return nil, err
+	}
+
+	out := &dropFile{
+		File:      file,
+		db:        h.DB,
+		link:      link.ID,
+		remaining: -1,
+		release: func() {
+			releaseName()
+			releaseSlot()
+		},
+	}
+	if link.MaxFileSize > 0 {
+		out.remaining = link.MaxFileSize
+	}
+	return out, nil
+}
+
+// slot takes up one of the files accepted by link for an upload, the returned function has to be called
+// once the upload is done. Only uploads that made it are counted in the database, see dropFile.Close
+func (h *dropHandler) slot(ctx context.Context, link *models.DropLink) (func(), error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if link.MaxFiles > 0 {
+		// re-read in case uploads finished since the link got loaded
+		current := &models.DropLink{}
+		err := h.DB.WithContext(ctx).Select("uploads").First(current, link.ID).Error
+		if err != nil {
+			return nil, err
+		}
+		if current.Uploads+h.active[link.ID] >= link.MaxFiles {
+			return nil, fmt.Errorf("%w: this link doesn't accept any more files", storage.ErrQuotaExceeded)
+		}
+	}
+
+	h.active[link.ID]++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mutex.Lock()
+			h.active[link.ID]--
+			if h.active[link.ID] <= 0 {
+				delete(h.active, link.ID)
+			}
+			h.mutex.Unlock()
+		})
+	}, nil
+}
+
+// reserve returns name, or name with a number appended if that's taken already by either a file in the
+// directory of link or by another upload that's still in progress. The returned function has to be called
+// once the upload is done, so the name can be picked again if the upload didn't make it
+func (h *dropHandler) reserve(ctx context.Context, store storage.StorageProvider, owner *models.User, link *models.DropLink, name string) (string, func(), error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// names like .profile are all extension
+		base, ext = name, ""
+	}
+
+	for i := 0; i < maxNameAttempts; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		key := fmt.Sprintf("%d:%s", owner.ID, path.Join(link.Path, candidate))
+		if h.pending[key] {
+			continue
+		}
+
+		_, err := store.Stat(ctx, owner, "/"+candidate)
+		if err == nil {
+			continue
+		} else if !errors.Is(err, storage.ErrNotExist) {
+			return "", nil, err
+		}
+
+		h.pending[key] = true
+		var once sync.Once
+		return candidate, func() {
+			once.Do(func() {
+				h.mutex.Lock()
+				delete(h.pending, key)
+				h.mutex.Unlock()
+			})
+		}, nil
+	}
+	return "", nil, fmt.Errorf("%w: too many files named %s", storage.ErrExist, name)
+}
+
+// dropFile refuses to grow beyond the maximum file size of the link it's uploaded through
+type dropFile struct {
+	storage.File
+	db   *gorm.DB
+	link uint64
+	// remaining is how many more bytes may be written, negative if there's no limit
+	remaining int64
+	release   func()
+}
+
+func (f *dropFile) Write(p []byte) (int, error) {
+	if f.remaining >= 0 && int64(len(p)) > f.remaining {
+		return 0, fmt.Errorf("%w: the file is larger than accepted by this link", storage.ErrQuotaExceeded)
+	}
+	n, err := f.File.Write(p)
+	if f.remaining >= 0 {
+		f.remaining -= int64(n)
+	}
+	return n, err
+}
+
+// Close counts the upload against the link once it made it, the slot is only released afterwards so
+// another upload can't take it in the meantime
+func (f *dropFile) Close() error {
+	defer f.release()
+	err := f.File.Close()
+	if err != nil {
+		return err
+	}
+	// the file is there already, so this can't use the context of the request that may be gone by now
+	return f.db.Model(&models.DropLink{}).
+		Where("id = ?", f.link).
+		UpdateColumn("uploads", gorm.Expr("uploads + 1")).Error
+}
+
+func (f *dropFile) Abort() error {
+	defer f.release()
+	return storage.Abort(f.File)
+}
@@ -0,0 +1,215 @@
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupDrop(t *testing.T, link *models.DropLink) (*dropHandler, *memory.StorageProvider) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateHandler, err := template.NewHandler(os.DirFS("../assets"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := &models.User{Email: "owner@test.com"}
+	assert.NoError(t, db.Create(owner).Error)
+	link.UserID = owner.ID
+	assert.NoError(t, db.Create(link).Error)
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/inbox"] = struct{}{}
+	memfs.Data["/inbox/report.pdf"] = []byte("existing")
+
+	return newDropHandler(db, memfs, templateHandler), memfs
+}
+
+func uploadMultipart(t *testing.T, handler http.Handler, target string, files map[string]string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for name, contents := range files {
+		part, err := writer.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = part.Write([]byte(contents))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, target, body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func startTus(t *testing.T, handler http.Handler, target, filename string, length int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, nil)
+	req.Header.Set("Upload-Length", strconv.Itoa(length))
+	req.Header.Set("Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename)))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func dropOwner(t *testing.T, handler *dropHandler) *models.User {
+	user := &models.User{}
+	assert.NoError(t, handler.DB.First(user, "email = ?", "owner@test.com").Error)
+	return user
+}
+
+func TestDropLink(t *testing.T) {
+	handler, memfs := setupDrop(t, &models.DropLink{Token: "drop", Path: "/inbox", MaxFileSize: 10, MaxFiles: 4})
+
+	t.Run("Page", func(t *testing.T) {
+		rr := get(handler, "/d/drop")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Contains(t, rr.Body.String(), `action="/d/drop"`)
+		// nothing in the directory is shown
+		assert.NotContains(t, rr.Body.String(), "report.pdf")
+
+		rr = get(handler, "/d/drop/report.pdf")
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+		rr = get(handler, "/d/missing")
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		rr := uploadMultipart(t, handler, "/d/drop", map[string]string{"../../report.pdf": "new"})
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+
+		// the existing file is left alone
+		assert.Equal(t, []byte("existing"), memfs.Data["/inbox/report.pdf"])
+		assert.Equal(t, []byte("new"), memfs.Data["/inbox/report (1).pdf"])
+	})
+
+	t.Run("Tus", func(t *testing.T) {
+		rr := startTus(t, handler, "/d/drop", "report.pdf", 3)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+
+		resume := rr.Header().Get("Location")
+		assert.Contains(t, resume, "/d/drop?resume=")
+
+		req := httptest.NewRequest(http.MethodPatch, resume, bytes.NewBufferString("tus"))
+		req.Header.Set("Upload-Offset", "0")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("tus"), memfs.Data["/inbox/report (2).pdf"])
+	})
+
+	t.Run("MaxFileSize", func(t *testing.T) {
+		rr := uploadMultipart(t, handler, "/d/drop", map[string]string{"large.data": "more than ten bytes"})
+		assert.Equal(t, http.StatusInsufficientStorage, rr.Code, rr.Result().Status)
+		assert.NotContains(t, memfs.Data, "/inbox/large.data")
+
+		rr = startTus(t, handler, "/d/drop", "large.data", 11)
+		assert.Equal(t, http.StatusInsufficientStorage, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("OtherLink", func(t *testing.T) {
+		other := &models.DropLink{Token: "other", Path: "/inbox", UserID: dropOwner(t, handler).ID}
+		assert.NoError(t, handler.DB.Create(other).Error)
+
+		rr := startTus(t, handler, "/d/other", "other.data", 4)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		resume := rr.Header().Get("Location")
+
+		// the owner is the same, the upload still can't be touched through another link
+		req := httptest.NewRequest(http.MethodHead, strings.Replace(resume, "/d/other", "/d/drop", 1), nil)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+		req = httptest.NewRequest(http.MethodHead, resume, nil)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("MaxFiles", func(t *testing.T) {
+		// the failed upload of large.data doesn't count, the tus upload in progress does
+		rr := startTus(t, handler, "/d/drop", "pending.data", 4)
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		resume := rr.Header().Get("Location")
+
+		rr = uploadMultipart(t, handler, "/d/drop", map[string]string{"last.data": "last"})
+		assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+		rr = uploadMultipart(t, handler, "/d/drop", map[string]string{"another.data": "another"})
+		assert.Equal(t, http.StatusInsufficientStorage, rr.Code, rr.Result().Status)
+		assert.NotContains(t, memfs.Data, "/inbox/another.data")
+
+		rr = get(handler, "/d/drop")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+		req := httptest.NewRequest(http.MethodPatch, resume, bytes.NewBufferString("done"))
+		req.Header.Set("Upload-Offset", "0")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, []byte("done"), memfs.Data["/inbox/pending.data"])
+
+		rr = get(handler, "/d/drop")
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		assert.NoError(t, handler.DB.Model(&models.DropLink{}).Where("token = ?", "drop").Update("expires_at", &expired).Error)
+		rr := uploadMultipart(t, handler, "/d/drop", map[string]string{"late.data": "late"})
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+	})
+}
+
+func TestDropLinkReserve(t *testing.T) {
+	ctx := context.Background()
+	link := &models.DropLink{Token: "drop", Path: "/inbox"}
+	handler, _ := setupDrop(t, link)
+	owner := &models.User{ID: link.UserID}
+
+	names := make([]string, 0, 3)
+	releases := make([]func(), 0, 3)
+	for i := 0; i < 3; i++ {
+		name, release, err := handler.reserve(ctx, handler.Storage, owner, link, ".profile")
+		if assert.NoError(t, err) {
+			names = append(names, name)
+			releases = append(releases, release)
+		}
+	}
+	// uploads in progress don't show up in the storage yet, they still have to get a name of their own
+	assert.Equal(t, []string{".profile", ".profile (1)", ".profile (2)"}, names)
+
+	for _, release := range releases {
+		release()
+		release()
+	}
+	name, _, err := handler.reserve(ctx, handler.Storage, owner, link, ".profile")
+	assert.NoError(t, err)
+	assert.Equal(t, ".profile", name)
+}
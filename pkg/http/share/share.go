@@ -0,0 +1,394 @@
+package share
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/helper/limiter"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/webapi"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/firewall"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Prefix is where the share links are served, followed by their token
+const Prefix = "/s/"
+
+// Init registers the public side of the share and drop links, these don't require logging in
+func Init(mux *http.ServeMux, db *gorm.DB, store storage.StorageProvider, templateHandler http.Handler) {
+	mux.Handle(Prefix, newLinkHandler(db, store, templateHandler))
+	mux.Handle(DropPrefix, newDropHandler(db, store, templateHandler))
+}
+
+const (
+	// maxLinkFailures is how many wrong passwords a single link gets within the window of the throttle
+	maxLinkFailures = 10
+	// maxAddressFailures is how many wrong passwords a single address gets, no matter for which links
+	maxAddressFailures = 20
+	// partialWindow is how long the parts of a file downloaded by a client are added up, download
+	// managers fetching a file in ranges are done well within that
+	partialWindow = time.Hour
+)
+
+type linkHandler struct {
+	DB            *gorm.DB
+	Storage       storage.StorageProvider
+	StaticHandler http.Handler
+
+	throttle *auth.Throttle
+
+	// how much of a file every client got so far through downloads that didn't cover all of it
+	mutex   sync.Mutex
+	partial map[string]*partial
+	clock   func() time.Time
+}
+
+// partial is how many bytes of a file a single client downloaded since a point in time
+type partial struct {
+	served int64
+	since  time.Time
+}
+
+func newLinkHandler(db *gorm.DB, store storage.StorageProvider, templateHandler http.Handler) *linkHandler {
+	return &linkHandler{
+		DB:            db,
+		Storage:       store,
+		StaticHandler: templateHandler,
+		throttle:      auth.NewThrottle(),
+		partial:       make(map[string]*partial),
+		clock:         time.Now,
+	}
+}
+
+type linkEntry struct {
+	Name      string
+	Href      string
+	Directory bool
+	Size      int64
+	UpdatedAt time.Time
+}
+
+type linkTemplateData struct {
+	Token string
+	// Name is the name of whatever got shared, Dir the directory within it that's being listed
+	Name  string
+	Dir   string
+	Up    string
+	Files []linkEntry
+
+	// PasswordRequired shows the password form instead of the listing, WrongPassword if one was tried already
+	PasswordRequired bool
+	WrongPassword    bool
+}
+
+// href returns the url of fullpath within the share of token
+func href(token, fullpath string) string {
+	out := Prefix + token
+	for _, part := range strings.Split(fullpath, "/") {
+		if part != "" {
+			out += "/" + url.PathEscape(part)
+		}
+	}
+	return out
+}
+
+// unlockValue is what the cookie of a link with a password is set to once the password has been entered,
+// this can't be forged without knowing the hash of the password and changes whenever the password does
+func unlockValue(link *models.ShareLink) string {
+	sum := sha256.Sum256(append([]byte(link.Token+"\x00"), link.PasswordHash...))
+	return hex.EncodeToString(sum[:])
+}
+
+func unlocked(r *http.Request, link *models.ShareLink) bool {
+	cookie, err := r.Cookie("share")
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(unlockValue(link))) == 1
+}
+
+// shareError replies with a status matching err, without telling anything about the storage behind the link
+func shareError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrNotExist), errors.Is(err, storage.ErrNotDirectory), errors.Is(err, storage.ErrInvalidPath):
+		http.Error(w, "Not found", http.StatusNotFound)
+	default:
+		logrus.Error(err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *linkHandler) render(w http.ResponseWriter, r *http.Request, data linkTemplateData) {
+	// internal rewrite to the share page, so we render that
+	r.URL.Path = "/share.gohtml"
+
+	ctx := template.AttachTemplateData(r.Context(), data)
+
+	h.StaticHandler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+func (h *linkHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, Prefix)
+	fullpath := "/"
+	if i := strings.IndexByte(token, '/'); i >= 0 {
+		token, fullpath = token[:i], path.Clean(token[i:])
+	}
+
+	link := &models.ShareLink{}
+	tx := h.DB.WithContext(r.Context()).First(link, "token = ?", token)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if tx.Error != nil {
+		shareError(w, tx.Error)
+		return
+	}
+	if link.Expired(time.Now()) {
+		http.Error(w, "This link has expired", http.StatusGone)
+		return
+	}
+
+	if link.HasPassword() && !unlocked(r, link) {
+		h.servePassword(w, r, link)
+		return
+	}
+
+	owner := &models.User{}
+	tx = h.DB.WithContext(r.Context()).First(owner, link.UserID)
+	if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+		http.NotFound(w, r)
+		return
+	} else if tx.Error != nil {
+		shareError(w, tx.Error)
+		return
+	}
+
+	// nothing outside of the shared path is reachable, and nothing can be changed
+	store := firewall.Firewall(storage.ReadOnly(h.Storage), link.Path)
+	info, err := store.Stat(r.Context(), owner, fullpath)
+	if err != nil {
+		shareError(w, err)
+		return
+	}
+
+	if info.Directory {
+		h.serveDirectory(w, r, link, owner, store, fullpath)
+		return
+	}
+
+	// the download is counted up front so the limit can't be exceeded by downloading concurrently, it's
+	// given back later on if it doesn't turn out to be a full download
+	if r.Method == http.MethodGet {
+		tx = h.DB.WithContext(r.Context()).
+			Model(&models.ShareLink{}).
+			Where("id = ? AND (max_downloads = 0 OR downloads < max_downloads)", link.ID).
+			UpdateColumn("downloads", gorm.Expr("downloads + 1"))
+		if tx.Error != nil {
+			shareError(w, tx.Error)
+			return
+		}
+		if tx.RowsAffected == 0 {
+			http.Error(w, "This link reached its download limit", http.StatusGone)
+			return
+		}
+	}
+
+	// the download limits of the owner apply, as it's their bandwidth being used
+	limiter.DownloadMiddleware(h.DB, &fileDownload{
+		DB:       h.DB,
+		Link:     link,
+		Storage:  store,
+		Info:     info,
+		Fullpath: fullpath,
+		Name:     path.Base(path.Join(link.Path, fullpath)),
+		Complete: h.complete,
+	}).Serve(owner, w, r)
+}
+
+func (h *linkHandler) servePassword(w http.ResponseWriter, r *http.Request, link *models.ShareLink) {
+	data := linkTemplateData{
+		Token:            link.Token,
+		Name:             path.Base(link.Path),
+		PasswordRequired: true,
+	}
+
+	if r.Method == http.MethodPost {
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		linkKey := "link:" + link.Token
+		addressKey := "address:" + auth.ClientAddress(r)
+		if !h.throttle.Allowed(linkKey, maxLinkFailures) || !h.throttle.Allowed(addressKey, maxAddressFailures) {
+			http.Error(w, auth.ErrThrottled.Error(), http.StatusTooManyRequests)
+			return
+		}
+
+		err = bcrypt.CompareHashAndPassword(link.PasswordHash, []byte(r.Form.Get("password")))
+		if err == nil {
+			h.throttle.Reset(linkKey)
+			http.SetCookie(w, &http.Cookie{
+				Name:     "share",
+				Value:    unlockValue(link),
+				Path:     Prefix + link.Token,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, r.URL.EscapedPath(), http.StatusSeeOther)
+			return
+		}
+		h.throttle.Fail(linkKey, addressKey)
+		data.WrongPassword = true
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusForbidden)
+	h.render(w, r, data)
+}
+
+func (h *linkHandler) serveDirectory(w http.ResponseWriter, r *http.Request, link *models.ShareLink, owner *models.User, store storage.StorageProvider, dir string) {
+	files, err := storage.List(r.Context(), store, owner, dir, storage.ListOptions{Sort: storage.SortName})
+	if err != nil {
+		shareError(w, err)
+		return
+	}
+
+	data := linkTemplateData{
+		Token: link.Token,
+		Name:  path.Base(link.Path),
+		Dir:   dir,
+		Files: []linkEntry{},
+	}
+	if dir != "/" {
+		data.Up = href(link.Token, path.Dir(dir))
+	}
+	for file := range files {
+		data.Files = append(data.Files, linkEntry{
+			Name:      file.Name,
+			Href:      href(link.Token, path.Join(dir, file.Name)),
+			Directory: file.Directory,
+			Size:      int64(file.Size),
+			UpdatedAt: file.UpdatedAt,
+		})
+	}
+
+	h.render(w, r, data)
+}
+
+type fileDownload struct {
+	DB       *gorm.DB
+	Link     *models.ShareLink
+	Storage  storage.StorageProvider
+	Info     storage.FileInfo
+	Fullpath string
+	Name     string
+	// Complete is told how much of the file got served, see linkHandler.complete
+	Complete func(r *http.Request, size, served int64) bool
+}
+
+// Serve leaves ranges and conditional requests to http.ServeContent just like /webapi/download does. A GET
+// counts as a download once the client got the whole file, be it at once or in ranges, anything else gives
+// back what was counted
+func (d *fileDownload) Serve(owner *models.User, w http.ResponseWriter, r *http.Request) {
+	counted := r.Method == http.MethodGet
+	out := &downloadWriter{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		if !counted {
+			return
+		}
+		served := out.status == http.StatusOK || out.status == http.StatusPartialContent
+		if !served || !d.Complete(r, int64(d.Info.Size), out.written) {
+			d.uncount()
+		}
+	}()
+
+	file, err := storage.ReadSeeker(r.Context(), d.Storage, owner, d.Fullpath, int64(d.Info.Size))
+	if err != nil {
+		shareError(out, err)
+		return
+	}
+	defer file.Close()
+
+	out.Header().Set("Content-Disposition", webapi.ContentDisposition("attachment", d.Name))
+	if etag := webapi.ETag(d.Info); etag != "" {
+		out.Header().Set("ETag", etag)
+	}
+
+	http.ServeContent(out, r, d.Name, d.Info.UpdatedAt, file)
+}
+
+// complete adds served bytes to what the client of r downloaded of the file behind its path so far, returning
+// whether that covers all of the file. The parts are forgotten once they add up or after partialWindow
+func (h *linkHandler) complete(r *http.Request, size, served int64) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := h.clock()
+	for key, entry := range h.partial {
+		if now.Sub(entry.since) >= partialWindow {
+			delete(h.partial, key)
+		}
+	}
+
+	key := auth.ClientAddress(r) + " " + r.URL.Path
+	entry, ok := h.partial[key]
+	if !ok {
+		entry = &partial{since: now}
+		h.partial[key] = entry
+	}
+	entry.served += served
+	if entry.served < size {
+		return false
+	}
+	delete(h.partial, key)
+	return true
+}
+
+// uncount takes back a download counted before serving it. The request may be gone already if the download
+// got cut short, so its context isn't used
+func (d *fileDownload) uncount() {
+	err := d.DB.Model(&models.ShareLink{}).
+		Where("id = ? AND downloads > 0", d.Link.ID).
+		UpdateColumn("downloads", gorm.Expr("downloads - 1")).Error
+	if err != nil {
+		logrus.Error(err)
+	}
+}
+
+// downloadWriter keeps track of the status and how much of the body got written
+type downloadWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (w *downloadWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
@@ -0,0 +1,244 @@
+package share
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/http/template"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setup(t *testing.T) (*linkHandler, *models.User) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	templateHandler, err := template.NewHandler(os.DirFS("../assets"), nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := &models.User{Email: "owner@test.com"}
+	assert.NoError(t, db.Create(owner).Error)
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/folder/sub dir"] = struct{}{}
+	memfs.Data["/folder/test.data"] = []byte("test")
+	memfs.Data["/folder/sub dir/nested.data"] = []byte("nested")
+	memfs.Data["/folder/\"quoted\" ü.data"] = []byte("quoted")
+	memfs.Data["/secret.data"] = []byte("secret")
+
+	return newLinkHandler(db, memfs, templateHandler), owner
+}
+
+func get(handler http.Handler, target string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestShareLink(t *testing.T) {
+	handler, owner := setup(t)
+
+	folder := &models.ShareLink{Token: "folder", UserID: owner.ID, Path: "/folder"}
+	file := &models.ShareLink{Token: "file", UserID: owner.ID, Path: "/folder/test.data", MaxDownloads: 2}
+	assert.NoError(t, handler.DB.Create(folder).Error)
+	assert.NoError(t, handler.DB.Create(file).Error)
+
+	t.Run("List", func(t *testing.T) {
+		rr := get(handler, "/s/folder")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Contains(t, rr.Body.String(), `href="/s/folder/test.data"`)
+		assert.Contains(t, rr.Body.String(), `href="/s/folder/sub%20dir"`)
+
+		rr = get(handler, "/s/folder/sub%20dir")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Contains(t, rr.Body.String(), `href="/s/folder/sub%20dir/nested.data"`)
+		assert.Contains(t, rr.Body.String(), `href="/s/folder"`)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		rr := get(handler, "/s/folder/sub%20dir/nested.data")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "nested", rr.Body.String())
+		assert.Equal(t, "attachment; filename=\"nested.data\"", rr.Header().Get("Content-Disposition"))
+
+		rr = get(handler, "/s/folder/"+url.PathEscape(`"quoted" ü.data`))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, `attachment; filename="_quoted_ _.data"; filename*=UTF-8''%22quoted%22%20%C3%BC.data`, rr.Header().Get("Content-Disposition"))
+
+		rr = get(handler, "/s/file")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "test", rr.Body.String())
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+	})
+
+	t.Run("Partial", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/s/file", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		etag := rr.Header().Get("ETag")
+
+		req := httptest.NewRequest(http.MethodGet, "/s/file", nil)
+		req.Header.Set("Range", "bytes=1-2")
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, "es", rr.Body.String())
+
+		req = httptest.NewRequest(http.MethodGet, "/s/file", nil)
+		req.Header.Set("If-None-Match", etag)
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotModified, rr.Code, rr.Result().Status)
+
+		// none of these is a whole download, so only the one in Download counts
+		assert.NoError(t, handler.DB.First(file, file.ID).Error)
+		assert.Equal(t, int64(1), file.Downloads)
+	})
+
+	t.Run("Ranges", func(t *testing.T) {
+		whole := &models.ShareLink{Token: "whole", UserID: owner.ID, Path: "/folder/test.data", MaxDownloads: 1}
+		pieces := &models.ShareLink{Token: "pieces", UserID: owner.ID, Path: "/folder/test.data", MaxDownloads: 1}
+		assert.NoError(t, handler.DB.Create(whole).Error)
+		assert.NoError(t, handler.DB.Create(pieces).Error)
+
+		ranged := func(target, ranges string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("Range", ranges)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			return rr
+		}
+
+		// an open range is all of the file
+		rr := ranged("/s/whole", "bytes=0-")
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, "test", rr.Body.String())
+		rr = get(handler, "/s/whole")
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+
+		// and so are ranges adding up to it
+		rr = ranged("/s/pieces", "bytes=0-1")
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.NoError(t, handler.DB.First(pieces, pieces.ID).Error)
+		assert.Equal(t, int64(0), pieces.Downloads)
+		rr = ranged("/s/pieces", "bytes=2-3")
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, "st", rr.Body.String())
+		assert.NoError(t, handler.DB.First(pieces, pieces.ID).Error)
+		assert.Equal(t, int64(1), pieces.Downloads)
+		rr = ranged("/s/pieces", "bytes=0-1")
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Confined", func(t *testing.T) {
+		for _, target := range []string{"/s/folder/../secret.data", "/s/folder/missing.data", "/s/file/test.data", "/s/missing"} {
+			rr := get(handler, target)
+			assert.Equal(t, http.StatusNotFound, rr.Code, target)
+			assert.NotContains(t, rr.Body.String(), "secret")
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/s/folder/test.data", strings.NewReader("changed")))
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("DownloadLimit", func(t *testing.T) {
+		// one of the two downloads was used up already
+		rr := get(handler, "/s/file")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		rr = get(handler, "/s/file")
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+
+		assert.NoError(t, handler.DB.First(file, file.ID).Error)
+		assert.Equal(t, int64(2), file.Downloads)
+
+		// listings don't count
+		assert.NoError(t, handler.DB.Model(file).Update("path", "/folder").Error)
+		rr = get(handler, "/s/file")
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		expired := time.Now().Add(-time.Minute)
+		assert.NoError(t, handler.DB.Model(folder).Update("expires_at", &expired).Error)
+		rr := get(handler, "/s/folder")
+		assert.Equal(t, http.StatusGone, rr.Code, rr.Result().Status)
+	})
+}
+
+func TestShareLinkPassword(t *testing.T) {
+	handler, owner := setup(t)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := &models.ShareLink{Token: "protected", UserID: owner.ID, Path: "/folder", PasswordHash: hash}
+	assert.NoError(t, handler.DB.Create(link).Error)
+
+	rr := get(handler, "/s/protected/test.data")
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Result().Status)
+	assert.Contains(t, rr.Body.String(), `name="password"`)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		form := url.Values{"password": {password}}
+		req := httptest.NewRequest(http.MethodPost, "/s/protected/test.data", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr = login("wrong")
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Result().Status)
+	assert.Contains(t, rr.Body.String(), "Wrong password")
+	assert.Empty(t, rr.Result().Cookies())
+
+	rr = login("secret")
+	assert.Equal(t, http.StatusSeeOther, rr.Code, rr.Result().Status)
+	assert.Equal(t, "/s/protected/test.data", rr.Header().Get("Location"))
+	cookies := rr.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "/s/protected", cookies[0].Path)
+
+		rr = get(handler, "/s/protected/test.data", cookies[0])
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "test", rr.Body.String())
+	}
+
+	rr = get(handler, "/s/protected/test.data", &http.Cookie{Name: "share", Value: "forged"})
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Result().Status)
+
+	// guessing gets cut off, even for the right password
+	for i := 0; i < maxLinkFailures; i++ {
+		rr = login("wrong")
+		assert.Equal(t, http.StatusForbidden, rr.Code, rr.Result().Status)
+	}
+	rr = login("secret")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, rr.Result().Status)
+	assert.Empty(t, rr.Result().Cookies())
+}
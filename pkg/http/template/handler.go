@@ -4,7 +4,9 @@ import (
 	"context"
 	"html/template"
 	"io/fs"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type TemplateHandler struct {
@@ -31,9 +33,10 @@ func createFuncMap(assets fs.FS, apps, plugins []string) (out template.FuncMap,
 	}()
 
 	out = template.FuncMap{
-		"apps":    func() []string { return apps },
-		"plugins": func() []string { return plugins },
-		"add":     func(a, b int) int { return a + b },
+		"apps":      func() []string { return apps },
+		"plugins":   func() []string { return plugins },
+		"add":       func(a, b int) int { return a + b },
+		"humansize": humanSize,
 		"notnil": func(data interface{}) bool {
 			return data != nil
 		},
@@ -46,6 +49,19 @@ func createFuncMap(assets fs.FS, apps, plugins []string) (out template.FuncMap,
 	return out, err
 }
 
+// humanSize formats size the same way humanFileSize in folder.js does
+func humanSize(size int64) string {
+	units := []string{"B", "kB", "MB", "GB", "TB"}
+
+	value := float64(size)
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return strconv.FormatFloat(math.Round(value*100)/100, 'f', -1, 64) + " " + units[i]
+}
+
 type templateDataKey int
 
 var templateDataKeyValue templateDataKey
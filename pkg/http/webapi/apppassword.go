@@ -0,0 +1,124 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// newAppPassword is what's returned when creating an app password, the only time the password itself is known
+type newAppPassword struct {
+	models.AppPassword
+	Password string `json:"password"`
+}
+
+type appPasswordHandler struct {
+	DB *gorm.DB
+}
+
+func newAppPasswordHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&appPasswordHandler{DB: db})
+}
+
+// Serve returns the app passwords of the user as a json array on GET, a POST creates a new one called name.
+// The generated password is only ever returned in the response to the POST
+func (h *appPasswordHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		passwords := []models.AppPassword{}
+		tx := h.DB.WithContext(r.Context()).Order("created_at").Find(&passwords, "user_id = ?", user.ID)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(passwords)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	case http.MethodPost:
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		out := newAppPassword{
+			AppPassword: models.AppPassword{
+				UserID: user.ID,
+				Name:   r.Form.Get("name"),
+			},
+		}
+		if out.Name == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+
+		out.Password, err = newShareToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out.Hash = models.HashAppPassword(out.Password)
+		tx := h.DB.WithContext(r.Context()).Create(&out.AppPassword)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(out)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+type appPasswordRevokeHandler struct {
+	DB *gorm.DB
+}
+
+func newAppPasswordRevokeHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&appPasswordRevokeHandler{DB: db})
+}
+
+// Serve deletes the app password with id, after which it can't be used to log in anymore
+func (h *appPasswordRevokeHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	// other users their passwords are treated as missing
+	tx := h.DB.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, user.ID).Delete(&models.AppPassword{})
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		http.Error(w, "No such app password", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAppPassword(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+	other := &models.User{
+		ID: 42,
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &appPasswordHandler{DB: db}
+	revoke := &appPasswordRevokeHandler{DB: db}
+
+	post := func(handler interface {
+		Serve(*models.User, http.ResponseWriter, *http.Request)
+	}, user *models.User, target string, form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	list := func(user *models.User) string {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/apppassword", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		return rr.Body.String()
+	}
+
+	rr := post(handler, user, "/webapi/apppassword", url.Values{"name": {"phone"}})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+	var created newAppPassword
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+	assert.Equal(t, "phone", created.Name)
+	assert.Len(t, created.Password, 43)
+
+	stored := &models.AppPassword{}
+	assert.NoError(t, db.First(stored, created.ID).Error)
+	assert.Equal(t, models.HashAppPassword(created.Password), stored.Hash)
+
+	rr = post(handler, user, "/webapi/apppassword", url.Values{})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+
+	// the password itself is never listed
+	listed := list(user)
+	assert.Contains(t, listed, "phone")
+	assert.NotContains(t, listed, created.Password)
+	assert.Equal(t, "[]\n", list(other))
+
+	id := strconv.FormatUint(created.ID, 10)
+	rr = post(revoke, other, "/webapi/apppassword/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(revoke, user, "/webapi/apppassword/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.Equal(t, "[]\n", list(user))
+}
@@ -0,0 +1,47 @@
+package webapi
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+type copyHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newCopyHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&copyHandler{Storage: store})
+}
+
+func (h *copyHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	src := r.Form.Get("src")
+	dst := r.Form.Get("dst")
+	if src == "" || dst == "" {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	err = storage.Copy(r.Context(), h.Storage, user, src, dst)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/?dir=%s", path.Dir(dst)), http.StatusTemporaryRedirect)
+}
@@ -0,0 +1,50 @@
+package webapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/test.data"] = []byte("test")
+
+	handler := &copyHandler{
+		Storage: memfs,
+	}
+
+	copyFile := func(src, dst string) *httptest.ResponseRecorder {
+		form := url.Values{"src": {src}, "dst": {dst}}
+		req, err := http.NewRequest(http.MethodPost, "/webapi/copy", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	rr := copyFile("/folder/test.data", "/copy.data")
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+	assert.Equal(t, []byte("test"), memfs.Data["/copy.data"])
+	assert.Equal(t, []byte("test"), memfs.Data["/folder/test.data"])
+
+	rr = copyFile("/folder/test.data", "/copy.data")
+	assert.Equal(t, http.StatusConflict, rr.Code, rr.Result().Status)
+
+	rr = copyFile("/folder/missing.data", "/other.data")
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+}
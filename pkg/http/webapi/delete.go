@@ -46,14 +46,14 @@ func (h *deleteHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 			}
 		}
 
-		delErr := h.Storage.Delete(r.Context(), user, file)
+		delErr := h.Storage.DeleteTree(r.Context(), user, file)
 		if delErr != nil {
 			err = multierr.Append(err, delErr)
 		}
 	}
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		storageError(w, err)
 		return
 	}
 
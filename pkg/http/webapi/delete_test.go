@@ -0,0 +1,42 @@
+package webapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteDirectory(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/nested/test.data"] = []byte("test")
+	memfs.Data["/folder/test.data"] = []byte("test")
+	memfs.Data["/other.data"] = []byte("test")
+
+	handler := &deleteHandler{
+		Storage: memfs,
+	}
+
+	form := url.Values{"file": {"/folder"}}
+	req, err := http.NewRequest(http.MethodPost, "/webapi/delete", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rr := httptest.NewRecorder()
+
+	handler.Serve(user, rr, req)
+
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+	assert.Equal(t, map[string][]byte{"/other.data": []byte("test")}, memfs.Data)
+}
@@ -3,10 +3,13 @@ package webapi
 import (
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
-	"path/filepath"
+	"path"
+	"strings"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
 	_ "github.com/leicht-cloud/leicht-cloud/pkg/fileinfo/builtin"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/helper/limiter"
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
@@ -17,34 +20,116 @@ import (
 
 type downloadHandler struct {
 	Storage storage.StorageProvider
+	// FileInfo determines the Content-Type from the contents, without it that only goes by the extension
+	FileInfo *fileinfo.Manager
 }
 
-func newDownloadHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
+func newDownloadHandler(db *gorm.DB, store storage.StorageProvider, fileinfo *fileinfo.Manager) http.Handler {
 	return auth.AuthHandler(
 		limiter.DownloadMiddleware(db,
 			&downloadHandler{
-				Storage: store,
+				Storage:  store,
+				FileInfo: fileinfo,
 			},
 		),
 	)
 }
 
+// Serve leaves ranges and conditional requests to http.ServeContent, based on the ETag we set and the
+// modification time
 func (h *downloadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
-	path := r.URL.Query().Get("filename")
+	fullpath := r.URL.Query().Get("filename")
 
-	file, err := h.Storage.File(r.Context(), user, path)
+	info, err := h.Storage.Stat(r.Context(), user, fullpath)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		storageError(w, err)
+		return
+	}
+	if info.Directory {
+		http.Error(w, "Can't download a directory", http.StatusBadRequest)
+		return
+	}
+
+	file, err := storage.ReadSeeker(r.Context(), h.Storage, user, fullpath, int64(info.Size))
+	if err != nil {
+		storageError(w, err)
 		return
 	}
 	defer file.Close()
 
-	filename := filepath.Base(path)
+	filename := path.Base(path.Clean("/" + fullpath))
 
-	w.Header().Add("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Header().Set("Content-Type", h.contentType(filename, file))
+	w.Header().Set("Content-Disposition", ContentDisposition("attachment", filename))
+	if etag := ETag(info); etag != "" {
+		w.Header().Set("ETag", etag)
+	}
 
-	_, err = io.Copy(w, file)
-	if err != nil {
-		logrus.Error(err)
+	http.ServeContent(w, r, filename, info.UpdatedAt, file)
+}
+
+// contentType determines the mime type of file, leaving it at the start again afterwards. The extension
+// is used for anything the mime provider doesn't recognize, like most text based formats
+func (h *downloadHandler) contentType(filename string, file io.ReadSeeker) string {
+	if h.FileInfo != nil {
+		mimeType, err := h.FileInfo.MimeType(filename, file)
+		if err != nil {
+			logrus.Errorf("Error %s while determining the mime type of %s", err, filename)
+		}
+		_, seekErr := file.Seek(0, io.SeekStart)
+		if seekErr != nil {
+			logrus.Error(seekErr)
+		}
+		if err == nil && mimeType.String() != "application/octet-stream" {
+			return mimeType.String()
+		}
+	}
+
+	if contentType := mime.TypeByExtension(path.Ext(filename)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// ETag returns the quoted ETag of info, providers that don't have one get one based on the size and
+// modification time. It's empty if there's neither
+func ETag(info storage.FileInfo) string {
+	if info.ETag != "" {
+		return `"` + info.ETag + `"`
+	}
+	if info.UpdatedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(`"%x-%x"`, info.UpdatedAt.UnixNano(), info.Size)
+}
+
+// ContentDisposition returns the Content-Disposition header for filename as described in RFC 6266. Names
+// that aren't plain ASCII get an ASCII fallback in filename along with the UTF-8 encoded name in filename*
+func ContentDisposition(disposition, filename string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r >= 0x7f || r == '"' || r == '\\' || r == '%' {
+			return '_'
+		}
+		return r
+	}, filename)
+	if fallback == filename {
+		return fmt.Sprintf(`%s; filename="%s"`, disposition, filename)
+	}
+
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback, encodeExtValue(filename))
+}
+
+// encodeExtValue percent-encodes every byte of value that isn't an attr-char of RFC 5987. None of the
+// escaping functions of net/url fits, as each of them leaves a few other characters alone
+func encodeExtValue(value string) string {
+	var out strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			out.WriteByte(c)
+		} else {
+			fmt.Fprintf(&out, "%%%02X", c)
+		}
 	}
+	return out.String()
 }
@@ -2,14 +2,18 @@ package webapi
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/prometheus"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
 	"github.com/stretchr/testify/assert"
 )
@@ -44,9 +48,118 @@ func TestDownload(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
 	assert.Equal(t, "attachment; filename=\"test.data\"", rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, fmt.Sprintf("%d", length), rr.Header().Get("Content-Length"))
 
 	read, err := io.ReadAll(rr.Body)
 	if assert.NoError(t, err) {
 		assert.Equal(t, raw, read)
 	}
 }
+
+func TestDownloadNotFound(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	handler := &downloadHandler{
+		Storage: memory.NewStorageProvider(),
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "/webapi/download?filename=/does/not/exist", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+
+	handler.Serve(user, rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+}
+
+func TestDownloadHeaders(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	prom, err := (&prometheus.Config{Enabled: false}).Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := fileinfo.NewManager(nil, prom, "gonative")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memfs := memory.NewStorageProvider()
+	handler := &downloadHandler{
+		Storage:  memfs,
+		FileInfo: manager,
+	}
+
+	// a png header, which gets recognized by its contents rather than the extension
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+	memfs.Data["/image.data"] = png
+	memfs.Data["/notes.txt"] = []byte("0123456789")
+	memfs.Data["/Grüße \"2021\".txt"] = []byte("hallo")
+
+	get := func(filename string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/webapi/download?filename="+url.QueryEscape(filename), nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	t.Run("ContentType", func(t *testing.T) {
+		rr := get("/image.data", nil)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, "image/png", rr.Header().Get("Content-Type"))
+		// determining the type doesn't eat into the contents
+		assert.Equal(t, png, rr.Body.Bytes())
+
+		rr = get("/notes.txt", nil)
+		assert.Equal(t, "text/plain; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Equal(t, "10", rr.Header().Get("Content-Length"))
+		assert.NotEmpty(t, rr.Header().Get("ETag"))
+		assert.Equal(t, "bytes", rr.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		rr := get("/notes.txt", map[string]string{"Range": "bytes=2-4"})
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Equal(t, "234", rr.Body.String())
+		assert.Equal(t, "bytes 2-4/10", rr.Header().Get("Content-Range"))
+
+		rr = get("/notes.txt", map[string]string{"Range": "bytes=7-,0-1"})
+		assert.Equal(t, http.StatusPartialContent, rr.Code, rr.Result().Status)
+		assert.Contains(t, rr.Header().Get("Content-Type"), "multipart/byteranges")
+		assert.Contains(t, rr.Body.String(), "789")
+		assert.Contains(t, rr.Body.String(), "01")
+
+		rr = get("/notes.txt", map[string]string{"Range": "bytes=20-"})
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Conditional", func(t *testing.T) {
+		etag := get("/notes.txt", nil).Header().Get("ETag")
+		rr := get("/notes.txt", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rr.Code, rr.Result().Status)
+		assert.Empty(t, rr.Body.String())
+
+		rr = get("/notes.txt", map[string]string{"If-None-Match": `"something-else"`})
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Filename", func(t *testing.T) {
+		rr := get("/Grüße \"2021\".txt", nil)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, `attachment; filename="Gr__e _2021_.txt"; filename*=UTF-8''Gr%C3%BC%C3%9Fe%20%222021%22.txt`, rr.Header().Get("Content-Disposition"))
+
+		// only attr-chars are left as they are in filename*
+		assert.Equal(t, `inline; filename="_ (1)'s*,;!$&+:=@.txt"; filename*=UTF-8''%C3%BC%20%281%29%27s%2A%2C%3B!$&+%3A%3D%40.txt`,
+			ContentDisposition("inline", "ü (1)'s*,;!$&+:=@.txt"))
+	})
+}
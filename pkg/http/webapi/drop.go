@@ -0,0 +1,142 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type dropHandler struct {
+	DB      *gorm.DB
+	Storage storage.StorageProvider
+}
+
+func newDropHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&dropHandler{DB: db, Storage: store})
+}
+
+// Serve returns the drop links of the user as a json array on GET, a POST creates a new link uploading into
+// the directory path. The optional expires is an RFC 3339 timestamp, max_file_size and max_files are optional as well
+func (h *dropHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		links := []models.DropLink{}
+		tx := h.DB.WithContext(r.Context()).Order("created_at").Find(&links, "user_id = ?", user.ID)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(links)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	case http.MethodPost:
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		link := &models.DropLink{
+			UserID: user.ID,
+			Path:   r.Form.Get("path"),
+		}
+		if link.Path == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+		link.ExpiresAt, err = formExpiry(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		link.MaxFileSize, err = formLimit(r, "max_file_size")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		link.MaxFiles, err = formLimit(r, "max_files")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		info, err := h.Storage.Stat(r.Context(), user, link.Path)
+		if err != nil {
+			storageError(w, err)
+			return
+		}
+		if !info.Directory {
+			http.Error(w, "Files can only be dropped into a directory", http.StatusBadRequest)
+			return
+		}
+
+		link.Token, err = newShareToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tx := h.DB.WithContext(r.Context()).Create(link)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(link)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+type dropRevokeHandler struct {
+	DB *gorm.DB
+}
+
+func newDropRevokeHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&dropRevokeHandler{DB: db})
+}
+
+// Serve deletes the drop link with id, the files uploaded through it are kept
+func (h *dropRevokeHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	// other users their links are treated as missing
+	tx := h.DB.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, user.ID).Delete(&models.DropLink{})
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		http.Error(w, "No such drop link", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,77 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDrop(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/test.data"] = []byte("test")
+	handler := &dropHandler{DB: db, Storage: memfs}
+
+	post := func(handler interface {
+		Serve(*models.User, http.ResponseWriter, *http.Request)
+	}, target string, form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	rr := post(handler, "/webapi/drop", url.Values{"path": {"/folder"}, "max_file_size": {"1024"}, "max_files": {"10"}})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+
+	var link models.DropLink
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&link))
+	assert.Equal(t, "/folder", link.Path)
+	assert.Equal(t, int64(1024), link.MaxFileSize)
+	assert.Equal(t, int64(10), link.MaxFiles)
+	assert.NotEmpty(t, link.Token)
+
+	// files can only be dropped into a directory
+	rr = post(handler, "/webapi/drop", url.Values{"path": {"/folder/test.data"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+	rr = post(handler, "/webapi/drop", url.Values{"path": {"/missing"}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	rr = httptest.NewRecorder()
+	handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/drop", nil))
+	var links []models.DropLink
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&links))
+	assert.Len(t, links, 1)
+
+	rr = post(&dropRevokeHandler{DB: db}, "/webapi/drop/revoke", url.Values{"id": {strconv.FormatUint(link.ID, 10)}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.Error(t, db.First(&models.DropLink{}, link.ID).Error)
+}
@@ -0,0 +1,36 @@
+package webapi
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// storageStatus maps the errors returned by a StorageProvider to the matching http status code
+func storageStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrExist):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrNotDirectory):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	case errors.Is(err, storage.ErrInvalidPath):
+		return http.StatusBadRequest
+	case errors.Is(err, storage.ErrNotSupported):
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// storageError replies to the request with err and the status code returned by storageStatus
+func storageError(w http.ResponseWriter, err error) {
+	http.Error(w, err.Error(), storageStatus(err))
+}
@@ -0,0 +1,23 @@
+package webapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageStatus(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, storageStatus(fmt.Errorf("%w: /file", storage.ErrNotExist)))
+	assert.Equal(t, http.StatusConflict, storageStatus(storage.ErrExist))
+	assert.Equal(t, http.StatusConflict, storageStatus(storage.ErrNotDirectory))
+	assert.Equal(t, http.StatusConflict, storageStatus(storage.ErrNotEmpty))
+	assert.Equal(t, http.StatusForbidden, storageStatus(storage.ErrReadOnly))
+	assert.Equal(t, http.StatusInsufficientStorage, storageStatus(storage.ErrQuotaExceeded))
+	assert.Equal(t, http.StatusBadRequest, storageStatus(storage.ErrInvalidPath))
+	assert.Equal(t, http.StatusNotImplemented, storageStatus(storage.ErrNotSupported))
+	assert.Equal(t, http.StatusInternalServerError, storageStatus(errors.New("Something else")))
+}
@@ -0,0 +1,81 @@
+package webapi
+
+import (
+	"context"
+	"net/http"
+	"path"
+
+	"github.com/gorilla/websocket"
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+type eventsHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newEventsHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&eventsHandler{Storage: store})
+}
+
+// inDirectory reports whether event affects the contents of dir, an empty dir means everything does
+func inDirectory(event storage.Event, dir string) bool {
+	if dir == "" {
+		return true
+	}
+	return path.Dir(event.Path) == dir || (event.OldPath != "" && path.Dir(event.OldPath) == dir)
+}
+
+// Serve streams the changes to the files of the user as json over a websocket, optionally limited
+// to the contents of a single directory using the dir parameter
+func (h *eventsHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		http.Error(w, "Expected a websocket connection", http.StatusBadRequest)
+		return
+	}
+
+	dir := r.URL.Query().Get("dir")
+	if dir != "" {
+		dir = path.Join("/", dir)
+	}
+
+	// the request context is of no use to us once the connection is hijacked, so we stop once the client goes away
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := storage.Watch(ctx, h.Storage, user)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	conn, err := websocketUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+	defer conn.Close()
+
+	go func(conn *websocket.Conn) {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}(conn)
+
+	for event := range events {
+		if !inDirectory(event, dir) {
+			continue
+		}
+
+		err = conn.WriteJSON(event)
+		if err != nil {
+			logrus.Debug(err)
+			return
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package webapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+	ctx := context.Background()
+
+	memfs := memory.NewStorageProvider()
+	store := notify.Notify(memfs)
+	handler := &eventsHandler{Storage: store}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.Serve(user, w, r)
+	}))
+	defer server.Close()
+
+	// a regular request isn't going to work
+	rr := httptest.NewRecorder()
+	handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/events", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/webapi/events?dir=/folder"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+
+	// the handler subscribes before upgrading the connection, so we're not going to miss anything from here on
+	assert.NoError(t, store.Mkdir(ctx, user, "/folder"))
+	assert.NoError(t, store.Mkdir(ctx, user, "/folder/nested"))
+	assert.NoError(t, store.Mkdir(ctx, user, "/folder/nested/deeper"))
+	assert.NoError(t, store.Move(ctx, user, "/folder/nested", "/nested"))
+
+	expected := []storage.Event{
+		{Type: storage.EventCreate, Path: "/folder/nested", Directory: true},
+		{Type: storage.EventMove, Path: "/nested", OldPath: "/folder/nested", Directory: true},
+	}
+	for _, event := range expected {
+		assert.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		var got storage.Event
+		if assert.NoError(t, conn.ReadJSON(&got)) {
+			assert.Equal(t, event, got)
+		}
+	}
+
+	// without anything able to report changes there's nothing to watch
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/webapi/events", nil)
+	req.Header.Set("Connection", "upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	(&eventsHandler{Storage: memfs}).Serve(user, rr, req)
+	assert.Equal(t, http.StatusNotImplemented, rr.Code, rr.Result().Status)
+}
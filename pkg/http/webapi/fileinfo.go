@@ -33,9 +33,21 @@ var websocketUpgrader = websocket.Upgrader{
 func (h *fileInfoHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
 	filename := r.URL.Query().Get("filename")
 
+	_, err := h.Storage.Stat(r.Context(), user, filename)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	metadata, err := fileMetadata(r.Context(), h.Storage, user, filename)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
 	file, err := h.Storage.File(r.Context(), user, filename)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		storageError(w, err)
 		return
 	}
 	defer file.Close()
@@ -57,11 +69,13 @@ func (h *fileInfoHandler) Serve(user *models.User, w http.ResponseWriter, r *htt
 		}
 
 		err = conn.WriteJSON(struct {
-			Apps map[string]string `json:"apps"`
-			Mime string            `json:"mime"`
+			Apps     map[string]string `json:"apps"`
+			Mime     string            `json:"mime"`
+			Metadata map[string]string `json:"metadata,omitempty"`
 		}{
-			Apps: apps,
-			Mime: out.MimeType.String(),
+			Apps:     apps,
+			Mime:     out.MimeType.String(),
+			Metadata: metadata,
 		})
 		if err != nil {
 			logrus.Error(err)
@@ -95,13 +109,15 @@ func (h *fileInfoHandler) Serve(user *models.User, w http.ResponseWriter, r *htt
 		}
 
 		output := struct {
-			Results map[string]types.Result `json:"data"`
-			Apps    map[string]string       `json:"apps"`
-			Mime    string                  `json:"mime"`
+			Results  map[string]types.Result `json:"data"`
+			Apps     map[string]string       `json:"apps"`
+			Mime     string                  `json:"mime"`
+			Metadata map[string]string       `json:"metadata,omitempty"`
 		}{
-			Results: outputs,
-			Apps:    apps,
-			Mime:    out.MimeType.String(),
+			Results:  outputs,
+			Apps:     apps,
+			Mime:     out.MimeType.String(),
+			Metadata: metadata,
 		}
 
 		err = json.NewEncoder(w).Encode(output)
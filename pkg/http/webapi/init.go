@@ -11,9 +11,28 @@ import (
 
 func Init(mux *http.ServeMux, db *gorm.DB, storage storage.StorageProvider, fileinfo *fileinfo.Manager, apps *app.Manager) {
 	mux.Handle("/webapi/upload", newUploadHandler(db, storage))
-	mux.Handle("/webapi/download", newDownloadHandler(db, storage))
+	mux.Handle("/webapi/download", newDownloadHandler(db, storage, fileinfo))
 	mux.Handle("/webapi/list", newListHandler(storage))
 	mux.Handle("/webapi/fileinfo", newFileInfoHandler(storage, fileinfo, apps))
+	mux.Handle("/webapi/events", newEventsHandler(storage))
+	mux.Handle("/webapi/search", newSearchHandler(storage))
+	mux.Handle("/webapi/metadata", newMetadataHandler(storage))
 	mux.Handle("/webapi/mkdir", newMkdirHandler(storage))
 	mux.Handle("/webapi/delete", newDeleteHandler(storage))
+	mux.Handle("/webapi/copy", newCopyHandler(storage))
+	mux.Handle("/webapi/versions", newVersionsHandler(storage))
+	mux.Handle("/webapi/restore", newRestoreHandler(storage))
+	mux.Handle("/webapi/trash", newTrashHandler(storage))
+	mux.Handle("/webapi/trash/restore", newTrashRestoreHandler(storage))
+	mux.Handle("/webapi/trash/empty", newTrashEmptyHandler(storage))
+	mux.Handle("/webapi/share", newShareHandler(db, storage))
+	mux.Handle("/webapi/share/revoke", newShareRevokeHandler(db))
+	mux.Handle("/webapi/drop", newDropHandler(db, storage))
+	mux.Handle("/webapi/drop/revoke", newDropRevokeHandler(db))
+	mux.Handle("/webapi/usershare", newUserShareHandler(db, storage))
+	mux.Handle("/webapi/usershare/accept", newUserShareAcceptHandler(db))
+	mux.Handle("/webapi/usershare/decline", newUserShareDeclineHandler(db))
+	mux.Handle("/webapi/usershare/revoke", newUserShareRevokeHandler(db))
+	mux.Handle("/webapi/apppassword", newAppPasswordHandler(db))
+	mux.Handle("/webapi/apppassword/revoke", newAppPasswordRevokeHandler(db))
 }
@@ -1,8 +1,12 @@
 package webapi
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"net/http"
+	"path"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 
@@ -11,6 +15,9 @@ import (
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 )
 
+// continuationHeader holds the token to pass as the token parameter to get the next page
+const continuationHeader = "X-Continuation-Token"
+
 type listHandler struct {
 	Storage storage.StorageProvider
 }
@@ -19,22 +26,137 @@ func newListHandler(store storage.StorageProvider) http.Handler {
 	return auth.AuthHandler(&listHandler{Storage: store})
 }
 
+// continuation is what we put in a continuation token, so the next page is listed the same way
+type continuation struct {
+	Sort       storage.SortKey `json:"sort"`
+	Descending bool            `json:"desc,omitempty"`
+	Cursor     string          `json:"cursor"`
+	Limit      int             `json:"limit"`
+	Filter     string          `json:"filter,omitempty"`
+}
+
+func encodeToken(opts storage.ListOptions) string {
+	data, _ := json.Marshal(continuation(opts))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeToken(token string) (storage.ListOptions, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return storage.ListOptions{}, err
+	}
+	var out continuation
+	err = json.Unmarshal(data, &out)
+	return storage.ListOptions(out), err
+}
+
+// listOptions reads the sort, order, limit and filter parameters, or all of them out of the token of a previous page
+func listOptions(r *http.Request) (storage.ListOptions, error) {
+	query := r.URL.Query()
+	if token := query.Get("token"); token != "" {
+		return decodeToken(token)
+	}
+
+	opts := storage.ListOptions{
+		Sort:       storage.SortKey(query.Get("sort")),
+		Descending: query.Get("order") == "desc",
+		Filter:     query.Get("filter"),
+	}
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		opts.Limit, err = strconv.Atoi(limit)
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
 func (h *listHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
 	dir := r.URL.Query().Get("dir")
 	if dir == "" {
 		dir = "/"
 	}
 
-	files, err := h.Storage.ListDirectory(r.Context(), user, dir)
+	opts, err := listOptions(r)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	// we ask for one more than we return, so we know whether there is a next page
+	limit := opts.Limit
+	if limit > 0 {
+		opts.Limit++
+	}
+
+	files, err := storage.List(r.Context(), h.Storage, user, dir, opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		storageError(w, err)
 		return
 	}
 
+	// looking up the metadata takes a call per file, so it's only done for those who ask for it
+	metadata := func(storage.FileInfo) map[string]string { return nil }
+	if r.URL.Query().Get("metadata") == "1" {
+		metadata = h.metadata(r.Context(), user, dir)
+	}
+	if limit == 0 {
+		for file := range files {
+			writeFile(w, file, metadata(file))
+		}
+		return
+	}
+
+	page := make([]storage.FileInfo, 0, limit)
 	for file := range files {
-		err = json.NewEncoder(w).Encode(file)
+		page = append(page, file)
+	}
+	if len(page) > limit {
+		page = page[:limit]
+		opts.Limit = limit
+		if opts.Sort == storage.SortNone {
+			// paging without sorting sorts by name, see storage.ListOptions
+			opts.Sort = storage.SortName
+		}
+		opts.Cursor = storage.CursorOf(opts.Sort, page[limit-1])
+		w.Header().Set(continuationHeader, encodeToken(opts))
+	}
+
+	for _, file := range page {
+		writeFile(w, file, metadata(file))
+	}
+}
+
+// metadata returns a function looking up the metadata of the files in dir, once the storage turns out to not
+// support metadata it doesn't bother asking again
+func (h *listHandler) metadata(ctx context.Context, user *models.User, dir string) func(storage.FileInfo) map[string]string {
+	supported := true
+	return func(file storage.FileInfo) map[string]string {
+		if !supported {
+			return nil
+		}
+		metadata, err := fileMetadata(ctx, h.Storage, user, path.Join(dir, file.Name))
 		if err != nil {
-			logrus.Errorf("Error %s while encoding json", err)
+			// most likely it's gone in the meantime, which isn't worth failing the listing over
+			logrus.Debugf("Error %s while reading the metadata of %s", err, file.Name)
+			return nil
 		}
+		supported = metadata != nil
+		return metadata
+	}
+}
+
+// listEntry is what we return for every file, the metadata is left out if there isn't any
+type listEntry struct {
+	storage.FileInfo
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// writeFile writes file as a single line of json
+func writeFile(w http.ResponseWriter, file storage.FileInfo, metadata map[string]string) {
+	err := json.NewEncoder(w).Encode(listEntry{FileInfo: file, Metadata: metadata})
+	if err != nil {
+		logrus.Errorf("Error %s while encoding json", err)
 	}
 }
@@ -0,0 +1,120 @@
+package webapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/folder"] = struct{}{}
+	memfs.Data["/folder/a.txt"] = []byte("aaa")
+	memfs.Data["/folder/b.txt"] = []byte("b")
+	memfs.Data["/folder/c.txt"] = []byte("cc")
+	memfs.Data["/folder/d.data"] = []byte("dddd")
+
+	handler := &listHandler{
+		Storage: memfs,
+	}
+
+	list := func(query url.Values) (*httptest.ResponseRecorder, []string) {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/list?"+query.Encode(), nil))
+
+		names := []string{}
+		if rr.Code != http.StatusOK {
+			return rr, names
+		}
+		scanner := bufio.NewScanner(rr.Body)
+		for scanner.Scan() {
+			var file storage.FileInfo
+			if assert.NoError(t, json.Unmarshal(scanner.Bytes(), &file)) {
+				names = append(names, file.Name)
+			}
+		}
+		return rr, names
+	}
+
+	rr, names := list(url.Values{"dir": {"/folder"}, "sort": {"name"}})
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	assert.Equal(t, []string{"a.txt", "b.txt", "c.txt", "d.data"}, names)
+	assert.Empty(t, rr.Header().Get(continuationHeader))
+
+	t.Run("Paging", func(t *testing.T) {
+		query := url.Values{"dir": {"/folder"}, "sort": {"size"}, "order": {"desc"}, "filter": {"*.txt"}, "limit": {"2"}}
+		rr, names := list(query)
+		assert.Equal(t, []string{"a.txt", "c.txt"}, names)
+
+		// the token keeps sorting and filtering the same way
+		token := rr.Header().Get(continuationHeader)
+		if !assert.NotEmpty(t, token) {
+			return
+		}
+		rr, names = list(url.Values{"dir": {"/folder"}, "token": {token}})
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+		assert.Equal(t, []string{"b.txt"}, names)
+		assert.Empty(t, rr.Header().Get(continuationHeader))
+
+		// exactly filling the page doesn't mean there's more
+		rr, names = list(url.Values{"dir": {"/folder"}, "limit": {"4"}})
+		assert.Equal(t, []string{"a.txt", "b.txt", "c.txt", "d.data"}, names)
+		assert.Empty(t, rr.Header().Get(continuationHeader))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, query := range []url.Values{
+			{"dir": {"/folder"}, "limit": {"many"}},
+			{"dir": {"/folder"}, "limit": {"-1"}},
+			{"dir": {"/folder"}, "sort": {"bogus"}},
+			{"dir": {"/folder"}, "filter": {"["}},
+			{"dir": {"/folder"}, "token": {"garbage"}},
+		} {
+			rr, _ := list(query)
+			assert.Equal(t, http.StatusBadRequest, rr.Code, query.Encode())
+		}
+
+		rr, _ := list(url.Values{"dir": {"/missing"}})
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		memfs.Metadata["/folder/b.txt"] = map[string]string{"tag": "red"}
+
+		listMetadata := func(target string) map[string]map[string]string {
+			rr := httptest.NewRecorder()
+			handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, target, nil))
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+			metadata := map[string]map[string]string{}
+			scanner := bufio.NewScanner(rr.Body)
+			for scanner.Scan() {
+				var file listEntry
+				if assert.NoError(t, json.Unmarshal(scanner.Bytes(), &file)) {
+					metadata[file.Name] = file.Metadata
+					assert.NotEmpty(t, file.ETag, file.Name)
+				}
+			}
+			return metadata
+		}
+
+		metadata := listMetadata("/webapi/list?dir=/folder&filter=[ab].txt&metadata=1")
+		assert.Equal(t, map[string]map[string]string{"a.txt": nil, "b.txt": {"tag": "red"}}, metadata)
+
+		// it's only included when asked for
+		metadata = listMetadata("/webapi/list?dir=/folder&filter=[ab].txt")
+		assert.Equal(t, map[string]map[string]string{"a.txt": nil, "b.txt": nil}, metadata)
+	})
+}
@@ -0,0 +1,77 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// fileMetadata returns the metadata of fullpath, which is nil if the storage doesn't support metadata
+func fileMetadata(ctx context.Context, store storage.StorageProvider, user *models.User, fullpath string) (map[string]string, error) {
+	metadata, err := storage.ListMetadata(ctx, store, user, fullpath)
+	if errors.Is(err, storage.ErrNotSupported) {
+		return nil, nil
+	}
+	return metadata, err
+}
+
+type metadataHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newMetadataHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&metadataHandler{Storage: store})
+}
+
+// Serve returns all the metadata of filename as a json object on GET, a POST sets key to value
+// or removes key if value is empty
+func (h *metadataHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		filename := r.URL.Query().Get("filename")
+		if filename == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+
+		metadata, err := storage.ListMetadata(r.Context(), h.Storage, user, filename)
+		if err != nil {
+			storageError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(metadata)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	case http.MethodPost:
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		filename := r.Form.Get("filename")
+		key := r.Form.Get("key")
+		if filename == "" || key == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+
+		err = storage.SetMetadata(r.Context(), h.Storage, user, filename, key, r.Form.Get("value"))
+		if err != nil {
+			storageError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
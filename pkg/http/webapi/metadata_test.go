@@ -0,0 +1,77 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/test.data"] = []byte("test")
+
+	handler := &metadataHandler{
+		Storage: memfs,
+	}
+
+	setMetadata := func(filename, key, value string) *httptest.ResponseRecorder {
+		form := url.Values{"filename": {filename}, "key": {key}, "value": {value}}
+		req, err := http.NewRequest(http.MethodPost, "/webapi/metadata", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	getMetadata := func(filename string) (*httptest.ResponseRecorder, map[string]string) {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/metadata?filename="+url.QueryEscape(filename), nil))
+
+		var metadata map[string]string
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&metadata))
+		}
+		return rr, metadata
+	}
+
+	rr := setMetadata("/folder/test.data", "tag", "red")
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.Equal(t, map[string]string{"tag": "red"}, memfs.Metadata["/folder/test.data"])
+
+	rr, metadata := getMetadata("/folder/test.data")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	assert.Equal(t, map[string]string{"tag": "red"}, metadata)
+
+	rr = setMetadata("/folder/test.data", "tag", "")
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	_, metadata = getMetadata("/folder/test.data")
+	assert.Empty(t, metadata)
+
+	rr = setMetadata("/folder/missing.data", "tag", "red")
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr, _ = getMetadata("/folder/missing.data")
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	rr = setMetadata("/folder/test.data", "", "red")
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+
+	handler.Storage = storage.ReadOnly(memfs)
+	rr = setMetadata("/folder/test.data", "tag", "red")
+	assert.Equal(t, http.StatusForbidden, rr.Code, rr.Result().Status)
+}
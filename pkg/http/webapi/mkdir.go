@@ -41,7 +41,7 @@ func (h *mkdirHandler) Serve(user *models.User, w http.ResponseWriter, r *http.R
 
 	err = h.Storage.Mkdir(r.Context(), user, dir)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		storageError(w, err)
 		return
 	}
 
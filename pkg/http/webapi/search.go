@@ -0,0 +1,109 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/search"
+	"github.com/sirupsen/logrus"
+)
+
+// findSearch looks for the search layer in store, returns nil if search isn't enabled
+func findSearch(store storage.StorageProvider) *search.SearchStorageProvider {
+	for ; store != nil; store = storage.Unwrap(store) {
+		if s, ok := store.(*search.SearchStorageProvider); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+type searchHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newSearchHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&searchHandler{Storage: store})
+}
+
+// parseDate accepts either a full RFC 3339 timestamp or just a date
+func parseDate(in string) (time.Time, error) {
+	if in == "" {
+		return time.Time{}, nil
+	}
+	out, err := time.Parse(time.RFC3339, in)
+	if err != nil {
+		return time.Parse("2006-01-02", in)
+	}
+	return out, nil
+}
+
+// parseSize parses an optional size in bytes
+func parseSize(in string) (uint64, error) {
+	if in == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(in, 10, 64)
+}
+
+// searchQuery reads the search.Query out of the parameters of r
+func searchQuery(r *http.Request) (search.Query, error) {
+	params := r.URL.Query()
+	query := search.Query{
+		Dir:  params.Get("dir"),
+		Name: params.Get("name"),
+		Mime: params.Get("mime"),
+		Hash: params.Get("hash"),
+	}
+
+	var err error
+	if query.MinSize, err = parseSize(params.Get("min_size")); err != nil {
+		return query, err
+	}
+	if query.MaxSize, err = parseSize(params.Get("max_size")); err != nil {
+		return query, err
+	}
+	if query.After, err = parseDate(params.Get("after")); err != nil {
+		return query, err
+	}
+	if query.Before, err = parseDate(params.Get("before")); err != nil {
+		return query, err
+	}
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, err
+		}
+	}
+	return query, nil
+}
+
+func (h *searchHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	index := findSearch(h.Storage)
+	if index == nil {
+		http.Error(w, "Search isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	query, err := searchQuery(r)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	results, err := index.Search(r.Context(), user, query)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(results)
+	if err != nil {
+		logrus.Errorf("Error %s while encoding json", err)
+	}
+}
@@ -0,0 +1,93 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/prometheus"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/notify"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/search"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSearch(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	handler := &searchHandler{Storage: memfs}
+
+	rr := httptest.NewRecorder()
+	handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/search?name=file", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prom, err := (&prometheus.Config{Enabled: false}).Create()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := fileinfo.NewManager(nil, prom, "gonative")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := search.Index(memfs, db, manager, search.Config{Enabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// it should be found underneath any other layers
+	handler.Storage = notify.Notify(index)
+
+	for name, contents := range map[string]string{"/small-file": "a", "/large-file": "a lot more", "/other": "b"} {
+		file, err := handler.Storage.File(context.Background(), user, name)
+		if assert.NoError(t, err) {
+			_, err = file.Write([]byte(contents))
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+		}
+	}
+
+	find := func(query url.Values) (*httptest.ResponseRecorder, []search.Result) {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/search?"+query.Encode(), nil))
+
+		var results []search.Result
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&results))
+		}
+		return rr, results
+	}
+
+	rr, results := find(url.Values{"name": {"file"}, "min_size": {"2"}, "after": {"2000-01-01"}})
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "/large-file", results[0].FullPath)
+		assert.Equal(t, uint64(len("a lot more")), results[0].Size)
+	}
+
+	_, results = find(url.Values{"name": {"file"}, "before": {"2000-01-01T00:00:00Z"}})
+	assert.Empty(t, results)
+
+	for _, query := range []url.Values{
+		{"min_size": {"small"}},
+		{"after": {"yesterday"}},
+		{"limit": {"a few"}},
+		{"mime": {"image"}},
+	} {
+		rr, _ := find(query)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query.Encode())
+	}
+}
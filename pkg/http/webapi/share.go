@@ -0,0 +1,183 @@
+package webapi
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// newShareToken returns a random token for a share link, long enough that it can't be guessed
+func newShareToken() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// formExpiry parses the optional expires field of the parsed form of r, which is an RFC 3339 timestamp
+func formExpiry(r *http.Request) (*time.Time, error) {
+	expires := r.Form.Get("expires")
+	if expires == "" {
+		return nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, expires)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt = expiresAt.UTC()
+	return &expiresAt, nil
+}
+
+// formLimit parses the optional limit in the field key of the parsed form of r, 0 means unlimited
+func formLimit(r *http.Request, key string) (int64, error) {
+	value := r.Form.Get(key)
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("Invalid %s", key)
+	}
+	return limit, nil
+}
+
+type shareHandler struct {
+	DB      *gorm.DB
+	Storage storage.StorageProvider
+}
+
+func newShareHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&shareHandler{DB: db, Storage: store})
+}
+
+// Serve returns the share links of the user as a json array on GET, a POST creates a new link to path.
+// The optional expires is an RFC 3339 timestamp, password and max_downloads are optional as well
+func (h *shareHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		links := []models.ShareLink{}
+		tx := h.DB.WithContext(r.Context()).Order("created_at").Find(&links, "user_id = ?", user.ID)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(links)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	case http.MethodPost:
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		link := &models.ShareLink{
+			UserID: user.ID,
+			Path:   r.Form.Get("path"),
+		}
+		if link.Path == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+		link.ExpiresAt, err = formExpiry(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		link.MaxDownloads, err = formLimit(r, "max_downloads")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if password := r.Form.Get("password"); password != "" {
+			link.PasswordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// you can only share what exists
+		_, err = h.Storage.Stat(r.Context(), user, link.Path)
+		if err != nil {
+			storageError(w, err)
+			return
+		}
+
+		link.Token, err = newShareToken()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tx := h.DB.WithContext(r.Context()).Create(link)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		err = json.NewEncoder(w).Encode(link)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+type shareRevokeHandler struct {
+	DB *gorm.DB
+}
+
+func newShareRevokeHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&shareRevokeHandler{DB: db})
+}
+
+// Serve deletes the share link with id, after which its token stops working
+func (h *shareRevokeHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	// other users their links are treated as missing
+	tx := h.DB.WithContext(r.Context()).Where("id = ? AND user_id = ?", id, user.ID).Delete(&models.ShareLink{})
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		http.Error(w, "No such share link", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
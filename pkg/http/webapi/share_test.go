@@ -0,0 +1,110 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestShare(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+	other := &models.User{
+		ID: 42,
+	}
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/test.data"] = []byte("test")
+	handler := &shareHandler{DB: db, Storage: memfs}
+	revoke := &shareRevokeHandler{DB: db}
+
+	post := func(handler interface {
+		Serve(*models.User, http.ResponseWriter, *http.Request)
+	}, user *models.User, target string, form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	listLinks := func(user *models.User) []models.ShareLink {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/share", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+		var out []models.ShareLink
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+		return out
+	}
+
+	rr := post(handler, user, "/webapi/share", url.Values{
+		"path":          {"/folder"},
+		"password":      {"secret"},
+		"max_downloads": {"3"},
+		"expires":       {"2030-01-02T15:04:05+02:00"},
+	})
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+	assert.NotContains(t, rr.Body.String(), "password")
+
+	var link models.ShareLink
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&link))
+	assert.Equal(t, "/folder", link.Path)
+	assert.Equal(t, int64(3), link.MaxDownloads)
+	assert.Len(t, link.Token, 43)
+	if assert.NotNil(t, link.ExpiresAt) {
+		assert.Equal(t, "2030-01-02T13:04:05Z", link.ExpiresAt.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+
+	stored := &models.ShareLink{}
+	assert.NoError(t, db.First(stored, link.ID).Error)
+	assert.Equal(t, user.ID, stored.UserID)
+	assert.NoError(t, bcrypt.CompareHashAndPassword(stored.PasswordHash, []byte("secret")))
+
+	rr = post(handler, user, "/webapi/share", url.Values{"path": {"/folder/missing.data"}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(handler, user, "/webapi/share", url.Values{"path": {"/folder"}, "expires": {"tomorrow"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+	rr = post(handler, user, "/webapi/share", url.Values{"path": {"/folder"}, "max_downloads": {"-1"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code, rr.Result().Status)
+
+	links := listLinks(user)
+	if assert.Len(t, links, 1) {
+		assert.Equal(t, link.Token, links[0].Token)
+	}
+	assert.Empty(t, listLinks(other))
+
+	// only the owner is able to revoke a link
+	id := strconv.FormatUint(link.ID, 10)
+	rr = post(revoke, other, "/webapi/share/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(revoke, user, "/webapi/share/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.Empty(t, listLinks(user))
+}
@@ -0,0 +1,122 @@
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/trash"
+	"github.com/sirupsen/logrus"
+)
+
+// findTrash looks for the trash layer in store, returns nil if the trash isn't enabled
+func findTrash(store storage.StorageProvider) *trash.TrashStorageProvider {
+	for ; store != nil; store = storage.Unwrap(store) {
+		if t, ok := store.(*trash.TrashStorageProvider); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+type trashHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newTrashHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&trashHandler{Storage: store})
+}
+
+func (h *trashHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	bin := findTrash(h.Storage)
+	if bin == nil {
+		http.Error(w, "Trash isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	items, err := bin.Items(r.Context(), user)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(items)
+	if err != nil {
+		logrus.Errorf("Error %s while encoding json", err)
+	}
+}
+
+type trashRestoreHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newTrashRestoreHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&trashRestoreHandler{Storage: store})
+}
+
+func (h *trashRestoreHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	bin := findTrash(h.Storage)
+	if bin == nil {
+		http.Error(w, "Trash isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.Form.Get("id")
+	if id == "" {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	item, err := bin.Restore(r.Context(), user, id)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/?dir=%s", path.Dir(item.Path)), http.StatusTemporaryRedirect)
+}
+
+type trashEmptyHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newTrashEmptyHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&trashEmptyHandler{Storage: store})
+}
+
+func (h *trashEmptyHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	bin := findTrash(h.Storage)
+	if bin == nil {
+		http.Error(w, "Trash isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	err := bin.Empty(r.Context(), user)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+}
@@ -0,0 +1,87 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/trash"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/folder"] = struct{}{}
+	memfs.Data["/folder/test.data"] = []byte("test")
+	memfs.Data["/other.data"] = []byte("test")
+	store := trash.Trash(memfs, trash.Policy{})
+
+	post := func(handler interface {
+		Serve(*models.User, http.ResponseWriter, *http.Request)
+	}, target string, form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	listTrash := func() []trash.Item {
+		req, err := http.NewRequest(http.MethodGet, "/webapi/trash", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		(&trashHandler{Storage: store}).Serve(user, rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+		var out []trash.Item
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+		return out
+	}
+
+	rr := post(&deleteHandler{Storage: store}, "/webapi/delete", url.Values{"file": {"/folder/test.data", "/other.data"}})
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+	assert.NotContains(t, memfs.Data, "/folder/test.data")
+	assert.NotContains(t, memfs.Data, "/other.data")
+
+	items := listTrash()
+	if !assert.Len(t, items, 2) {
+		return
+	}
+
+	for _, item := range items {
+		if item.Path == "/folder/test.data" {
+			rr = post(&trashRestoreHandler{Storage: store}, "/webapi/trash/restore", url.Values{"id": {item.ID}})
+			assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+			assert.Equal(t, "/?dir=/folder", rr.Header().Get("Location"))
+		}
+	}
+	assert.Equal(t, []byte("test"), memfs.Data["/folder/test.data"])
+	assert.Len(t, listTrash(), 1)
+
+	rr = post(&trashRestoreHandler{Storage: store}, "/webapi/trash/restore", url.Values{"id": {"1234"}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	rr = post(&trashEmptyHandler{Storage: store}, "/webapi/trash/empty", url.Values{})
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+	assert.Empty(t, listTrash())
+
+	// without the trash layer there's nothing to empty
+	rr = post(&trashEmptyHandler{Storage: memfs}, "/webapi/trash/empty", url.Values{})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+}
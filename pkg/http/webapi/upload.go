@@ -1,9 +1,12 @@
 package webapi
 
 import (
+	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"math"
 	"mime"
 	"mime/multipart"
 	"net/http"
@@ -11,6 +14,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
 	"github.com/leicht-cloud/leicht-cloud/pkg/http/helper/limiter"
@@ -22,17 +26,38 @@ import (
 
 type uploadHandler struct {
 	Storage storage.StorageProvider
+	// Open is used instead of Storage.File if set, see NewUploadHandler
+	Open OpenFunc
 
-	mutex   sync.RWMutex
-	nextID  int64
-	uploads map[int64]*uploadState
+	mutex   sync.Mutex
+	uploads map[string]*uploadState
+
+	clock func() time.Time
 }
 
+// uploadTimeout is how long a tus upload is kept around without hearing from the client, after that
+// it's aborted so whatever it's holding on to is freed
+const uploadTimeout = 24 * time.Hour
+
 type uploadState struct {
-	UserID   uint64
+	UserID uint64
+	// URL is the path the upload got started at, the upload can't be resumed anywhere else. For uploads
+	// through a drop link that's what binds them to the link, as these are all done as the owner
+	URL      string
 	Length   uint64
 	Position uint64
 	File     storage.File
+	// LastSeen is when the client last did anything with the upload
+	LastSeen time.Time
+}
+
+func newUploadID() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
 }
 
 func newUploadHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
@@ -40,11 +65,34 @@ func newUploadHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
 		limiter.UploadMiddleware(db,
 			&uploadHandler{
 				Storage: store,
-				uploads: make(map[int64]*uploadState),
+				uploads: make(map[string]*uploadState),
+				clock:   time.Now,
 			}),
 	)
 }
 
+// OpenFunc returns the file an upload of filename gets written to, length is -1 if it isn't known up front
+type OpenFunc func(r *http.Request, user *models.User, filename string, length int64) (storage.File, error)
+
+// NewUploadHandler accepts multipart and tus uploads just like /webapi/upload, but leaves it up to open where
+// they end up. Authentication is up to the caller, so this works for uploads by people without an account too
+func NewUploadHandler(store storage.StorageProvider, open OpenFunc) auth.AuthHandlerInterface {
+	return &uploadHandler{
+		Storage: store,
+		Open:    open,
+		uploads: make(map[string]*uploadState),
+		clock:   time.Now,
+	}
+}
+
+// open returns the file an upload of filename into dir is written to
+func (h *uploadHandler) open(r *http.Request, user *models.User, dir, filename string, length int64) (storage.File, error) {
+	if h.Open != nil {
+		return h.Open(r, user, filename, length)
+	}
+	return h.Storage.File(r.Context(), user, path.Join(dir, filename))
+}
+
 func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
 	dir := r.URL.Query().Get("dir")
 	if dir == "" {
@@ -59,18 +107,25 @@ func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 		return
 	} else if r.URL.Query().Has("resume") {
 		// if we have the resume parameter it is an attempt to resume a previously started upload
-		id, err := strconv.ParseInt(r.URL.Query().Get("resume"), 10, 64)
+		id := r.URL.Query().Get("resume")
+		_, err := hex.DecodeString(id)
 		if err != nil {
 			http.Error(w, "Invalid id?", http.StatusBadRequest)
 			return
 		}
 
-		h.mutex.RLock()
+		h.expire()
+		h.mutex.Lock()
 		state, ok := h.uploads[id]
-		h.mutex.RUnlock()
+		// uploads of other users or started elsewhere are treated as missing
+		ok = ok && state.UserID == user.ID && state.URL == r.URL.Path
+		if ok {
+			state.LastSeen = h.clock()
+		}
+		h.mutex.Unlock()
 
 		if !ok {
-			logrus.Errorf("Couldn't find state with id: %d", id)
+			logrus.Errorf("Couldn't find state with id: %s", id)
 			http.Error(w, "No previous upload found with this id", http.StatusNotFound)
 			return
 		}
@@ -80,11 +135,6 @@ func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 			h.mutex.Lock()
 			delete(h.uploads, id)
 			h.mutex.Unlock()
-
-			err = state.Close()
-			if err != nil {
-				logrus.Error(err)
-			}
 		}
 		return
 	} else if r.Method == http.MethodPost && r.Header.Get("Upload-Length") != "" {
@@ -103,24 +153,36 @@ func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 
 		state := &uploadState{
 			UserID: user.ID,
+			URL:    r.URL.Path,
 			Length: length,
 		}
 
-		file, err := h.Storage.File(r.Context(), user, path.Join(dir, filename))
+		if length > math.MaxInt64 {
+			http.Error(w, "Invalid Upload-Length header", http.StatusBadRequest)
+			return
+		}
+		file, err := h.open(r, user, dir, filename, int64(length))
 		if err != nil {
 			logrus.Error(err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			storageError(w, err)
 			return
 		}
 		state.File = file
 
+		id, err := newUploadID()
+		if err != nil {
+			storage.Abort(file)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		h.expire()
 		h.mutex.Lock()
-		id := h.nextID
-		h.nextID++
+		state.LastSeen = h.clock()
 		h.uploads[id] = state
 		h.mutex.Unlock()
 
-		http.Redirect(w, r, fmt.Sprintf("/webapi/upload?resume=%d", id), http.StatusCreated)
+		http.Redirect(w, r, fmt.Sprintf("%s?resume=%s", r.URL.EscapedPath(), id), http.StatusCreated)
 		return
 	}
 
@@ -150,16 +212,22 @@ func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 				return
 			}
 
-			f, err := h.Storage.File(r.Context(), user, path.Join("/", filename))
+			f, err := h.open(r, user, "/", filename, -1)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				storageError(w, err)
 				return
 			}
-			defer f.Close()
 
+			// an interrupted upload shouldn't replace the file with whatever made it through so far
 			_, err = io.Copy(f, p)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				storage.Abort(f)
+				storageError(w, err)
+				return
+			}
+			err = f.Close()
+			if err != nil {
+				storageError(w, err)
 				return
 			}
 		}
@@ -171,6 +239,27 @@ func (h *uploadHandler) Serve(user *models.User, w http.ResponseWriter, r *http.
 	http.Error(w, "Invalid request, expected multipart", http.StatusBadRequest)
 }
 
+// expire aborts the tus uploads the client hasn't been heard of for longer than uploadTimeout
+func (h *uploadHandler) expire() {
+	h.mutex.Lock()
+	expired := make([]*uploadState, 0)
+	now := h.clock()
+	for id, state := range h.uploads {
+		if now.Sub(state.LastSeen) >= uploadTimeout {
+			expired = append(expired, state)
+			delete(h.uploads, id)
+		}
+	}
+	h.mutex.Unlock()
+
+	for _, state := range expired {
+		err := storage.Abort(state.File)
+		if err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
 func parseTusMetadata(header string) map[string]string {
 	meta := make(map[string]string)
 
@@ -223,21 +312,31 @@ func (s *uploadState) Handle(w http.ResponseWriter, r *http.Request) bool {
 			return false
 		}
 
-		// we copy the actual data to the end of our file
+		// we copy the actual data to the end of our file, then we calculate the next position by adding
+		// the amount of written bytes to our position. that's done even if the copy got cut short, so the
+		// client is able to continue with whatever didn't make it
 		n, err := io.Copy(s.File, r.Body)
+		s.Position += uint64(n)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			storageError(w, err)
 			return false
 		}
 
-		// then we calculate the next position by adding the amount of read bytes to our position
-		s.Position += uint64(n)
+		// if our new position is equal to the expected length we are done, the file is only closed before
+		// responding so the client finds out if it didn't make it after all
+		if s.Position == s.Length {
+			err = s.Close()
+			if err != nil {
+				logrus.Error(err)
+				storageError(w, err)
+				return true
+			}
+		}
 
 		// and we report this new offset to the client
 		w.Header().Add("Upload-Offset", fmt.Sprintf("%d", s.Position))
 		w.WriteHeader(http.StatusNoContent)
 
-		// if our new position is equal to the expected length we are done and can return true
 		return s.Position == s.Length
 	}
 
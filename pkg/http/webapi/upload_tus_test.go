@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -48,7 +49,8 @@ func initUploadHandler(t *testing.T) (storage.StorageProvider, *uploadHandler) {
 
 	handler := &uploadHandler{
 		Storage: store,
-		uploads: make(map[int64]*uploadState),
+		uploads: make(map[string]*uploadState),
+		clock:   time.Now,
 	}
 
 	return store, handler
@@ -238,3 +240,128 @@ func TestTusResumeNotExistingID(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
 }
+
+// abortFile remembers whether it got aborted
+type abortFile struct {
+	storage.File
+	aborted bool
+}
+
+func (f *abortFile) Abort() error {
+	f.aborted = true
+	return storage.Abort(f.File)
+}
+
+// brokenReader returns data, followed by an error instead of io.EOF like a connection that got cut
+type brokenReader struct {
+	data io.Reader
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func startTusUpload(t *testing.T, handler *uploadHandler, user *models.User, target string, length int) string {
+	req := httptest.NewRequest(http.MethodPost, target, nil)
+	req.Header.Add("Upload-Length", fmt.Sprintf("%d", length))
+	req.Header.Add("Upload-Metadata", "filename dGVzdC5kYXRh") // base64 for "test.data"
+	rr := httptest.NewRecorder()
+	handler.Serve(user, rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code, rr.Result().Status)
+	return rr.Header().Get("Location")
+}
+
+func headTusUpload(handler *uploadHandler, user *models.User, resume string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.Serve(user, rr, httptest.NewRequest(http.MethodHead, resume, nil))
+	return rr
+}
+
+func TestTusResumeElsewhere(t *testing.T) {
+	_, handler := initUploadHandler(t)
+	user := &models.User{ID: 1337}
+
+	resume := startTusUpload(t, handler, user, "/d/first", 4)
+	assert.Regexp(t, `^/d/first\?resume=[0-9a-f]{32}$`, resume)
+
+	// the same user at another url, like another drop link of the same owner
+	rr := headTusUpload(handler, user, strings.Replace(resume, "/d/first", "/d/second", 1))
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = headTusUpload(handler, &models.User{ID: 1}, resume)
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	rr = headTusUpload(handler, user, resume)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+}
+
+func TestTusInterrupted(t *testing.T) {
+	store, handler := initUploadHandler(t)
+	user := &models.User{ID: 1337}
+
+	resume := startTusUpload(t, handler, user, "/webapi/upload", 6)
+
+	req := httptest.NewRequest(http.MethodPatch, resume, &brokenReader{data: bytes.NewBufferString("abc")})
+	req.Header.Add("Upload-Offset", "0")
+	rr := httptest.NewRecorder()
+	handler.Serve(user, rr, req)
+	assert.NotEqual(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+
+	// whatever made it through doesn't have to be sent again
+	rr = headTusUpload(handler, user, resume)
+	assert.Equal(t, "3", rr.Header().Get("Upload-Offset"))
+
+	req = httptest.NewRequest(http.MethodPatch, resume, bytes.NewBufferString("def"))
+	req.Header.Add("Upload-Offset", "3")
+	rr = httptest.NewRecorder()
+	handler.Serve(user, rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+
+	file, err := store.File(context.Background(), user, "/test.data")
+	if assert.NoError(t, err) {
+		written, err := ioutil.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, "abcdef", string(written))
+	}
+}
+
+func TestTusExpire(t *testing.T) {
+	store, handler := initUploadHandler(t)
+	user := &models.User{ID: 1337}
+
+	now := time.Now()
+	handler.clock = func() time.Time {
+		return now
+	}
+	opened := make([]*abortFile, 0)
+	handler.Open = func(r *http.Request, user *models.User, filename string, length int64) (storage.File, error) {
+		file, err := store.File(r.Context(), user, "/"+filename)
+		if err != nil {
+			return nil, err
+		}
+		out := &abortFile{File: file}
+		opened = append(opened, out)
+		return out, nil
+	}
+
+	abandoned := startTusUpload(t, handler, user, "/webapi/upload", 4)
+	active := startTusUpload(t, handler, user, "/webapi/upload", 4)
+
+	now = now.Add(uploadTimeout / 2)
+	rr := headTusUpload(handler, user, active)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+	now = now.Add(uploadTimeout / 2)
+	rr = headTusUpload(handler, user, abandoned)
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = headTusUpload(handler, user, active)
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+	if assert.Len(t, opened, 2) {
+		assert.True(t, opened[0].aborted)
+		assert.False(t, opened[1].aborted)
+	}
+}
@@ -0,0 +1,269 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/shared"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// userShareInfo is a models.UserShare along with who is on either side of it
+type userShareInfo struct {
+	models.UserShare
+	Owner     string `json:"owner"`
+	Recipient string `json:"recipient"`
+}
+
+// userShareList is what's returned by a GET of /webapi/usershare
+type userShareList struct {
+	Outgoing []userShareInfo `json:"outgoing"`
+	Incoming []userShareInfo `json:"incoming"`
+}
+
+type userShareHandler struct {
+	DB      *gorm.DB
+	Storage storage.StorageProvider
+}
+
+func newUserShareHandler(db *gorm.DB, store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&userShareHandler{DB: db, Storage: store})
+}
+
+// Serve returns the directories the user shared with others and those shared with them on GET, a POST shares
+// the directory path with the user with email. The optional role is either read, the default, or readwrite.
+// The share itself shows up in the GET afterwards, a POST only answers whether the request was valid
+func (h *userShareHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var list userShareList
+		var err error
+		list.Outgoing, err = h.find(r.Context(), "user_id = ?", user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		list.Incoming, err = h.find(r.Context(), "recipient_id = ?", user.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(list)
+		if err != nil {
+			logrus.Errorf("Error %s while encoding json", err)
+		}
+	case http.MethodPost:
+		err := r.ParseForm()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		share := &models.UserShare{
+			UserID: user.ID,
+			Path:   path.Clean("/" + r.Form.Get("path")),
+			Role:   models.GroupRole(r.Form.Get("role")),
+		}
+		email := r.Form.Get("email")
+		if r.Form.Get("path") == "" || email == "" {
+			http.Error(w, "Invalid parameters", http.StatusBadRequest)
+			return
+		}
+		if share.Role == "" {
+			share.Role = models.GroupRoleRead
+		} else if !share.Role.Valid() {
+			http.Error(w, "Invalid role", http.StatusBadRequest)
+			return
+		}
+		// these are stored elsewhere, so sharing them would end up sharing something else entirely
+		for _, dir := range []string{shared.SharedDir, shared.SharedWithMeDir} {
+			if share.Path == dir || strings.HasPrefix(share.Path, dir+"/") {
+				http.Error(w, "Shared directories can't be shared any further", http.StatusBadRequest)
+				return
+			}
+		}
+
+		if email == user.Email {
+			http.Error(w, "You can't share with yourself", http.StatusBadRequest)
+			return
+		}
+
+		info, err := h.Storage.Stat(r.Context(), user, share.Path)
+		if err != nil {
+			storageError(w, err)
+			return
+		}
+		if !info.Directory {
+			http.Error(w, "Only directories can be shared with other users", http.StatusBadRequest)
+			return
+		}
+
+		// an unknown email gets the very same answer as a known one, so this can't be used to find out who
+		// has an account
+		recipient := &models.User{}
+		tx := h.DB.WithContext(r.Context()).First(recipient, "email = ?", email)
+		if errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		} else if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+		share.RecipientID = recipient.ID
+
+		var count int64
+		tx = h.DB.WithContext(r.Context()).Model(&models.UserShare{}).
+			Where("user_id = ? AND path = ? AND recipient_id = ?", user.ID, share.Path, recipient.ID).
+			Count(&count)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+		if count > 0 {
+			// sharing twice doesn't change anything
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		name := path.Base(share.Path)
+		if share.Path == "/" {
+			name = user.Email
+		}
+		share.Name, err = h.uniqueName(r.Context(), recipient, name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tx = h.DB.WithContext(r.Context()).Create(share)
+		if tx.Error != nil {
+			http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+	}
+}
+
+// find returns the shares matching query along with who is on either side of them
+func (h *userShareHandler) find(ctx context.Context, query string, args ...interface{}) ([]userShareInfo, error) {
+	shares := []models.UserShare{}
+	tx := h.DB.WithContext(ctx).Preload("User").Preload("Recipient").Order("created_at").Find(&shares, append([]interface{}{query}, args...)...)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	out := make([]userShareInfo, 0, len(shares))
+	for _, share := range shares {
+		info := userShareInfo{UserShare: share}
+		if share.User != nil {
+			info.Owner = share.User.Email
+		}
+		if share.Recipient != nil {
+			info.Recipient = share.Recipient.Email
+		}
+		out = append(out, info)
+	}
+	return out, nil
+}
+
+// uniqueName returns name, or name with a number appended if recipient has a share with that name already
+func (h *userShareHandler) uniqueName(ctx context.Context, recipient *models.User, name string) (string, error) {
+	for i := 0; i < 1000; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)", name, i)
+		}
+
+		var count int64
+		tx := h.DB.WithContext(ctx).Model(&models.UserShare{}).
+			Where("recipient_id = ? AND name = ?", recipient.ID, candidate).
+			Count(&count)
+		if tx.Error != nil {
+			return "", tx.Error
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("Too many shares named %s", name)
+}
+
+// userShareIDHandler is the base of the handlers acting on a single share picked by its id
+type userShareIDHandler struct {
+	DB *gorm.DB
+	// action is applied to the share with id, it returns how many shares it affected
+	action func(tx *gorm.DB, user *models.User, id uint64) *gorm.DB
+}
+
+func (h *userShareIDHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	tx := h.action(h.DB.WithContext(r.Context()), user, id)
+	if tx.Error != nil {
+		http.Error(w, tx.Error.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tx.RowsAffected == 0 {
+		http.Error(w, "No such share", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newUserShareAcceptHandler lets the recipient accept the share with id, after which it shows up in their
+// shared.SharedWithMeDir
+func newUserShareAcceptHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&userShareIDHandler{DB: db, action: acceptUserShare})
+}
+
+// newUserShareDeclineHandler lets the recipient decline the share with id, or leave it if they accepted it before
+func newUserShareDeclineHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&userShareIDHandler{DB: db, action: declineUserShare})
+}
+
+// newUserShareRevokeHandler lets the owner stop sharing with id, the files themselves are kept
+func newUserShareRevokeHandler(db *gorm.DB) http.Handler {
+	return auth.AuthHandler(&userShareIDHandler{DB: db, action: revokeUserShare})
+}
+
+// shares of other users are treated as missing by all of these
+func acceptUserShare(tx *gorm.DB, user *models.User, id uint64) *gorm.DB {
+	return tx.Model(&models.UserShare{}).Where("id = ? AND recipient_id = ?", id, user.ID).Update("accepted", true)
+}
+
+func declineUserShare(tx *gorm.DB, user *models.User, id uint64) *gorm.DB {
+	return tx.Where("id = ? AND recipient_id = ?", id, user.ID).Delete(&models.UserShare{})
+}
+
+func revokeUserShare(tx *gorm.DB, user *models.User, id uint64) *gorm.DB {
+	return tx.Where("id = ? AND user_id = ?", id, user.ID).Delete(&models.UserShare{})
+}
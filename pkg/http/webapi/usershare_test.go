@@ -0,0 +1,126 @@
+package webapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestUserShare(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owner := &models.User{Email: "owner@test.com"}
+	recipient := &models.User{Email: "recipient@test.com"}
+	assert.NoError(t, db.Create(owner).Error)
+	assert.NoError(t, db.Create(recipient).Error)
+
+	memfs := memory.NewStorageProvider()
+	memfs.Data["/folder/test.data"] = []byte("test")
+	memfs.Data["/other/folder/test.data"] = []byte("test")
+	handler := &userShareHandler{DB: db, Storage: memfs}
+	accept := &userShareIDHandler{DB: db, action: acceptUserShare}
+	decline := &userShareIDHandler{DB: db, action: declineUserShare}
+	revoke := &userShareIDHandler{DB: db, action: revokeUserShare}
+
+	post := func(handler interface {
+		Serve(*models.User, http.ResponseWriter, *http.Request)
+	}, user *models.User, target string, form url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, req)
+		return rr
+	}
+
+	listShares := func(user *models.User) userShareList {
+		rr := httptest.NewRecorder()
+		handler.Serve(user, rr, httptest.NewRequest(http.MethodGet, "/webapi/usershare", nil))
+		assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+
+		var out userShareList
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+		return out
+	}
+
+	rr := post(handler, owner, "/webapi/usershare", url.Values{"path": {"/folder"}, "email": {recipient.Email}, "role": {"readwrite"}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+
+	// names are unique for the recipient
+	rr = post(handler, owner, "/webapi/usershare", url.Values{"path": {"/other/folder"}, "email": {recipient.Email}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+
+	// whether there's an account behind an email isn't given away, and sharing twice is no error either
+	for _, email := range []string{"nobody@test.com", recipient.Email} {
+		rr = post(handler, owner, "/webapi/usershare", url.Values{"path": {"/folder"}, "email": {email}})
+		assert.Equal(t, http.StatusNoContent, rr.Code, email)
+		assert.Empty(t, rr.Body.String(), email)
+	}
+
+	for _, form := range []url.Values{
+		{"path": {"/folder/test.data"}, "email": {recipient.Email}},
+		{"path": {"/folder/test.data"}, "email": {"nobody@test.com"}},
+		{"path": {"/folder"}, "email": {owner.Email}},
+		{"path": {"/folder"}, "email": {recipient.Email}, "role": {"owner"}},
+		{"path": {"/Shared with me/folder"}, "email": {recipient.Email}},
+	} {
+		rr = post(handler, owner, "/webapi/usershare", form)
+		assert.GreaterOrEqual(t, rr.Code, http.StatusBadRequest, form.Encode())
+	}
+
+	list := listShares(recipient)
+	assert.Empty(t, list.Outgoing)
+	if !assert.Len(t, list.Incoming, 2) {
+		return
+	}
+	share, second := list.Incoming[0], list.Incoming[1]
+	assert.Equal(t, owner.Email, share.Owner)
+	assert.Equal(t, recipient.Email, share.Recipient)
+	assert.Equal(t, "folder", share.Name)
+	assert.Equal(t, models.GroupRoleReadWrite, share.Role)
+	assert.False(t, share.Accepted)
+	assert.Equal(t, "folder (1)", second.Name)
+	assert.Equal(t, models.GroupRoleRead, second.Role)
+	assert.Len(t, listShares(owner).Outgoing, 2)
+
+	// only the recipient is able to accept or decline a share, and only the owner is able to revoke it
+	id := strconv.FormatUint(share.ID, 10)
+	rr = post(accept, owner, "/webapi/usershare/accept", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(accept, recipient, "/webapi/usershare/accept", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.True(t, listShares(recipient).Incoming[0].Accepted)
+
+	secondID := strconv.FormatUint(second.ID, 10)
+	rr = post(decline, owner, "/webapi/usershare/decline", url.Values{"id": {secondID}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(decline, recipient, "/webapi/usershare/decline", url.Values{"id": {secondID}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+
+	rr = post(revoke, recipient, "/webapi/usershare/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	rr = post(revoke, owner, "/webapi/usershare/revoke", url.Values{"id": {id}})
+	assert.Equal(t, http.StatusNoContent, rr.Code, rr.Result().Status)
+	assert.Empty(t, listShares(recipient).Incoming)
+}
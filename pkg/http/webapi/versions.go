@@ -0,0 +1,100 @@
+package webapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/auth"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/versioning"
+	"github.com/sirupsen/logrus"
+)
+
+// findVersioning looks for the versioning layer in store, returns nil if versioning isn't enabled
+func findVersioning(store storage.StorageProvider) *versioning.VersioningStorageProvider {
+	for ; store != nil; store = storage.Unwrap(store) {
+		if v, ok := store.(*versioning.VersioningStorageProvider); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+type versionsHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newVersionsHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&versionsHandler{Storage: store})
+}
+
+func (h *versionsHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	versions := findVersioning(h.Storage)
+	if versions == nil {
+		http.Error(w, "Versioning isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	filename := r.URL.Query().Get("path")
+	if filename == "" {
+		http.Error(w, "No path specified", http.StatusBadRequest)
+		return
+	}
+
+	list, err := versions.Versions(r.Context(), user, filename)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(list)
+	if err != nil {
+		logrus.Errorf("Error %s while encoding json", err)
+	}
+}
+
+type restoreHandler struct {
+	Storage storage.StorageProvider
+}
+
+func newRestoreHandler(store storage.StorageProvider) http.Handler {
+	return auth.AuthHandler(&restoreHandler{Storage: store})
+}
+
+func (h *restoreHandler) Serve(user *models.User, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Invalid request", http.StatusBadRequest)
+		return
+	}
+
+	versions := findVersioning(h.Storage)
+	if versions == nil {
+		http.Error(w, "Versioning isn't enabled", http.StatusNotFound)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filename := r.Form.Get("path")
+	version := r.Form.Get("version")
+	if filename == "" || version == "" {
+		http.Error(w, "Invalid parameters", http.StatusBadRequest)
+		return
+	}
+
+	err = versions.Restore(r.Context(), user, filename, version)
+	if err != nil {
+		storageError(w, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/?dir=%s", path.Dir(filename)), http.StatusTemporaryRedirect)
+}
@@ -0,0 +1,85 @@
+package webapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/versioning"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersions(t *testing.T) {
+	user := &models.User{
+		ID: 1337,
+	}
+
+	memfs := memory.NewStorageProvider()
+	memfs.Dirs["/folder"] = struct{}{}
+	memfs.Data["/folder/test.data"] = []byte("first")
+	store := versioning.Versioning(memfs, versioning.Policy{})
+
+	file, err := store.File(context.Background(), user, "/folder/test.data")
+	if assert.NoError(t, err) {
+		_, err = file.Write([]byte("second"))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	listVersions := func(filename string) (*httptest.ResponseRecorder, []versioning.Version) {
+		req, err := http.NewRequest(http.MethodGet, "/webapi/versions?path="+url.QueryEscape(filename), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		(&versionsHandler{Storage: store}).Serve(user, rr, req)
+
+		var out []versioning.Version
+		if rr.Code == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&out))
+		}
+		return rr, out
+	}
+
+	restore := func(filename, version string) *httptest.ResponseRecorder {
+		form := url.Values{"path": {filename}, "version": {version}}
+		req, err := http.NewRequest(http.MethodPost, "/webapi/restore", strings.NewReader(form.Encode()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		rr := httptest.NewRecorder()
+		(&restoreHandler{Storage: store}).Serve(user, rr, req)
+		return rr
+	}
+
+	rr, versions := listVersions("/folder/test.data")
+	assert.Equal(t, http.StatusOK, rr.Code, rr.Result().Status)
+	if !assert.Len(t, versions, 1) {
+		return
+	}
+	assert.Equal(t, uint64(len("first")), versions[0].Size)
+
+	rr = restore("/folder/test.data", versions[0].ID)
+	assert.Equal(t, http.StatusTemporaryRedirect, rr.Code, rr.Result().Status)
+	assert.Equal(t, []byte("first"), memfs.Data["/folder/test.data"])
+
+	rr = restore("/folder/test.data", "1234")
+	assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+
+	// without the versioning layer there's nothing to list
+	req, err := http.NewRequest(http.MethodGet, "/webapi/versions?path=/folder/test.data", nil)
+	if assert.NoError(t, err) {
+		rr = httptest.NewRecorder()
+		(&versionsHandler{Storage: memfs}).Serve(user, rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code, rr.Result().Status)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+// AppPassword lets User log in with a generated password rather than their own, meant for clients like WebDAV
+// that have to store it. Only its sha256 hash is kept, which is enough as these are long and random, unlike the
+// passwords people pick themselves, and keeps checking them cheap as those clients send it along every request
+type AppPassword struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint64    `gorm:"index:app_password_idx,unique" json:"-"`
+	User      *User     `json:"-"`
+	Name      string    `json:"name"`
+	Hash      []byte    `gorm:"index:app_password_idx,unique" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// HashAppPassword returns what's stored as the Hash of an AppPassword with password
+func HashAppPassword(password string) []byte {
+	sum := sha256.Sum256([]byte(password))
+	return sum[:]
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// GroupRole decides what members of a group are allowed to do with its shared folders
+type GroupRole string
+
+const (
+	GroupRoleRead      GroupRole = "read"
+	GroupRoleReadWrite GroupRole = "readwrite"
+)
+
+// Valid returns whether r is one of the known roles
+func (r GroupRole) Valid() bool {
+	return r == GroupRoleRead || r == GroupRoleReadWrite
+}
+
+// Group is a set of users sharing folders with each other, managed by the admins
+type Group struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement"`
+	Name      string    `gorm:"index:idx_group_name,unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// GroupMember gives User access to the shared folders of Group, a user is a member of a group at most once
+type GroupMember struct {
+	ID      int64  `gorm:"primaryKey;autoIncrement"`
+	GroupID uint64 `gorm:"index:group_member_idx,unique"`
+	Group   *Group
+	UserID  uint64 `gorm:"index:group_member_idx,unique"`
+	User    *User
+	Role    GroupRole
+}
+
+// SharedFolder belongs to a Group and shows up at /Shared/<Name> for all of its members. The name is
+// unique across all groups, so a user in multiple groups never ends up with two folders of the same name
+type SharedFolder struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"`
+	GroupID   uint64 `gorm:"index:shared_folder_group_id_idx"`
+	Group     *Group
+	Name      string    `gorm:"index:shared_folder_name_idx,unique"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -7,5 +7,13 @@ func InitModels(db *gorm.DB) error {
 		&User{},
 		&UploadLimit{},
 		&DownloadLimit{},
+		&Quota{},
+		&Group{},
+		&GroupMember{},
+		&SharedFolder{},
+		&ShareLink{},
+		&DropLink{},
+		&UserShare{},
+		&AppPassword{},
 	)
 }
@@ -0,0 +1,10 @@
+package models
+
+// Quota limits the amount of bytes a user is allowed to store, users without one are unlimited
+type Quota struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement"`
+	UserID    uint64 `gorm:"index:quota_user_id_idx,unique"`
+	User      *User
+	Unlimited bool
+	Limit     int64
+}
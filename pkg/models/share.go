@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// ShareLink gives anyone knowing Token read-only access to Path of User, without needing an account
+type ShareLink struct {
+	ID     uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Token  string `gorm:"index:share_link_token_idx,unique" json:"token"`
+	UserID uint64 `gorm:"index:share_link_user_id_idx" json:"-"`
+	User   *User  `json:"-"`
+	Path   string `json:"path"`
+	// ExpiresAt is nil for links that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// PasswordHash is the bcrypt hash of the password, it's empty for links that don't have one
+	PasswordHash []byte `json:"-"`
+	// MaxDownloads limits how often files may be downloaded through the link, 0 means unlimited
+	MaxDownloads int64     `json:"max_downloads"`
+	Downloads    int64     `json:"downloads"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Expired returns whether the link expired at now
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// HasPassword returns whether the link is protected with a password
+func (l *ShareLink) HasPassword() bool {
+	return len(l.PasswordHash) > 0
+}
+
+// DropLink lets anyone knowing Token upload files into the directory at Path of User, without being able to
+// see what's in there. Files with a name that's taken already get a number appended rather than replacing it
+type DropLink struct {
+	ID     uint64 `gorm:"primaryKey;autoIncrement" json:"id"`
+	Token  string `gorm:"index:drop_link_token_idx,unique" json:"token"`
+	UserID uint64 `gorm:"index:drop_link_user_id_idx" json:"-"`
+	User   *User  `json:"-"`
+	Path   string `json:"path"`
+	// ExpiresAt is nil for links that never expire
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// MaxFileSize is the largest file in bytes accepted through the link, 0 means unlimited
+	MaxFileSize int64 `json:"max_file_size"`
+	// MaxFiles limits how many files may be uploaded through the link, 0 means unlimited
+	MaxFiles int64 `json:"max_files"`
+	// Uploads is how many files made it through the link, failed uploads aren't counted
+	Uploads   int64     `json:"uploads"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Expired returns whether the link expired at now
+func (l *DropLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// UserShare shares the directory at Path of User with another account, Recipient. Once they accepted it, it
+// shows up for them in their "Shared with me" directory as Name, with the same roles as the shared folders
+// of a group
+type UserShare struct {
+	ID          uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint64    `gorm:"index:user_share_idx,unique" json:"-"`
+	User        *User     `json:"-"`
+	Path        string    `gorm:"index:user_share_idx,unique" json:"path"`
+	RecipientID uint64    `gorm:"index:user_share_idx,unique;index:user_share_name_idx,unique" json:"-"`
+	Recipient   *User     `json:"-"`
+	Name        string    `gorm:"index:user_share_name_idx,unique" json:"name"`
+	Role        GroupRole `json:"role"`
+	Accepted    bool      `json:"accepted"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
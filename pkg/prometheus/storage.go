@@ -12,8 +12,8 @@ import (
 type wrappedStorage struct {
 	store storage.StorageProvider
 
-	promInitUser, promMkdir, promMove, promListDirectory, promDelete *prometheus.SummaryVec
-	promFilesOpen, promFileRead, promFileWrite                       *prometheus.GaugeVec
+	promInitUser, promMkdir, promMove, promCopy, promListDirectory, promList, promStat, promDelete, promDeleteTree, promMetadata *prometheus.SummaryVec
+	promFilesOpen, promFileRead, promFileWrite                                                                                   *prometheus.GaugeVec
 }
 
 func newWrappedStorage(store storage.StorageProvider) *wrappedStorage {
@@ -34,16 +34,41 @@ func newWrappedStorage(store storage.StorageProvider) *wrappedStorage {
 				Name: "move",
 			}, nil,
 		),
+		promCopy: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name: "copy",
+			}, nil,
+		),
 		promListDirectory: prometheus.NewSummaryVec(
 			prometheus.SummaryOpts{
 				Name: "list_directory",
 			}, nil,
 		),
+		promList: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name: "list",
+			}, nil,
+		),
+		promStat: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name: "stat",
+			}, nil,
+		),
 		promDelete: prometheus.NewSummaryVec(
 			prometheus.SummaryOpts{
 				Name: "delete",
 			}, nil,
 		),
+		promDeleteTree: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name: "delete_tree",
+			}, nil,
+		),
+		promMetadata: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Name: "metadata",
+			}, []string{"operation"},
+		),
 		promFilesOpen: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Name: "open_files",
@@ -66,8 +91,13 @@ func newWrappedStorage(store storage.StorageProvider) *wrappedStorage {
 	registry.MustRegister(out.promInitUser,
 		out.promMkdir,
 		out.promMove,
+		out.promCopy,
 		out.promListDirectory,
+		out.promList,
+		out.promMetadata,
+		out.promStat,
 		out.promDelete,
+		out.promDeleteTree,
 		out.promFilesOpen,
 		out.promFileRead,
 		out.promFileWrite,
@@ -76,6 +106,10 @@ func newWrappedStorage(store storage.StorageProvider) *wrappedStorage {
 	return out
 }
 
+func (w *wrappedStorage) Unwrap() storage.StorageProvider {
+	return w.store
+}
+
 func (w *wrappedStorage) InitUser(ctx context.Context, user *models.User) error {
 	start := time.Now()
 	err := w.store.InitUser(ctx, user)
@@ -106,6 +140,16 @@ func (w *wrappedStorage) Move(ctx context.Context, user *models.User, src, dst s
 	return err
 }
 
+func (w *wrappedStorage) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	start := time.Now()
+	err := storage.Copy(ctx, w.store, user, src, dst)
+	took := time.Since(start)
+
+	w.promCopy.With(nil).Observe(float64(took) / float64(time.Second))
+
+	return err
+}
+
 func (w *wrappedStorage) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
 	start := time.Now()
 	ch, err := w.store.ListDirectory(ctx, user, path)
@@ -116,16 +160,30 @@ func (w *wrappedStorage) ListDirectory(ctx context.Context, user *models.User, p
 	return ch, err
 }
 
+func (w *wrappedStorage) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	start := time.Now()
+	info, err := w.store.Stat(ctx, user, fullpath)
+	took := time.Since(start)
+
+	w.promStat.With(nil).Observe(float64(took) / float64(time.Second))
+
+	return info, err
+}
+
 func (w *wrappedStorage) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	file, err := w.store.File(ctx, user, fullpath)
 	if file != nil {
 		w.promFilesOpen.With(nil).Inc()
-		return &wrappedFile{
+		wrapped := wrappedFile{
 			file:  file,
 			open:  w.promFilesOpen,
 			read:  w.promFileRead,
 			write: w.promFileWrite,
-		}, nil
+		}
+		if seekable, ok := file.(storage.SeekableFile); ok {
+			return &wrappedSeekableFile{wrappedFile: wrapped, seeker: seekable}, nil
+		}
+		return &wrapped, nil
 	}
 
 	return nil, err
@@ -141,6 +199,16 @@ func (w *wrappedStorage) Delete(ctx context.Context, user *models.User, fullpath
 	return err
 }
 
+func (w *wrappedStorage) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	start := time.Now()
+	err := w.store.DeleteTree(ctx, user, fullpath)
+	took := time.Since(start)
+
+	w.promDeleteTree.With(nil).Observe(float64(took) / float64(time.Second))
+
+	return err
+}
+
 type wrappedFile struct {
 	file storage.File
 
@@ -152,6 +220,11 @@ func (w *wrappedFile) Close() error {
 	return w.file.Close()
 }
 
+func (w *wrappedFile) Abort() error {
+	w.open.With(nil).Dec()
+	return storage.Abort(w.file)
+}
+
 func (w *wrappedFile) Read(d []byte) (int, error) {
 	n, err := w.file.Read(d)
 	if err == nil {
@@ -169,3 +242,78 @@ func (w *wrappedFile) Write(d []byte) (int, error) {
 
 	return n, err
 }
+
+type wrappedSeekableFile struct {
+	wrappedFile
+	seeker storage.SeekableFile
+}
+
+func (w *wrappedSeekableFile) ReadAt(d []byte, off int64) (int, error) {
+	n, err := w.seeker.ReadAt(d, off)
+	if n > 0 {
+		w.read.With(nil).Add(float64(n))
+	}
+
+	return n, err
+}
+
+func (w *wrappedSeekableFile) WriteAt(d []byte, off int64) (int, error) {
+	n, err := w.seeker.WriteAt(d, off)
+	if err == nil {
+		w.write.With(nil).Add(float64(n))
+	}
+
+	return n, err
+}
+
+func (w *wrappedSeekableFile) Seek(offset int64, whence int) (int64, error) {
+	return w.seeker.Seek(offset, whence)
+}
+
+func (w *wrappedStorage) List(ctx context.Context, user *models.User, path string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	start := time.Now()
+	ch, err := storage.List(ctx, w.store, user, path, opts)
+	took := time.Since(start)
+
+	w.promList.With(nil).Observe(float64(took) / float64(time.Second))
+
+	return ch, err
+}
+
+func (w *wrappedStorage) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	return storage.Watch(ctx, w.store, user)
+}
+
+func (w *wrappedStorage) AtomicWrites() bool {
+	return storage.AtomicWrites(w.store)
+}
+
+func (w *wrappedStorage) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	start := time.Now()
+	value, err := storage.GetMetadata(ctx, w.store, user, fullpath, key)
+	took := time.Since(start)
+
+	w.promMetadata.WithLabelValues("get").Observe(float64(took) / float64(time.Second))
+
+	return value, err
+}
+
+func (w *wrappedStorage) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	start := time.Now()
+	err := storage.SetMetadata(ctx, w.store, user, fullpath, key, value)
+	took := time.Since(start)
+
+	w.promMetadata.WithLabelValues("set").Observe(float64(took) / float64(time.Second))
+
+	return err
+}
+
+func (w *wrappedStorage) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	start := time.Now()
+	metadata, err := storage.ListMetadata(ctx, w.store, user, fullpath)
+	took := time.Since(start)
+
+	w.promMetadata.WithLabelValues("list").Observe(float64(took) / float64(time.Second))
+
+	return metadata, err
+}
@@ -0,0 +1,31 @@
+package storage
+
+// AtomicWriter can optionally be implemented by your StorageProvider to advertise that files written from
+// the start never end up partially written. The new contents only replace the old ones once Close succeeds,
+// until then everyone keeps seeing the old contents (or no file at all). Writes after a Seek modify the file
+// in place and don't have to be atomic. Callers should use AtomicWrites rather than checking for this.
+type AtomicWriter interface {
+	AtomicWrites() bool
+}
+
+// Aborter can optionally be implemented by the File returned from your StorageProvider, to throw away
+// everything written to it instead of keeping it like Close does. It closes the file as well.
+// Callers should use Abort, which falls back to Close if this isn't implemented.
+type Aborter interface {
+	Abort() error
+}
+
+// AtomicWrites reports whether provider guarantees files written from the start are replaced atomically
+func AtomicWrites(provider StorageProvider) bool {
+	writer, ok := provider.(AtomicWriter)
+	return ok && writer.AtomicWrites()
+}
+
+// Abort throws away what was written to file if it's able to, it's closed either way.
+// Use this rather than Close when a write failed halfway, like an upload that got interrupted
+func Abort(file File) error {
+	if aborter, ok := file.(Aborter); ok {
+		return aborter.Abort()
+	}
+	return file.Close()
+}
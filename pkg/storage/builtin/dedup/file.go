@@ -0,0 +1,210 @@
+package dedup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// File reads straight from the blob, while writes go to a temporary file first.
+// Once closed we know the hash and move it into place, unless we already have the same contents.
+type File struct {
+	provider *StorageProvider
+	user     *models.User
+	fullpath string
+
+	read  *os.File
+	write *os.File
+	hash  hash.Hash
+	size  uint64
+	// the first error writing to write, after which closing throws it away rather than committing it
+	writeErr error
+}
+
+func (f *File) openRead() error {
+	if f.write != nil {
+		return errors.New("File is already opened in write mode")
+	}
+	if f.read != nil {
+		return nil
+	}
+
+	entry, err := f.provider.lookup(f.provider.db, f.user, f.fullpath)
+	if err != nil {
+		return err
+	} else if entry.Directory {
+		return fmt.Errorf("%s is a directory", f.fullpath)
+	}
+
+	f.read, err = os.Open(f.provider.blobPath(entry.Hash))
+	return err
+}
+
+func (f *File) openWrite() error {
+	if f.read != nil {
+		return errors.New("File is already opened in read mode")
+	}
+	if f.write != nil {
+		return nil
+	}
+
+	// we check up front whether we'll actually be able to store this file
+	parent, err := f.provider.lookup(f.provider.db, f.user, path.Dir(f.fullpath))
+	if err != nil {
+		return err
+	} else if !parent.Directory {
+		return fmt.Errorf("%w: %s", storage.ErrNotDirectory, path.Dir(f.fullpath))
+	}
+	entry, err := f.provider.lookup(f.provider.db, f.user, f.fullpath)
+	if err == nil && entry.Directory {
+		return fmt.Errorf("%w: %s is a directory", storage.ErrExist, f.fullpath)
+	} else if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	f.write, err = os.CreateTemp(f.provider.tmpDir(), "upload-")
+	if err != nil {
+		return err
+	}
+	f.hash = sha256.New()
+	return nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
+
+	return f.read.Read(p)
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	err := f.openWrite()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.write.Write(p)
+	f.hash.Write(p[:n])
+	f.size += uint64(n)
+	if err != nil && f.writeErr == nil {
+		f.writeErr = err
+	}
+	return n, err
+}
+
+func (f *File) Close() error {
+	if f.read != nil {
+		return f.read.Close()
+	}
+	if f.write != nil {
+		return f.commit()
+	}
+	return nil
+}
+
+// Abort throws away the temporary file, leaving the entry as it was
+func (f *File) Abort() error {
+	if f.write == nil {
+		return f.Close()
+	}
+	f.write.Close()
+	return os.Remove(f.write.Name())
+}
+
+// commit moves the written data into place and points the entry at it
+func (f *File) commit() error {
+	tmp := f.write.Name()
+	defer os.Remove(tmp)
+
+	err := f.writeErr
+	if err == nil {
+		err = f.write.Sync()
+	}
+	if closeErr := f.write.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	sum := hex.EncodeToString(f.hash.Sum(nil))
+
+	f.provider.mutex.Lock()
+	defer f.provider.mutex.Unlock()
+
+	var removed []string
+	created := false
+	err = f.provider.db.WithContext(context.Background()).Transaction(func(tx *gorm.DB) error {
+		blob := &Blob{}
+		res := tx.Limit(1).Find(blob, "hash = ?", sum)
+		if res.Error != nil {
+			return res.Error
+		}
+
+		if res.RowsAffected > 0 {
+			err := f.provider.reference(tx, sum)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := os.MkdirAll(path.Dir(f.provider.blobPath(sum)), 0700)
+			if err != nil {
+				return err
+			}
+			err = os.Rename(tmp, f.provider.blobPath(sum))
+			if err != nil {
+				return err
+			}
+			created = true
+
+			err = tx.Create(&Blob{Hash: sum, Size: f.size, RefCount: 1}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		entry, err := f.provider.lookup(tx, f.user, f.fullpath)
+		if errors.Is(err, storage.ErrNotExist) {
+			return tx.Create(&Entry{
+				UserID: f.user.ID,
+				Path:   f.fullpath,
+				Dir:    path.Dir(f.fullpath),
+				Hash:   sum,
+				Size:   f.size,
+			}).Error
+		} else if err != nil {
+			return err
+		}
+
+		old := entry.Hash
+		err = tx.Model(entry).Updates(map[string]interface{}{
+			"hash": sum,
+			"size": f.size,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return f.provider.release(tx, old, &removed)
+	})
+	if err != nil {
+		// the blob we just moved into place isn't referenced by anything now
+		if created {
+			os.Remove(f.provider.blobPath(sum))
+		}
+		return err
+	}
+
+	removeBlobs(removed)
+	return nil
+}
@@ -0,0 +1,31 @@
+package dedup
+
+import "time"
+
+// Entry is a single file or directory of a user, files point to the Blob holding their contents
+type Entry struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"`
+	UserID    uint64 `gorm:"index:dedup_entry_path_idx,unique;index:dedup_entry_dir_idx"`
+	Path      string `gorm:"index:dedup_entry_path_idx,unique"`
+	Dir       string `gorm:"index:dedup_entry_dir_idx"`
+	Directory bool
+	Hash      string
+	Size      uint64
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (Entry) TableName() string {
+	return "dedup_entries"
+}
+
+// Blob is a file stored on disk by its sha256, RefCount is the amount of entries pointing to it
+type Blob struct {
+	Hash     string `gorm:"primaryKey"`
+	Size     uint64
+	RefCount int64
+}
+
+func (Blob) TableName() string {
+	return "dedup_blobs"
+}
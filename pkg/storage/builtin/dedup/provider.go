@@ -0,0 +1,487 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StorageProvider stores the contents of files by their sha256 on the local filesystem, so identical
+// files are only stored once. Which path points to which contents is kept in the database.
+type StorageProvider struct {
+	RootPath string `yaml:"path"`
+
+	db *gorm.DB
+
+	// protects the reference counting, so a blob can't get removed while it's getting referenced again
+	mutex sync.Mutex
+}
+
+func NewStorageProvider(dir string, db *gorm.DB) (*StorageProvider, error) {
+	out := &StorageProvider{
+		RootPath: dir,
+		db:       db,
+	}
+
+	err := db.AutoMigrate(&Entry{}, &Blob{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range []string{out.blobDir(), out.tmpDir()} {
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func (s *StorageProvider) blobDir() string {
+	return filepath.Join(s.RootPath, "blobs")
+}
+
+func (s *StorageProvider) tmpDir() string {
+	return filepath.Join(s.RootPath, "tmp")
+}
+
+func (s *StorageProvider) blobPath(hash string) string {
+	return filepath.Join(s.blobDir(), hash[:2], hash)
+}
+
+func cleanPath(fullpath string) string {
+	return path.Join("/", fullpath)
+}
+
+// subtree returns a query matching everything inside of the directory fullpath
+func subtree(tx *gorm.DB, user *models.User, fullpath string) *gorm.DB {
+	prefix := strings.TrimSuffix(fullpath, "/") + "/"
+	return tx.Where("user_id = ? AND substr(path, 1, ?) = ?", user.ID, len(prefix), prefix)
+}
+
+// lookup returns the entry at fullpath, the root directory always exists even though it's not stored
+func (s *StorageProvider) lookup(tx *gorm.DB, user *models.User, fullpath string) (*Entry, error) {
+	if fullpath == "/" {
+		return &Entry{UserID: user.ID, Path: "/", Directory: true}, nil
+	}
+
+	entry := &Entry{}
+	res := tx.Limit(1).Find(entry, "user_id = ? AND path = ?", user.ID, fullpath)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+	return entry, nil
+}
+
+// checkTarget makes sure dst doesn't exist yet and its parent is a directory
+func (s *StorageProvider) checkTarget(tx *gorm.DB, user *models.User, dst string) error {
+	_, err := s.lookup(tx, user, dst)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	parent, err := s.lookup(tx, user, path.Dir(dst))
+	if err != nil {
+		return err
+	} else if !parent.Directory {
+		return fmt.Errorf("%w: %s", storage.ErrNotDirectory, path.Dir(dst))
+	}
+	return nil
+}
+
+// reference increases the reference count of hash, which should already exist
+func (s *StorageProvider) reference(tx *gorm.DB, hash string) error {
+	return tx.Model(&Blob{}).Where("hash = ?", hash).Update("ref_count", gorm.Expr("ref_count + 1")).Error
+}
+
+// release decreases the reference count of hash, and adds the blob to removed in case it's no longer used.
+// the caller is responsible for actually removing these files after committing the transaction
+func (s *StorageProvider) release(tx *gorm.DB, hash string, removed *[]string) error {
+	blob := &Blob{}
+	err := tx.First(blob, "hash = ?", hash).Error
+	if err != nil {
+		return err
+	}
+
+	if blob.RefCount > 1 {
+		return tx.Model(blob).Update("ref_count", blob.RefCount-1).Error
+	}
+
+	err = tx.Delete(blob).Error
+	if err != nil {
+		return err
+	}
+	*removed = append(*removed, s.blobPath(hash))
+	return nil
+}
+
+func removeBlobs(removed []string) {
+	for _, blob := range removed {
+		err := os.Remove(blob)
+		if err != nil {
+			logrus.Errorf("Failed to remove unused blob: %s", err)
+		}
+	}
+}
+
+func (s *StorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return nil
+}
+
+func (s *StorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	dir = cleanPath(dir)
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// we go over all the parents first, creating them if they don't exist yet
+		parts := strings.Split(strings.TrimPrefix(dir, "/"), "/")
+		current := "/"
+		for _, part := range parts {
+			if part == "" {
+				continue
+			}
+			current = path.Join(current, part)
+
+			entry, err := s.lookup(tx, user, current)
+			if errors.Is(err, storage.ErrNotExist) {
+				err = tx.Create(&Entry{
+					UserID:    user.ID,
+					Path:      current,
+					Dir:       path.Dir(current),
+					Directory: true,
+				}).Error
+				if err != nil {
+					return err
+				}
+				continue
+			} else if err != nil {
+				return err
+			}
+
+			if !entry.Directory {
+				return fmt.Errorf("%w: %s", storage.ErrNotDirectory, current)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	src = cleanPath(src)
+	dst = cleanPath(dst)
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.lookup(tx, user, src)
+		if err != nil {
+			return err
+		}
+		err = s.checkTarget(tx, user, dst)
+		if err != nil {
+			return err
+		}
+		if src == "/" || strings.HasPrefix(dst, src+"/") {
+			return fmt.Errorf("%w: %s is inside of %s", storage.ErrInvalidPath, dst, src)
+		}
+
+		if entry.Directory {
+			var children []Entry
+			err = subtree(tx, user, src).Find(&children).Error
+			if err != nil {
+				return err
+			}
+
+			for _, child := range children {
+				newPath := dst + strings.TrimPrefix(child.Path, src)
+				err = tx.Model(&child).Updates(map[string]interface{}{
+					"path": newPath,
+					"dir":  path.Dir(newPath),
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Model(entry).Updates(map[string]interface{}{
+			"path": dst,
+			"dir":  path.Dir(dst),
+		}).Error
+	})
+}
+
+// Copy only has to copy the entries, as the contents are shared anyway
+func (s *StorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	src = cleanPath(src)
+	dst = cleanPath(dst)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.lookup(tx, user, src)
+		if err != nil {
+			return err
+		}
+		err = s.checkTarget(tx, user, dst)
+		if err != nil {
+			return err
+		}
+		if src == "/" || strings.HasPrefix(dst, src+"/") {
+			return fmt.Errorf("%w: %s is inside of %s", storage.ErrInvalidPath, dst, src)
+		}
+
+		entries := []Entry{*entry}
+		if entry.Directory {
+			var children []Entry
+			err = subtree(tx, user, src).Find(&children).Error
+			if err != nil {
+				return err
+			}
+			entries = append(entries, children...)
+		}
+
+		for _, entry := range entries {
+			newPath := dst + strings.TrimPrefix(entry.Path, src)
+			err = tx.Create(&Entry{
+				UserID:    user.ID,
+				Path:      newPath,
+				Dir:       path.Dir(newPath),
+				Directory: entry.Directory,
+				Hash:      entry.Hash,
+				Size:      entry.Size,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			if entry.Hash != "" {
+				err = s.reference(tx, entry.Hash)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func toFileInfo(entry *Entry) storage.FileInfo {
+	return storage.FileInfo{
+		Name:      path.Base(entry.Path),
+		FullPath:  entry.Path,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		Size:      entry.Size,
+		Directory: entry.Directory,
+		// the contents are stored by their hash, which makes for the perfect etag
+		ETag: entry.Hash,
+	}
+}
+
+func (s *StorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	dir = cleanPath(dir)
+
+	entry, err := s.lookup(s.db.WithContext(ctx), user, dir)
+	if err != nil {
+		return nil, err
+	} else if !entry.Directory {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotDirectory, dir)
+	}
+
+	var entries []Entry
+	err = s.db.WithContext(ctx).Find(&entries, "user_id = ? AND dir = ? AND path != '/'", user.ID, dir).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan storage.FileInfo, len(entries))
+	for i := range entries {
+		out <- toFileInfo(&entries[i])
+	}
+	close(out)
+
+	return out, nil
+}
+
+// List sorts and pages by name and size in the database, anything else is left to storage.StreamList
+func (s *StorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	if opts.Sort != storage.SortName && opts.Sort != storage.SortSize {
+		return storage.StreamList(ctx, s, user, dir, opts)
+	}
+	dir = cleanPath(dir)
+
+	entry, err := s.lookup(s.db.WithContext(ctx), user, dir)
+	if err != nil {
+		return nil, err
+	} else if !entry.Directory {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotDirectory, dir)
+	}
+
+	// all entries share the same dir, so sorting by path is the same as sorting by name
+	order, cmp := "ASC", ">"
+	if opts.Descending {
+		order, cmp = "DESC", "<"
+	}
+	query := s.db.WithContext(ctx).Where("user_id = ? AND dir = ? AND path != '/'", user.ID, dir)
+	if opts.Sort == storage.SortSize {
+		query = query.Order("size " + order)
+	}
+	query = query.Order("path " + order)
+
+	if opts.Cursor != "" {
+		cursor := storage.FileInfo{Name: opts.Cursor}
+		if opts.Sort == storage.SortSize {
+			cursor, err = storage.ParseCursor(opts.Sort, opts.Cursor)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("(size "+cmp+" ? OR (size = ? AND path "+cmp+" ?))", cursor.Size, cursor.Size, path.Join(dir, cursor.Name))
+		} else {
+			query = query.Where("path "+cmp+" ?", path.Join(dir, cursor.Name))
+		}
+	}
+	// the filter is applied afterwards, so we can only limit the query without one
+	if opts.Limit > 0 && opts.Filter == "" {
+		query = query.Limit(opts.Limit)
+	}
+
+	var entries []Entry
+	err = query.Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan storage.FileInfo, len(entries))
+	sent := 0
+	for i := range entries {
+		if opts.Limit > 0 && sent >= opts.Limit {
+			break
+		}
+		if opts.Match(path.Base(entries[i].Path)) {
+			out <- toFileInfo(&entries[i])
+			sent++
+		}
+	}
+	close(out)
+
+	return out, nil
+}
+
+func (s *StorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	entry, err := s.lookup(s.db.WithContext(ctx), user, cleanPath(fullpath))
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	return toFileInfo(entry), nil
+}
+
+func (s *StorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	return &File{
+		provider: s,
+		user:     user,
+		fullpath: cleanPath(fullpath),
+	}, nil
+}
+
+// AtomicWrites is always true, as the entry only points at the new contents once the file is closed
+func (s *StorageProvider) AtomicWrites() bool {
+	return true
+}
+
+func (s *StorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	fullpath = cleanPath(fullpath)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var removed []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.lookup(tx, user, fullpath)
+		if err != nil {
+			return err
+		}
+
+		if entry.Directory {
+			var children int64
+			err = subtree(tx, user, fullpath).Model(&Entry{}).Count(&children).Error
+			if err != nil {
+				return err
+			} else if children > 0 || fullpath == "/" {
+				return fmt.Errorf("%w: %s", storage.ErrNotEmpty, fullpath)
+			}
+		}
+
+		return s.deleteEntry(tx, entry, &removed)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(removed)
+	return nil
+}
+
+func (s *StorageProvider) deleteEntry(tx *gorm.DB, entry *Entry, removed *[]string) error {
+	err := tx.Delete(entry).Error
+	if err != nil {
+		return err
+	}
+	if entry.Hash != "" {
+		return s.release(tx, entry.Hash, removed)
+	}
+	return nil
+}
+
+func (s *StorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	fullpath = cleanPath(fullpath)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var removed []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		entry, err := s.lookup(tx, user, fullpath)
+		if err != nil {
+			return err
+		}
+
+		var entries []Entry
+		err = subtree(tx, user, fullpath).Find(&entries).Error
+		if err != nil {
+			return err
+		}
+		// the root directory itself isn't actually stored
+		if entry.ID != 0 {
+			entries = append(entries, *entry)
+		}
+
+		for i := range entries {
+			err = s.deleteEntry(tx, &entries[i], &removed)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(removed)
+	return nil
+}
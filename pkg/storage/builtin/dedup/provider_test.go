@@ -0,0 +1,120 @@
+package dedup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupProvider(t testing.TB) *StorageProvider {
+	dir := t.TempDir()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	provider, err := NewStorageProvider(filepath.Join(dir, "data"), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestDedup(t *testing.T) {
+	storage.TestStorageProvider(setupProvider(t), t)
+}
+
+func BenchmarkDedup(b *testing.B) {
+	storage.BenchmarkStorageProvider(setupProvider(b), b)
+}
+
+func TestDedupSharesBlobs(t *testing.T) {
+	ctx := context.Background()
+	provider := setupProvider(t)
+	user := &models.User{ID: 1}
+	other := &models.User{ID: 2}
+	contents := []byte("the same installer, uploaded again")
+
+	write := func(user *models.User, filename string) {
+		file, err := provider.File(ctx, user, filename)
+		if assert.NoError(t, err) {
+			_, err = file.Write(contents)
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+		}
+	}
+	blobs := func() int {
+		var count int64
+		assert.NoError(t, provider.db.Model(&Blob{}).Count(&count).Error)
+
+		files, err := filepath.Glob(filepath.Join(provider.blobDir(), "*", "*"))
+		assert.NoError(t, err)
+		assert.Equal(t, int(count), len(files))
+
+		return len(files)
+	}
+
+	write(user, "first")
+	write(user, "second")
+	write(other, "first")
+	assert.NoError(t, provider.Copy(ctx, user, "first", "copy"))
+	assert.Equal(t, 1, blobs())
+
+	blob := &Blob{}
+	if assert.NoError(t, provider.db.First(blob).Error) {
+		assert.Equal(t, int64(4), blob.RefCount)
+		assert.Equal(t, uint64(len(contents)), blob.Size)
+	}
+
+	assert.NoError(t, provider.Delete(ctx, user, "first"))
+	assert.NoError(t, provider.Delete(ctx, user, "second"))
+	assert.NoError(t, provider.Delete(ctx, other, "first"))
+	assert.Equal(t, 1, blobs())
+
+	assert.NoError(t, provider.Delete(ctx, user, "copy"))
+	assert.Equal(t, 0, blobs())
+
+	// nothing should be left behind from the uploads either
+	tmp, err := os.ReadDir(provider.tmpDir())
+	assert.NoError(t, err)
+	assert.Empty(t, tmp)
+}
+
+func TestDedupFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	provider := setupProvider(t)
+	user := &models.User{ID: 1}
+
+	file, err := provider.File(ctx, user, "partial")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = file.Write([]byte("the first half"))
+	assert.NoError(t, err)
+
+	// swapping the temporary file for a read-only handle of it makes every write after this fail, while
+	// syncing and closing it still work
+	f := file.(*File)
+	assert.NoError(t, f.write.Close())
+	f.write, err = os.Open(f.write.Name())
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = file.Write([]byte("the second half"))
+	assert.Error(t, err)
+	assert.Error(t, file.Close())
+
+	_, err = provider.Stat(ctx, user, "partial")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	tmp, err := os.ReadDir(provider.tmpDir())
+	assert.NoError(t, err)
+	assert.Empty(t, tmp)
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package local
+
+import (
+	"errors"
+	"os"
+)
+
+func cloneFile(dst, src *os.File) error {
+	return errors.New("Reflinks are not supported on this platform")
+}
@@ -0,0 +1,102 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+func (s *StorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	srcPath, err := s.resolve(user, src, true)
+	if err != nil {
+		return err
+	}
+	dstPath, err := s.resolve(user, dst, false)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(srcPath)
+	if err != nil {
+		return toStorageError(err, src)
+	}
+
+	_, err = os.Lstat(dstPath)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return toStorageError(err, dst)
+	}
+
+	if !info.IsDir() {
+		return toStorageError(copyFile(srcPath, dstPath), dst)
+	}
+
+	if strings.HasPrefix(dstPath+"/", srcPath+"/") {
+		return fmt.Errorf("%w: can't copy %s into itself", storage.ErrInvalidPath, src)
+	}
+
+	err = filepath.WalkDir(srcPath, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcPath, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstPath, rel)
+
+		switch {
+		case entry.IsDir():
+			err = os.Mkdir(target, 0700)
+			if err != nil {
+				return err
+			}
+			return copyMetadata(name, target)
+		case entry.Type().IsRegular() && !isTemp(entry.Name()):
+			return copyFile(name, target)
+		}
+		// anything else, like symlinks or writes still in progress, we simply don't copy
+		return nil
+	})
+	return toStorageError(err, dst)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0700)
+	if err != nil {
+		return err
+	}
+
+	// we first try to just share the blocks of the file, which filesystems like btrfs and xfs support.
+	// if that doesn't work, io.Copy between 2 files will use copy_file_range wherever possible
+	if cloneFile(out, in) != nil {
+		_, err = io.Copy(out, in)
+	}
+	if err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+	return copyMetadata(src, dst)
+}
@@ -0,0 +1,39 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// toStorageError turns the errors returned by the os package into the matching storage errors,
+// we only include the path as seen by the user, so we don't leak where on disk we're storing things
+func toStorageError(err error, fullpath string) error {
+	var target error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, fs.ErrNotExist):
+		target = storage.ErrNotExist
+	case errors.Is(err, syscall.ENOTEMPTY):
+		target = storage.ErrNotEmpty
+	case errors.Is(err, fs.ErrExist):
+		target = storage.ErrExist
+	case errors.Is(err, syscall.ENOTDIR):
+		target = storage.ErrNotDirectory
+	case errors.Is(err, syscall.EROFS):
+		target = storage.ErrReadOnly
+	case errors.Is(err, syscall.EDQUOT), errors.Is(err, syscall.ENOSPC):
+		target = storage.ErrQuotaExceeded
+	case errors.Is(err, errOutsideRoot), errors.Is(err, syscall.ELOOP):
+		target = storage.ErrInvalidPath
+	case errors.Is(err, syscall.ENAMETOOLONG), errors.Is(err, syscall.EINVAL):
+		target = storage.ErrInvalidPath
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %s", target, fullpath)
+}
@@ -1,55 +1,301 @@
 package local
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 )
 
+// tempPrefix starts the name of the temporary files we write to before renaming them into place,
+// these are hidden from listings and watchers
+const tempPrefix = ".leicht-write-"
+
+func isTemp(name string) bool {
+	return strings.HasPrefix(name, tempPrefix)
+}
+
+// createTemp creates a new temporary file in dir, returning it along with its path within root
+func createTemp(root, dir string) (*os.File, string, error) {
+	for i := 0; ; i++ {
+		random := make([]byte, 8)
+		_, err := rand.Read(random)
+		if err != nil {
+			return nil, "", err
+		}
+
+		rel := path.Join(dir, tempPrefix+hex.EncodeToString(random))
+		file, err := openBeneath(root, rel, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0700)
+		if errors.Is(err, fs.ErrExist) && i < 10 {
+			continue
+		}
+		return file, rel, err
+	}
+}
+
+// File writes to a temporary file next to the actual one when it gets written from the start, which
+// replaces the actual file once it's closed. Writing after a Seek modifies the actual file in place.
 type File struct {
 	FullPath string
 
+	// the root of the user and the path within it, we never open anything outside of root
+	root, rel string
+	// the path as the user sees it, used in errors
+	name string
+
 	read  *os.File
 	write *os.File
+
+	// offset we should seek to once the file actually gets opened, as we only open
+	// the underlying file on the first read or write
+	offset int64
+	// whether we got positioned explicitly, in which case writing modifies the file in place
+	seeked bool
+
+	// the temporary file we're writing to and what it replaces once closed, both relative to root.
+	// Unlike rel the target has every symlink resolved, so we write through them rather than replace them
+	temp, target string
+	// the first error writing to temp, after which we won't rename it into place
+	writeErr error
 }
 
-func (f *File) Read(p []byte) (int, error) {
+func (f *File) openRead() error {
 	if f.write != nil {
-		return 0, errors.New("File is already opened in write mode")
+		return errors.New("File is already opened in write mode")
 	}
 	if f.read == nil {
-		file, err := os.OpenFile(f.FullPath, os.O_RDONLY, 0700)
+		file, err := openBeneath(f.root, f.rel, os.O_RDONLY, 0700)
 		if err != nil {
-			return 0, err
+			return toStorageError(err, f.name)
+		}
+		if f.offset != 0 {
+			_, err = file.Seek(f.offset, io.SeekStart)
+			if err != nil {
+				file.Close()
+				return err
+			}
 		}
 
 		f.read = file
 	}
+	return nil
+}
+
+// openWrite opens the file for writing, starting out with an empty temporary file if truncate is set
+func (f *File) openWrite(truncate bool) error {
+	if f.read != nil {
+		return errors.New("File is already opened in read mode")
+	}
+	if f.write == nil && truncate {
+		target, err := resolveBeneath(f.root, f.rel)
+		if err != nil {
+			return toStorageError(err, f.name)
+		}
+		info, err := os.Stat(path.Join(f.root, target))
+		if err == nil && info.IsDir() {
+			return fmt.Errorf("%w: %s is a directory", storage.ErrExist, f.name)
+		}
+
+		file, temp, err := createTemp(f.root, path.Dir(target))
+		if err != nil {
+			return toStorageError(err, f.name)
+		}
+		f.write = file
+		f.temp = temp
+		f.target = target
+	} else if f.write == nil {
+		file, err := openBeneath(f.root, f.rel, os.O_WRONLY|os.O_CREATE, 0700)
+		if err != nil {
+			return toStorageError(err, f.name)
+		}
+		if f.offset != 0 {
+			_, err = file.Seek(f.offset, io.SeekStart)
+			if err != nil {
+				file.Close()
+				return err
+			}
+		}
+
+		f.write = file
+	}
+	return nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
 
 	return f.read.Read(p)
 }
 
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
+
+	return f.read.ReadAt(p, off)
+}
+
 func (f *File) Close() error {
 	if f.read != nil {
 		return f.read.Close()
 	}
+	if f.write != nil && f.temp != "" {
+		return f.commit()
+	}
 	if f.write != nil {
 		return f.write.Close()
 	}
 	return nil
 }
 
+// Abort throws away everything written since the file was opened, as long as it was written from the start
+func (f *File) Abort() error {
+	if f.temp == "" {
+		return f.Close()
+	}
+	f.write.Close()
+	return f.discard()
+}
+
+// discard removes the temporary file, which has to be closed already
+func (f *File) discard() error {
+	err := os.Remove(path.Join(f.root, f.temp))
+	f.temp = ""
+	return toStorageError(err, f.name)
+}
+
+// commit makes sure everything written to the temporary file is on disk, before renaming it over the actual file
+func (f *File) commit() error {
+	err := f.writeErr
+	if err == nil {
+		err = f.write.Sync()
+	}
+	if closeErr := f.write.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		f.discard()
+		return toStorageError(err, f.name)
+	}
+
+	err = f.replace()
+	if err != nil {
+		f.discard()
+		return toStorageError(err, f.name)
+	}
+	f.temp = ""
+
+	// the rename itself only survives a crash once the directory is synced as well, but as the file is in
+	// place already we don't fail over this. Not every filesystem supports syncing directories either
+	dir, err := openBeneath(f.root, path.Dir(f.target), os.O_RDONLY, 0)
+	if err == nil {
+		_ = dir.Sync()
+		dir.Close()
+	}
+	return nil
+}
+
+// replace renames the temporary file over the actual file, keeping the permissions and metadata of the
+// file it replaces
+func (f *File) replace() error {
+	// the directories leading up to it could've been swapped for a symlink in the meantime
+	_, err := resolveBeneath(f.root, path.Dir(f.target))
+	if err != nil {
+		return err
+	}
+	target := path.Join(f.root, f.target)
+	temp := path.Join(f.root, f.temp)
+
+	info, err := os.Lstat(target)
+	if err == nil && info.Mode().IsRegular() {
+		err = os.Chmod(temp, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		err = copyMetadata(target, temp)
+		if err != nil {
+			return err
+		}
+	} else if err == nil && info.IsDir() {
+		return fmt.Errorf("%w: %s is a directory", storage.ErrExist, f.name)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return os.Rename(temp, target)
+}
+
+// Write replaces the contents of the file, unless Seek was called first
 func (f *File) Write(p []byte) (int, error) {
+	err := f.openWrite(!f.seeked)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.write.Write(p)
+	if err != nil && f.writeErr == nil {
+		f.writeErr = err
+	}
+	return n, toStorageError(err, f.name)
+}
+
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	err := f.openWrite(false)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.write.WriteAt(p, off)
+	if err != nil && f.writeErr == nil {
+		f.writeErr = err
+	}
+	return n, toStorageError(err, f.name)
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.seeked = true
 	if f.read != nil {
-		return 0, errors.New("File is already opened in read mode")
+		return f.read.Seek(offset, whence)
+	}
+	if f.write != nil {
+		return f.write.Seek(offset, whence)
 	}
-	if f.write == nil {
-		file, err := os.OpenFile(f.FullPath, os.O_WRONLY|os.O_CREATE, 0700)
+
+	// the file isn't opened yet, so we just keep track of the offset ourselves
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		file, err := openBeneath(f.root, f.rel, os.O_RDONLY, 0)
+		if err != nil {
+			return 0, toStorageError(err, f.name)
+		}
+		info, err := file.Stat()
+		file.Close()
 		if err != nil {
-			return 0, err
+			return 0, toStorageError(err, f.name)
 		}
+		offset += info.Size()
+	default:
+		return 0, errors.New("Invalid whence")
+	}
 
-		f.write = file
+	if offset < 0 {
+		return 0, errors.New("Negative position")
 	}
 
-	return f.write.Write(p)
+	f.offset = offset
+	return offset, nil
 }
@@ -0,0 +1,95 @@
+package local
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"syscall"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+const listBatchSize = 256
+
+// openDir opens the directory rel inside of root, failing with ENOTDIR if it's something else
+func openDir(root, rel string) (*os.File, error) {
+	f, err := openBeneath(root, rel, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if !info.IsDir() {
+		f.Close()
+		return nil, &fs.PathError{Op: "readdir", Path: f.Name(), Err: syscall.ENOTDIR}
+	}
+	return f, nil
+}
+
+func (s *StorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	if opts.Sort != storage.SortName {
+		files, err := s.ListDirectory(ctx, user, dir)
+		if err != nil || (opts.Sort == storage.SortNone && opts.Filter == "") {
+			return files, err
+		}
+		return storage.ApplyListOptions(ctx, files, opts), nil
+	}
+
+	// sorting by name only needs the names, so we only look up the entries that end up in the page we return
+	root := s.userRoot(user)
+	rel := path.Clean("/" + dir)
+	f, err := openDir(root, rel)
+	if err != nil {
+		return nil, toStorageError(err, dir)
+	}
+	names, err := f.Readdirnames(-1)
+	f.Close()
+	if err != nil {
+		return nil, toStorageError(err, dir)
+	}
+
+	page := make([]string, 0, len(names))
+	for _, name := range names {
+		if !isTemp(name) && opts.Match(name) && opts.After(storage.FileInfo{Name: name}) {
+			page = append(page, name)
+		}
+	}
+	if opts.Descending {
+		sort.Sort(sort.Reverse(sort.StringSlice(page)))
+	} else {
+		sort.Strings(page)
+	}
+
+	out := make(chan storage.FileInfo)
+
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+		sent := 0
+		for _, name := range page {
+			if opts.Limit > 0 && sent >= opts.Limit {
+				return
+			}
+			// anything deleted in the meantime is simply skipped, just like ListDirectory does
+			info, err := lookup(root, path.Join(rel, name), dir, name)
+			if err != nil {
+				continue
+			}
+			select {
+			case out <- info:
+				sent++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+
+	return out, nil
+}
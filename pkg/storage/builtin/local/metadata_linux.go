@@ -0,0 +1,154 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"golang.org/x/sys/unix"
+)
+
+// we store metadata as extended attributes in the user namespace, prefixed so we don't touch anything else
+const xattrPrefix = "user.leicht."
+
+// toMetadataError is toStorageError with the errors specific to extended attributes added
+func toMetadataError(err error, fullpath string) error {
+	switch {
+	case errors.Is(err, unix.ENODATA):
+		return fmt.Errorf("%w: metadata of %s", storage.ErrNotExist, fullpath)
+	case errors.Is(err, unix.ENOTSUP):
+		return fmt.Errorf("%w: metadata on this filesystem", storage.ErrNotSupported)
+	case errors.Is(err, unix.E2BIG), errors.Is(err, unix.ERANGE):
+		return fmt.Errorf("%w: metadata of %s is too large", storage.ErrInvalidPath, fullpath)
+	}
+	return toStorageError(err, fullpath)
+}
+
+func getxattr(filename, attr string) ([]byte, error) {
+	for {
+		size, err := unix.Lgetxattr(filename, attr, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		n, err := unix.Lgetxattr(filename, attr, buf)
+		// it grew in between, so we simply try again
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+}
+
+// listxattr returns the names of our extended attributes on filename, including the prefix
+func listxattr(filename string) ([]string, error) {
+	var buf []byte
+	for {
+		size, err := unix.Llistxattr(filename, nil)
+		if err != nil {
+			return nil, err
+		}
+		buf = make([]byte, size)
+		n, err := unix.Llistxattr(filename, buf)
+		if errors.Is(err, unix.ERANGE) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+		break
+	}
+
+	out := []string{}
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if strings.HasPrefix(string(name), xattrPrefix) {
+			out = append(out, string(name))
+		}
+	}
+	return out, nil
+}
+
+// copyMetadata copies our extended attributes from src to dst, it's a no-op if the filesystem doesn't support them
+func copyMetadata(src, dst string) error {
+	names, err := listxattr(src)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		value, err := getxattr(src, name)
+		if errors.Is(err, unix.ENODATA) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = unix.Lsetxattr(dst, name, value, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	filename, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := getxattr(filename, xattrPrefix+key)
+	if err != nil {
+		return "", toMetadataError(err, fullpath)
+	}
+	return string(value), nil
+}
+
+func (s *StorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	filename, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return err
+	}
+	if value != "" {
+		return toMetadataError(unix.Lsetxattr(filename, xattrPrefix+key, []byte(value), 0), fullpath)
+	}
+
+	err = unix.Lremovexattr(filename, xattrPrefix+key)
+	if errors.Is(err, unix.ENODATA) {
+		return nil
+	}
+	return toMetadataError(err, fullpath)
+}
+
+func (s *StorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	filename, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return nil, err
+	}
+	names, err := listxattr(filename)
+	if err != nil {
+		return nil, toMetadataError(err, fullpath)
+	}
+
+	out := make(map[string]string, len(names))
+	for _, name := range names {
+		value, err := getxattr(filename, name)
+		if errors.Is(err, unix.ENODATA) {
+			// removed in the meantime
+			continue
+		} else if err != nil {
+			return nil, toMetadataError(err, fullpath)
+		}
+		out[strings.TrimPrefix(name, xattrPrefix)] = string(value)
+	}
+	return out, nil
+}
@@ -0,0 +1,27 @@
+//go:build !linux
+
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+func copyMetadata(src, dst string) error {
+	return nil
+}
+
+func (s *StorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	return "", fmt.Errorf("%w: metadata on this platform", storage.ErrNotSupported)
+}
+
+func (s *StorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	return fmt.Errorf("%w: metadata on this platform", storage.ErrNotSupported)
+}
+
+func (s *StorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	return nil, fmt.Errorf("%w: metadata on this platform", storage.ErrNotSupported)
+}
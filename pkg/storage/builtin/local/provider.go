@@ -2,6 +2,7 @@ package local
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -21,58 +22,162 @@ func NewStorageProvider(dir string) *StorageProvider {
 	}
 }
 
-func (s *StorageProvider) joinPath(user *models.User, dir string) string {
-	return path.Join(s.RootPath, fmt.Sprintf("%d", user.ID), dir)
+// userRoot is the directory everything of user is stored in, nothing may lead outside of it
+func (s *StorageProvider) userRoot(user *models.User) string {
+	return path.Join(s.RootPath, fmt.Sprintf("%d", user.ID))
+}
+
+// joinPath returns where fullpath is stored on disk, ".." can't take it outside of the root of user.
+// This doesn't look at symlinks though, use resolve or openBeneath for that
+func (s *StorageProvider) joinPath(user *models.User, fullpath string) string {
+	return path.Join(s.userRoot(user), path.Clean("/"+fullpath))
+}
+
+// resolve is joinPath making sure none of the directories leading up to fullpath is a symlink pointing
+// outside of the root of user. With follow set fullpath itself is checked as well, leave that to the
+// operations that don't follow the last symlink anyway, like renaming or removing it.
+func (s *StorageProvider) resolve(user *models.User, fullpath string, follow bool) (string, error) {
+	rel := path.Clean("/" + fullpath)
+	check := rel
+	if !follow {
+		check = path.Dir(rel)
+	}
+
+	_, err := resolveBeneath(s.userRoot(user), check)
+	if err != nil {
+		return "", toStorageError(err, fullpath)
+	}
+	return path.Join(s.userRoot(user), rel), nil
 }
 
 func (s *StorageProvider) InitUser(ctx context.Context, user *models.User) error {
-	return os.MkdirAll(path.Join(s.RootPath, fmt.Sprintf("%d", user.ID)), 0700)
+	return os.MkdirAll(s.userRoot(user), 0700)
 }
 
 func (s *StorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
-	return os.MkdirAll(s.joinPath(user, dir), 0700)
+	dirPath, err := s.resolve(user, dir, true)
+	if err != nil {
+		return err
+	}
+	return toStorageError(os.MkdirAll(dirPath, 0700), dir)
 }
 
 func (s *StorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
-	return os.Rename(s.joinPath(user, src), s.joinPath(user, dst))
+	srcPath, err := s.resolve(user, src, false)
+	if err != nil {
+		return err
+	}
+	dstPath, err := s.resolve(user, dst, false)
+	if err != nil {
+		return err
+	}
+
+	// rename would happily replace an existing file, which isn't what we want
+	_, err = os.Lstat(dstPath)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return toStorageError(err, dst)
+	}
+
+	err = os.Rename(srcPath, dstPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		// either src or the parent of dst is missing
+		if _, statErr := os.Lstat(srcPath); statErr != nil {
+			return toStorageError(statErr, src)
+		}
+		return toStorageError(err, path.Dir(dst))
+	}
+	return toStorageError(err, dst)
 }
 
 func (s *StorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
-	direntires, err := os.ReadDir(s.joinPath(user, dir))
+	root := s.userRoot(user)
+	rel := path.Clean("/" + dir)
+	f, err := openDir(root, rel)
 	if err != nil {
-		return nil, err
+		return nil, toStorageError(err, dir)
 	}
 
 	out := make(chan storage.FileInfo)
 
-	go func(out chan<- storage.FileInfo, direntries []fs.DirEntry) {
-		for _, entry := range direntries {
-			info, err := entry.Info()
-			if err != nil {
-				continue
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+		defer f.Close()
+
+		// we read the directory in batches, so huge directories start streaming right away
+		for {
+			names, err := f.Readdirnames(listBatchSize)
+			for _, name := range names {
+				if isTemp(name) {
+					continue
+				}
+				info, err := lookup(root, path.Join(rel, name), dir, name)
+				if err != nil {
+					continue
+				}
+				select {
+				case out <- info:
+				case <-ctx.Done():
+					return
+				}
 			}
-			out <- storage.FileInfo{
-				Name:      entry.Name(),
-				FullPath:  path.Join(dir, entry.Name()),
-				CreatedAt: info.ModTime(),
-				UpdatedAt: info.ModTime(),
-				Size:      uint64(info.Size()),
-				Directory: entry.IsDir(),
+			if err != nil {
+				return
 			}
 		}
-
-		close(out)
-	}(out, direntires)
+	}(out)
 
 	return out, nil
 }
 
+func (s *StorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	_, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+
+	info, err := lookup(s.userRoot(user), path.Clean("/"+fullpath), path.Dir(fullpath), path.Base(fullpath))
+	if err != nil {
+		return storage.FileInfo{}, toStorageError(err, fullpath)
+	}
+	info.FullPath = fullpath
+	return info, nil
+}
+
 func (s *StorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	return &File{
 		FullPath: s.joinPath(user, fullpath),
+		root:     s.userRoot(user),
+		rel:      path.Clean("/" + fullpath),
+		name:     fullpath,
 	}, nil
 }
 
+// AtomicWrites is always true, as files written from the start only replace the actual file once closed
+func (s *StorageProvider) AtomicWrites() bool {
+	return true
+}
+
 func (s *StorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
-	return os.Remove(s.joinPath(user, fullpath))
+	filename, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return err
+	}
+	return toStorageError(os.Remove(filename), fullpath)
+}
+
+func (s *StorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	filename, err := s.resolve(user, fullpath, false)
+	if err != nil {
+		return err
+	}
+
+	// RemoveAll doesn't report missing files, so we check for that ourselves
+	_, err = os.Lstat(filename)
+	if err != nil {
+		return toStorageError(err, fullpath)
+	}
+
+	return toStorageError(os.RemoveAll(filename), fullpath)
 }
@@ -1,9 +1,16 @@
 package local
 
 import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
 	"testing"
 
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestLocal(t *testing.T) {
@@ -17,3 +24,141 @@ func BenchmarkLocal(b *testing.B) {
 
 	storage.BenchmarkStorageProvider(provider, b)
 }
+
+func TestLocalInfo(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+	provider := NewStorageProvider(t.TempDir())
+	if !assert.NoError(t, provider.InitUser(ctx, user)) || !assert.NoError(t, provider.Mkdir(ctx, user, "dir")) {
+		return
+	}
+
+	file, err := provider.File(ctx, user, "dir/file")
+	if assert.NoError(t, err) {
+		_, err = file.Write([]byte("hello world"))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+	assert.NoError(t, os.Chmod(provider.joinPath(user, "dir/file"), 0640))
+	assert.NoError(t, os.Symlink("file", provider.joinPath(user, "dir/link")))
+	assert.NoError(t, os.Symlink("missing", provider.joinPath(user, "dir/broken")))
+
+	info, err := provider.Stat(ctx, user, "dir/file")
+	if assert.NoError(t, err) {
+		assert.Equal(t, fs.FileMode(0640), info.Mode)
+		assert.NotEmpty(t, info.ETag)
+		assert.False(t, info.Symlink)
+	}
+
+	// symlinks describe what they point to
+	link, err := provider.Stat(ctx, user, "dir/link")
+	if assert.NoError(t, err) {
+		assert.True(t, link.Symlink)
+		assert.Equal(t, info.Size, link.Size)
+		assert.Equal(t, info.ETag, link.ETag)
+	}
+
+	files, err := provider.ListDirectory(ctx, user, "dir")
+	if assert.NoError(t, err) {
+		symlinks := map[string]bool{}
+		for file := range files {
+			symlinks[file.Name] = file.Symlink
+		}
+		assert.Equal(t, map[string]bool{"file": false, "link": true, "broken": true}, symlinks)
+	}
+}
+
+func TestAtomicWrite(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+	provider := NewStorageProvider(t.TempDir())
+	if !assert.NoError(t, provider.InitUser(ctx, user)) || !assert.NoError(t, provider.Mkdir(ctx, user, "dir")) {
+		return
+	}
+
+	write := func(fullpath, contents string) error {
+		file, err := provider.File(ctx, user, fullpath)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write([]byte(contents))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	// the names on disk, rather than what ListDirectory shows
+	onDisk := func() []string {
+		entries, err := os.ReadDir(provider.joinPath(user, "dir"))
+		assert.NoError(t, err)
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	if !assert.NoError(t, write("dir/file", "hello world")) {
+		return
+	}
+	assert.NoError(t, os.Chmod(provider.joinPath(user, "dir/file"), 0640))
+	metadata := true
+	err := storage.SetMetadata(ctx, provider, user, "dir/file", "tag", "kept")
+	if errors.Is(err, storage.ErrNotSupported) {
+		metadata = false
+	} else {
+		assert.NoError(t, err)
+	}
+
+	t.Run("Replace", func(t *testing.T) {
+		assert.NoError(t, write("dir/file", "bye"))
+
+		// the file it replaced had these, so the new one should as well
+		info, err := provider.Stat(ctx, user, "dir/file")
+		if assert.NoError(t, err) {
+			assert.Equal(t, fs.FileMode(0640), info.Mode)
+			assert.Equal(t, uint64(len("bye")), info.Size)
+		}
+		if metadata {
+			value, err := storage.GetMetadata(ctx, provider, user, "dir/file", "tag")
+			assert.NoError(t, err)
+			assert.Equal(t, "kept", value)
+		}
+		assert.Equal(t, []string{"file"}, onDisk())
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		file, err := provider.File(ctx, user, "dir/file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = file.Write([]byte("interrupted"))
+		assert.NoError(t, err)
+		assert.Len(t, onDisk(), 2)
+		assert.NoError(t, storage.Abort(file))
+		assert.Equal(t, []string{"file"}, onDisk())
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		// writing to a symlink writes to what it points at, rather than replacing it
+		assert.NoError(t, os.Symlink("file", provider.joinPath(user, "dir/link")))
+		assert.NoError(t, write("dir/link", "through the link"))
+
+		info, err := os.Lstat(provider.joinPath(user, "dir/link"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, fs.ModeSymlink, info.Mode().Type())
+		}
+		file, err := provider.File(ctx, user, "dir/file")
+		if assert.NoError(t, err) {
+			data, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			assert.Equal(t, "through the link", string(data))
+			assert.NoError(t, file.Close())
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		assert.ErrorIs(t, write("dir", "not a file"), storage.ErrExist)
+	})
+}
@@ -0,0 +1,79 @@
+package local
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+)
+
+// errOutsideRoot is returned for paths that would lead outside of the directory of the user
+var errOutsideRoot = errors.New("Path leads outside of the storage root")
+
+// maxSymlinks is how many symlinks we follow while resolving a single path, the same limit linux has
+const maxSymlinks = 40
+
+// resolveBeneath walks rel inside of root the way the kernel would, making sure neither ".." nor a symlink
+// takes it outside of root. Just like RESOLVE_BENEATH we refuse absolute symlinks altogether, as they have
+// no meaning within root. Once a part of rel doesn't exist we only check the rest of it lexically.
+// It returns the path within root that rel ends up at, with every symlink along the way followed.
+func resolveBeneath(root, rel string) (string, error) {
+	todo := strings.Split(rel, "/")
+	resolved := make([]string, 0, len(todo))
+	links := 0
+
+	for len(todo) > 0 {
+		part := todo[0]
+		todo = todo[1:]
+
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return "", &fs.PathError{Op: "resolve", Path: rel, Err: errOutsideRoot}
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		current := path.Join(root, path.Join(resolved...), part)
+		info, err := os.Lstat(current)
+		if errors.Is(err, fs.ErrNotExist) {
+			resolved = append(resolved, part)
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = append(resolved, part)
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: "resolve", Path: rel, Err: syscall.ELOOP}
+		}
+		target, err := os.Readlink(current)
+		if err != nil {
+			return "", err
+		}
+		if path.IsAbs(target) {
+			return "", &fs.PathError{Op: "resolve", Path: rel, Err: errOutsideRoot}
+		}
+		todo = append(strings.Split(target, "/"), todo...)
+	}
+	return "/" + path.Join(resolved...), nil
+}
+
+// openCompat is the fallback of openBeneath for when the kernel can't do the resolving for us, checking the
+// path before opening it leaves a small window in which a symlink could still get swapped in
+func openCompat(root, rel string, flags int, perm fs.FileMode) (*os.File, error) {
+	_, err := resolveBeneath(root, rel)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path.Join(root, rel), flags, perm)
+}
@@ -0,0 +1,44 @@
+package local
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// openBeneath opens rel inside of root, following symlinks only as long as they stay within root.
+// We let openat2 do the resolving where the kernel supports it (5.6 and up), which can't be raced
+func openBeneath(root, rel string, flags int, perm fs.FileMode) (*os.File, error) {
+	dirfd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: root, Err: err}
+	}
+	defer unix.Close(dirfd)
+
+	name := strings.TrimPrefix(path.Clean("/"+rel), "/")
+	if name == "" {
+		name = "."
+	}
+	how := &unix.OpenHow{
+		Flags:   uint64(flags) | unix.O_CLOEXEC,
+		Resolve: unix.RESOLVE_BENEATH | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	// unlike open, openat2 refuses a mode when it's not creating anything
+	if flags&os.O_CREATE != 0 {
+		how.Mode = uint64(perm.Perm())
+	}
+	fd, err := unix.Openat2(dirfd, name, how)
+	switch {
+	case errors.Is(err, unix.ENOSYS):
+		return openCompat(root, rel, flags, perm)
+	case errors.Is(err, unix.EXDEV):
+		return nil, &fs.PathError{Op: "openat2", Path: rel, Err: errOutsideRoot}
+	case err != nil:
+		return nil, &fs.PathError{Op: "openat2", Path: path.Join(root, rel), Err: err}
+	}
+	return os.NewFile(uintptr(fd), path.Join(root, rel)), nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package local
+
+import (
+	"io/fs"
+	"os"
+)
+
+func openBeneath(root, rel string, flags int, perm fs.FileMode) (*os.File, error) {
+	return openCompat(root, rel, flags, perm)
+}
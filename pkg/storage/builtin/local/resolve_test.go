@@ -0,0 +1,155 @@
+package local
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscape(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	outside := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0600))
+
+	provider := NewStorageProvider(t.TempDir())
+	if !assert.NoError(t, provider.InitUser(ctx, user)) || !assert.NoError(t, provider.Mkdir(ctx, user, "dir")) {
+		return
+	}
+	root := provider.userRoot(user)
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "dir", "file"), []byte("file"), 0600))
+
+	assert.NoError(t, os.Symlink(outside, filepath.Join(root, "outside")))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "secret")))
+	assert.NoError(t, os.Symlink("../../../"+filepath.Base(outside), filepath.Join(root, "dir", "relative")))
+	assert.NoError(t, os.Symlink(filepath.Join(root, "dir"), filepath.Join(root, "absolute")))
+	assert.NoError(t, os.Symlink("dir", filepath.Join(root, "inside")))
+
+	read := func(fullpath string) (string, error) {
+		file, err := provider.File(ctx, user, fullpath)
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		return string(data), err
+	}
+	write := func(fullpath string) error {
+		file, err := provider.File(ctx, user, fullpath)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write([]byte("overwritten"))
+		if err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+
+	t.Run("DotDot", func(t *testing.T) {
+		// it stays inside of the root, rather than ending up in the directory of another user
+		assert.NoError(t, provider.Mkdir(ctx, user, "../2"))
+		assert.NoError(t, write("../2/file"))
+		_, err := os.Stat(filepath.Join(provider.RootPath, "2"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		data, err := read("/2/file")
+		assert.NoError(t, err)
+		assert.Equal(t, "overwritten", data)
+	})
+
+	t.Run("Symlinks", func(t *testing.T) {
+		for _, fullpath := range []string{"outside/secret", "secret", "dir/relative/secret", "absolute/file"} {
+			_, err := read(fullpath)
+			assert.ErrorIs(t, err, storage.ErrInvalidPath, fullpath)
+			assert.ErrorIs(t, write(fullpath), storage.ErrInvalidPath, fullpath)
+
+			_, err = provider.Stat(ctx, user, fullpath+"/child")
+			assert.ErrorIs(t, err, storage.ErrInvalidPath, fullpath)
+		}
+
+		for _, dir := range []string{"outside", "dir/relative"} {
+			_, err := provider.ListDirectory(ctx, user, dir)
+			assert.ErrorIs(t, err, storage.ErrInvalidPath, dir)
+			_, err = provider.List(ctx, user, dir, storage.ListOptions{Sort: storage.SortName})
+			assert.ErrorIs(t, err, storage.ErrInvalidPath, dir)
+			assert.ErrorIs(t, provider.Mkdir(ctx, user, dir+"/new"), storage.ErrInvalidPath, dir)
+			assert.ErrorIs(t, provider.Move(ctx, user, dir+"/secret", "stolen"), storage.ErrInvalidPath, dir)
+			assert.ErrorIs(t, provider.Copy(ctx, user, dir, "stolen"), storage.ErrInvalidPath, dir)
+			assert.ErrorIs(t, provider.DeleteTree(ctx, user, dir+"/secret"), storage.ErrInvalidPath, dir)
+		}
+
+		// the symlink itself is visible, but not where it points to
+		info, err := provider.Stat(ctx, user, "outside")
+		if assert.NoError(t, err) {
+			assert.True(t, info.Symlink)
+			assert.False(t, info.Directory)
+		}
+
+		data, err := os.ReadFile(filepath.Join(outside, "secret"))
+		assert.NoError(t, err)
+		assert.Equal(t, "secret", string(data))
+		_, err = os.Stat(filepath.Join(outside, "new"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("Inside", func(t *testing.T) {
+		data, err := read("inside/file")
+		assert.NoError(t, err)
+		assert.Equal(t, "file", data)
+
+		info, err := provider.Stat(ctx, user, "inside")
+		if assert.NoError(t, err) {
+			assert.True(t, info.Symlink)
+			assert.True(t, info.Directory)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// removing a symlink only removes the link
+		assert.NoError(t, provider.Delete(ctx, user, "secret"))
+		assert.NoError(t, provider.DeleteTree(ctx, user, "outside"))
+		_, err := os.Stat(filepath.Join(outside, "secret"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestResolveBeneath(t *testing.T) {
+	root := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "a", "b"), 0700))
+	assert.NoError(t, os.Symlink("../../..", filepath.Join(root, "a", "b", "up")))
+	assert.NoError(t, os.Symlink("..", filepath.Join(root, "a", "b", "parent")))
+	assert.NoError(t, os.Symlink("loop", filepath.Join(root, "loop")))
+
+	// an empty result means it leads outside of root
+	for rel, resolved := range map[string]string{
+		"/":                        "/",
+		"/a/b":                     "/a/b",
+		"/a/b/parent/b":            "/a/b",
+		"/a/b/missing/../..":       "/a",
+		"/a/b/up":                  "",
+		"/a/b/parent/../..":        "",
+		"/../a":                    "",
+		"/a/b/missing/../../../..": "",
+	} {
+		out, err := resolveBeneath(root, rel)
+		if resolved == "" {
+			assert.ErrorIs(t, err, errOutsideRoot, rel)
+		} else if assert.NoError(t, err, rel) {
+			assert.Equal(t, resolved, out, rel)
+		}
+	}
+	_, err := resolveBeneath(root, "/loop")
+	assert.Error(t, err)
+
+	// the fallback used on older kernels refuses the same
+	_, err = openCompat(root, "/a/b/up/etc", os.O_RDONLY, 0)
+	assert.ErrorIs(t, err, errOutsideRoot)
+}
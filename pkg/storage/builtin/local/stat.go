@@ -0,0 +1,84 @@
+package local
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// fileStat is what we need to know about a file on disk to turn it into a storage.FileInfo
+type fileStat struct {
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+	// birthTime is zero if the platform or filesystem doesn't keep track of it
+	birthTime time.Time
+	// inode is 0 if we don't know it
+	inode uint64
+}
+
+// statCompat is the portable fallback for statFile, which knows nothing about birth times
+func statCompat(filename string, follow bool) (fileStat, error) {
+	var info fs.FileInfo
+	var err error
+	if follow {
+		info, err = os.Stat(filename)
+	} else {
+		info, err = os.Lstat(filename)
+	}
+	if err != nil {
+		return fileStat{}, err
+	}
+	return fileStat{
+		mode:    info.Mode(),
+		size:    info.Size(),
+		modTime: info.ModTime(),
+	}, nil
+}
+
+// etag is derived from the inode, modification time and size, as hashing the contents would be way too slow
+func (st fileStat) etag() string {
+	if st.inode == 0 {
+		return fmt.Sprintf("%x-%x", st.modTime.UnixNano(), st.size)
+	}
+	return fmt.Sprintf("%x-%x-%x", st.inode, st.modTime.UnixNano(), st.size)
+}
+
+// lookup returns the information about rel inside of root, which is dir/name as the user knows it. Symlinks
+// are reported as such, with the rest of the information about what they point to if that exists within root
+func lookup(root, rel, dir, name string) (storage.FileInfo, error) {
+	filename := path.Join(root, rel)
+	st, err := statFile(filename, false)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+
+	symlink := st.mode&fs.ModeSymlink != 0
+	if symlink {
+		if _, err := resolveBeneath(root, rel); err == nil {
+			if target, err := statFile(filename, true); err == nil {
+				st = target
+			}
+		}
+	}
+
+	createdAt := st.birthTime
+	if createdAt.IsZero() {
+		createdAt = st.modTime
+	}
+	return storage.FileInfo{
+		Name:      name,
+		FullPath:  path.Join(dir, name),
+		CreatedAt: createdAt,
+		UpdatedAt: st.modTime,
+		Size:      uint64(st.size),
+		Directory: st.mode.IsDir(),
+		Mode:      st.mode.Perm(),
+		ETag:      st.etag(),
+		Symlink:   symlink,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package local
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// statFile uses statx, which unlike stat also returns the time a file was created on most filesystems
+func statFile(filename string, follow bool) (fileStat, error) {
+	flags := unix.AT_SYMLINK_NOFOLLOW
+	if follow {
+		flags = 0
+	}
+
+	var stx unix.Statx_t
+	err := unix.Statx(unix.AT_FDCWD, filename, flags, unix.STATX_BASIC_STATS|unix.STATX_BTIME, &stx)
+	if errors.Is(err, unix.ENOSYS) {
+		// kernels older than 4.11
+		return statCompat(filename, follow)
+	} else if err != nil {
+		return fileStat{}, &fs.PathError{Op: "statx", Path: filename, Err: err}
+	}
+
+	out := fileStat{
+		mode:    toFileMode(uint32(stx.Mode)),
+		size:    int64(stx.Size),
+		modTime: time.Unix(stx.Mtime.Sec, int64(stx.Mtime.Nsec)),
+		inode:   stx.Ino,
+	}
+	if stx.Mask&unix.STATX_BTIME != 0 {
+		out.birthTime = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	return out, nil
+}
+
+// toFileMode converts the mode as returned by the kernel, just like os.Stat does
+func toFileMode(mode uint32) fs.FileMode {
+	out := fs.FileMode(mode & 0777)
+	switch mode & unix.S_IFMT {
+	case unix.S_IFDIR:
+		out |= fs.ModeDir
+	case unix.S_IFLNK:
+		out |= fs.ModeSymlink
+	case unix.S_IFIFO:
+		out |= fs.ModeNamedPipe
+	case unix.S_IFSOCK:
+		out |= fs.ModeSocket
+	case unix.S_IFBLK:
+		out |= fs.ModeDevice
+	case unix.S_IFCHR:
+		out |= fs.ModeDevice | fs.ModeCharDevice
+	}
+	if mode&unix.S_ISUID != 0 {
+		out |= fs.ModeSetuid
+	}
+	if mode&unix.S_ISGID != 0 {
+		out |= fs.ModeSetgid
+	}
+	if mode&unix.S_ISVTX != 0 {
+		out |= fs.ModeSticky
+	}
+	return out
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package local
+
+func statFile(filename string, follow bool) (fileStat, error) {
+	return statCompat(filename, follow)
+}
@@ -0,0 +1,283 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const watchMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_DELETE | unix.IN_ONLYDIR
+
+// Watch uses inotify to report every change to the files of user, including the ones made by other programs.
+// inotify isn't recursive, so we add a watch for every directory and keep track of new ones as they appear
+func (s *StorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan storage.Event)
+	w := &watcher{
+		fd: fd,
+		// as the fd is non blocking this ends up in the runtime poller, so closing it interrupts a pending read
+		file: os.NewFile(uintptr(fd), "inotify"),
+		root: s.joinPath(user, "/"),
+		dirs: make(map[int]string),
+		ctx:  ctx,
+		out:  out,
+	}
+
+	err = w.addTree("/", nil)
+	if err != nil {
+		w.file.Close()
+		return nil, toStorageError(err, "/")
+	}
+
+	go func() {
+		<-ctx.Done()
+		w.file.Close()
+	}()
+	go w.run()
+
+	return out, nil
+}
+
+type watcher struct {
+	fd   int
+	file *os.File
+	root string
+	// the directory every watch descriptor is for, as the user knows it
+	dirs map[int]string
+
+	ctx context.Context
+	out chan<- storage.Event
+}
+
+// rawEvent is a single event as read from inotify
+type rawEvent struct {
+	wd     int
+	mask   uint32
+	cookie uint32
+	name   string
+}
+
+func parseEvents(buf []byte) []rawEvent {
+	out := make([]rawEvent, 0)
+	for offset := 0; offset+unix.SizeofInotifyEvent <= len(buf); {
+		raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + unix.SizeofInotifyEvent
+		out = append(out, rawEvent{
+			wd:     int(raw.Wd),
+			mask:   raw.Mask,
+			cookie: raw.Cookie,
+			name:   string(bytes.TrimRight(buf[nameStart:nameStart+int(raw.Len)], "\x00")),
+		})
+		offset = nameStart + int(raw.Len)
+	}
+	return out
+}
+
+// addTree adds a watch for dir and every directory within it, found is called for everything in it
+// that already exists when we get to it
+func (w *watcher) addTree(dir string, found func(storage.Event)) error {
+	top := filepath.Join(w.root, dir)
+	return filepath.WalkDir(top, func(fullpath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// it may have been removed again by the time we get to it
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(w.root, fullpath)
+		if err != nil {
+			return err
+		}
+		name := path.Join("/", filepath.ToSlash(rel))
+		if isTemp(entry.Name()) {
+			return nil
+		}
+		if found != nil && fullpath != top {
+			found(storage.Event{Type: storage.EventCreate, Path: name, Directory: entry.IsDir()})
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+
+		wd, err := unix.InotifyAddWatch(w.fd, fullpath, watchMask)
+		if errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ENOTDIR) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		w.dirs[wd] = name
+		return nil
+	})
+}
+
+// moveTree updates the directories we know of after src got moved to dst, the watches themselves stay valid
+func (w *watcher) moveTree(src, dst string) {
+	for wd, dir := range w.dirs {
+		if dir == src || strings.HasPrefix(dir, src+"/") {
+			w.dirs[wd] = dst + strings.TrimPrefix(dir, src)
+		}
+	}
+}
+
+// removeTree stops watching dir and everything in it, used when it got moved somewhere we can't see
+func (w *watcher) removeTree(dir string) {
+	for wd, watched := range w.dirs {
+		if watched == dir || strings.HasPrefix(watched, dir+"/") {
+			_, _ = unix.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.dirs, wd)
+		}
+	}
+}
+
+func (w *watcher) emit(event storage.Event) bool {
+	select {
+	case w.out <- event:
+		return true
+	case <-w.ctx.Done():
+		return false
+	}
+}
+
+func (w *watcher) run() {
+	defer close(w.out)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			if w.ctx.Err() == nil {
+				logrus.Errorf("Failed to read inotify events: %s", err)
+			}
+			return
+		}
+
+		if !w.handle(buf[:n]) {
+			return
+		}
+	}
+}
+
+// handle processes a single read worth of events, returns false once we should stop
+func (w *watcher) handle(buf []byte) bool {
+	events := parseEvents(buf)
+
+	for i := 0; i < len(events); i++ {
+		raw := events[i]
+
+		if raw.mask&unix.IN_Q_OVERFLOW != 0 {
+			logrus.Warnf("Too many changes in %s, some events have been dropped", w.root)
+			continue
+		}
+		if raw.mask&unix.IN_IGNORED != 0 {
+			delete(w.dirs, raw.wd)
+			continue
+		}
+
+		dir, ok := w.dirs[raw.wd]
+		if !ok {
+			continue
+		}
+		if isTemp(raw.name) {
+			// our own temporary files are left out, the file only changes once one gets renamed into place.
+			// Whether that replaced an existing file or not, to anyone watching it got modified
+			if raw.mask&unix.IN_MOVED_FROM != 0 && i+1 < len(events) && events[i+1].mask&unix.IN_MOVED_TO != 0 && events[i+1].cookie == raw.cookie {
+				i++
+				toDir, ok := w.dirs[events[i].wd]
+				if ok && !isTemp(events[i].name) && !w.emit(storage.Event{Type: storage.EventModify, Path: path.Join(toDir, events[i].name)}) {
+					return false
+				}
+			}
+			continue
+		}
+		event := storage.Event{
+			Path:      path.Join(dir, raw.name),
+			Directory: raw.mask&unix.IN_ISDIR != 0,
+		}
+
+		// anything created in a new directory before we got to watch it
+		var found []storage.Event
+
+		switch {
+		case raw.mask&unix.IN_CREATE != 0:
+			if event.Directory {
+				found = w.watchNew(event.Path)
+			}
+			event.Type = storage.EventCreate
+		case raw.mask&unix.IN_CLOSE_WRITE != 0:
+			event.Type = storage.EventModify
+		case raw.mask&unix.IN_DELETE != 0:
+			event.Type = storage.EventDelete
+		case raw.mask&unix.IN_MOVED_FROM != 0:
+			// both halves of a move are queued right after each other, if the second one isn't there
+			// it got moved to somewhere we're not watching, which is as good as deleting it
+			var to string
+			if i+1 < len(events) && events[i+1].mask&unix.IN_MOVED_TO != 0 && events[i+1].cookie == raw.cookie {
+				if toDir, ok := w.dirs[events[i+1].wd]; ok {
+					to = path.Join(toDir, events[i+1].name)
+				}
+				i++
+			}
+
+			if to == "" {
+				if event.Directory {
+					w.removeTree(event.Path)
+				}
+				event.Type = storage.EventDelete
+			} else {
+				if event.Directory {
+					w.moveTree(event.Path, to)
+				}
+				event.Type = storage.EventMove
+				event.OldPath = event.Path
+				event.Path = to
+			}
+		case raw.mask&unix.IN_MOVED_TO != 0:
+			// moved in from somewhere we're not watching, so we don't know about anything in it either
+			if event.Directory {
+				found = w.watchNew(event.Path)
+			}
+			event.Type = storage.EventCreate
+		default:
+			continue
+		}
+
+		if !w.emit(event) {
+			return false
+		}
+		for _, event := range found {
+			if !w.emit(event) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// watchNew starts watching a directory that just appeared, returning the create events for what's already in it
+func (w *watcher) watchNew(dir string) []storage.Event {
+	found := make([]storage.Event, 0)
+	err := w.addTree(dir, func(event storage.Event) {
+		found = append(found, event)
+	})
+	if err != nil {
+		logrus.Errorf("Failed to watch %s: %s", dir, err)
+	}
+	return found
+}
@@ -0,0 +1,63 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	provider := NewStorageProvider(t.TempDir())
+	assert.NoError(t, provider.InitUser(ctx, user))
+	assert.NoError(t, provider.Mkdir(ctx, user, "/existing"))
+	root := provider.joinPath(user, "/")
+
+	events, err := provider.Watch(ctx, user)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// everything is done without going through the provider, as if someone else changed the files
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "existing", "file"), []byte("data"), 0600))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/existing/file"}, storagetest.NextEvent(t, events))
+	assert.Equal(t, storage.Event{Type: storage.EventModify, Path: "/existing/file"}, storagetest.NextEvent(t, events))
+
+	// new directories get watched as well
+	assert.NoError(t, os.Mkdir(filepath.Join(root, "new"), 0700))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/new", Directory: true}, storagetest.NextEvent(t, events))
+	assert.NoError(t, os.Rename(filepath.Join(root, "existing", "file"), filepath.Join(root, "new", "file")))
+	assert.Equal(t, storage.Event{Type: storage.EventMove, Path: "/new/file", OldPath: "/existing/file"}, storagetest.NextEvent(t, events))
+
+	// and keep reporting the right path after being moved
+	assert.NoError(t, os.Rename(filepath.Join(root, "new"), filepath.Join(root, "renamed")))
+	assert.Equal(t, storage.Event{Type: storage.EventMove, Path: "/renamed", OldPath: "/new", Directory: true}, storagetest.NextEvent(t, events))
+	assert.NoError(t, os.Remove(filepath.Join(root, "renamed", "file")))
+	assert.Equal(t, storage.Event{Type: storage.EventDelete, Path: "/renamed/file"}, storagetest.NextEvent(t, events))
+
+	// moving something out of sight is as good as deleting it
+	assert.NoError(t, os.Rename(filepath.Join(root, "renamed"), filepath.Join(provider.RootPath, "elsewhere")))
+	assert.Equal(t, storage.Event{Type: storage.EventDelete, Path: "/renamed", Directory: true}, storagetest.NextEvent(t, events))
+
+	// our own temporary files don't show up, just the file they end up replacing
+	file, err := provider.File(ctx, user, "/written")
+	if assert.NoError(t, err) {
+		_, err = file.Write([]byte("data"))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+		assert.Equal(t, storage.Event{Type: storage.EventModify, Path: "/written"}, storagetest.NextEvent(t, events))
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package local
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+func (s *StorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	return nil, fmt.Errorf("%w: watching for changes on this platform", storage.ErrNotSupported)
+}
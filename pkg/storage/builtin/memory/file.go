@@ -1,9 +1,11 @@
 package memory
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"io"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 )
 
 type File struct {
@@ -11,38 +13,125 @@ type File struct {
 
 	filename string
 	provider *StorageProvider
-	read     *bytes.Reader
-	write    *bytes.Buffer
+	pos      int64
+	reading  bool
+	writing  bool
+	// whether we got positioned explicitly, in which case writing modifies the file in place
+	seeked bool
 }
 
-func (f *File) Read(p []byte) (int, error) {
-	if f.write != nil {
-		return 0, errors.New("File was already opened in write mode")
+func (f *File) openRead() error {
+	if f.writing {
+		return errors.New("File was already opened in write mode")
 	}
-	if f.read == nil {
+	if !f.reading {
 		if _, ok := f.provider.Data[f.filename]; !ok {
-			return 0, fmt.Errorf("File doesn't exist yet, so can't be read: %s", f.filename)
+			return fmt.Errorf("%w: %s", storage.ErrNotExist, f.filename)
 		}
-		f.read = bytes.NewReader(f.Data)
+		f.reading = true
+	}
+	return nil
+}
+
+// openWrite switches to write mode, starting from an empty file if requested
+func (f *File) openWrite(truncate bool) error {
+	if f.reading {
+		return errors.New("File is already opened in read mode")
+	}
+	if !f.writing && truncate {
+		// a new slice rather than reslicing, as the old contents are still shared with the provider
+		f.Data = make([]byte, 0)
+	}
+	f.writing = true
+	return nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
+	if off >= int64(len(f.Data)) {
+		return 0, io.EOF
 	}
 
-	return f.read.Read(p)
+	n := copy(p, f.Data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
 }
 
 func (f *File) Close() error {
-	if f.write != nil {
-		f.provider.Data[f.filename] = f.write.Bytes()
+	if f.writing {
+		f.provider.Data[f.filename] = f.Data
 	}
 	return nil
 }
 
+// Abort leaves the file as it was, unless it was modified in place after a Seek
+func (f *File) Abort() error {
+	f.writing = false
+	return nil
+}
+
+// Write replaces the contents of the file, unless Seek was called first
 func (f *File) Write(p []byte) (int, error) {
-	if f.read != nil {
-		return 0, errors.New("File is already opened in read mode")
+	err := f.openWrite(!f.seeked)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.WriteAt(p, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	err := f.openWrite(false)
+	if err != nil {
+		return 0, err
 	}
-	if f.write == nil {
-		f.write = bytes.NewBuffer(f.Data)
+
+	end := off + int64(len(p))
+	if end > int64(cap(f.Data)) {
+		// grow like append does, so lots of small writes don't end up copying everything every time
+		capacity := 2 * int64(cap(f.Data))
+		if capacity < end {
+			capacity = end
+		}
+		grown := make([]byte, end, capacity)
+		copy(grown, f.Data)
+		f.Data = grown
+	} else if end > int64(len(f.Data)) {
+		f.Data = f.Data[:end]
+	}
+
+	return copy(f.Data[off:], p), nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.seeked = true
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += int64(len(f.Data))
+	default:
+		return 0, errors.New("Invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("Negative position")
 	}
 
-	return f.write.Write(p)
+	f.pos = offset
+	return offset, nil
 }
@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// copyMetadata copies the metadata of src and everything in it over to dst, removing it from src if move is set
+func (s *StorageProvider) copyMetadata(src, dst string, move bool) {
+	prefix := dirPrefix(src)
+	for key, metadata := range s.Metadata {
+		if key != src && !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		copied := make(map[string]string, len(metadata))
+		for k, v := range metadata {
+			copied[k] = v
+		}
+		s.Metadata[dst+strings.TrimPrefix(key, src)] = copied
+		if move {
+			delete(s.Metadata, key)
+		}
+	}
+}
+
+func (s *StorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	name := s.joinPath(user, fullpath)
+	if !s.exists(name) {
+		return "", fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+
+	value, ok := s.Metadata[name][key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no metadata %s", storage.ErrNotExist, fullpath, key)
+	}
+	return value, nil
+}
+
+func (s *StorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	name := s.joinPath(user, fullpath)
+	if !s.exists(name) {
+		return fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+
+	if value == "" {
+		delete(s.Metadata[name], key)
+		return nil
+	}
+	if s.Metadata[name] == nil {
+		s.Metadata[name] = make(map[string]string)
+	}
+	s.Metadata[name][key] = value
+	return nil
+}
+
+func (s *StorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	name := s.joinPath(user, fullpath)
+	if !s.exists(name) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+
+	out := make(map[string]string, len(s.Metadata[name]))
+	for key, value := range s.Metadata[name] {
+		out[key] = value
+	}
+	return out, nil
+}
@@ -3,6 +3,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"path"
 	"strings"
 
@@ -13,11 +14,17 @@ import (
 // This provider should NOT be used in production and also has no concept of users
 type StorageProvider struct {
 	Data map[string][]byte
+	// we keep track of explicitly created directories, any directory that has files in it exists implicitly
+	Dirs map[string]struct{}
+	// Metadata holds the metadata of both files and directories by their path
+	Metadata map[string]map[string]string
 }
 
 func NewStorageProvider() *StorageProvider {
 	return &StorageProvider{
-		Data: make(map[string][]byte),
+		Data:     make(map[string][]byte),
+		Dirs:     make(map[string]struct{}),
+		Metadata: make(map[string]map[string]string),
 	}
 }
 
@@ -25,44 +32,220 @@ func (s *StorageProvider) joinPath(user *models.User, dir string) string {
 	return path.Join("/", dir)
 }
 
+// isDir reports whether name is a directory, either because it was created as one or because something is stored in it
+func (s *StorageProvider) isDir(name string) bool {
+	if name == "/" {
+		return true
+	}
+	if _, ok := s.Dirs[name]; ok {
+		return true
+	}
+	return s.hasChildren(name)
+}
+
+func (s *StorageProvider) hasChildren(name string) bool {
+	prefix := dirPrefix(name)
+	for key := range s.Data {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for key := range s.Dirs {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dirPrefix returns the prefix all keys inside of directory name start with
+func dirPrefix(name string) string {
+	return strings.TrimSuffix(name, "/") + "/"
+}
+
+// etag hashes the contents, which is cheap enough as everything is in memory anyway
+func etag(data []byte) string {
+	hash := fnv.New64a()
+	hash.Write(data)
+	return fmt.Sprintf("%x", hash.Sum64())
+}
+
+func (s *StorageProvider) exists(name string) bool {
+	_, ok := s.Data[name]
+	return ok || s.isDir(name)
+}
+
 func (s *StorageProvider) InitUser(ctx context.Context, user *models.User) error {
 	return nil
 }
 
 func (s *StorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	for name := s.joinPath(user, dir); name != "/"; name = path.Dir(name) {
+		if _, ok := s.Data[name]; ok {
+			return fmt.Errorf("%w: %s", storage.ErrNotDirectory, name)
+		}
+		s.Dirs[name] = struct{}{}
+	}
 	return nil
 }
 
 func (s *StorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
-	srcFile, ok := s.Data[s.joinPath(user, src)]
-	if !ok {
-		return fmt.Errorf("Couldn't find src file with the name: %s", src)
+	srcName := s.joinPath(user, src)
+	dstName := s.joinPath(user, dst)
+
+	err := s.checkTarget(srcName, dstName)
+	if err != nil {
+		return err
+	}
+
+	s.copyMetadata(srcName, dstName, true)
+	if file, ok := s.Data[srcName]; ok {
+		s.Data[dstName] = file
+		delete(s.Data, srcName)
+		return nil
+	}
+
+	prefix := dirPrefix(srcName)
+	for key, file := range s.Data {
+		if strings.HasPrefix(key, prefix) {
+			s.Data[dstName+"/"+strings.TrimPrefix(key, prefix)] = file
+			delete(s.Data, key)
+		}
+	}
+	for key := range s.Dirs {
+		if strings.HasPrefix(key, prefix) {
+			s.Dirs[dstName+"/"+strings.TrimPrefix(key, prefix)] = struct{}{}
+			delete(s.Dirs, key)
+		}
+	}
+	delete(s.Dirs, srcName)
+	s.Dirs[dstName] = struct{}{}
+
+	return nil
+}
+
+func (s *StorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	srcName := s.joinPath(user, src)
+	dstName := s.joinPath(user, dst)
+
+	err := s.checkTarget(srcName, dstName)
+	if err != nil {
+		return err
+	}
+
+	s.copyMetadata(srcName, dstName, false)
+	if file, ok := s.Data[srcName]; ok {
+		s.Data[dstName] = append([]byte{}, file...)
+		return nil
+	}
+
+	prefix := dirPrefix(srcName)
+	for key, file := range s.Data {
+		if strings.HasPrefix(key, prefix) {
+			s.Data[dstName+"/"+strings.TrimPrefix(key, prefix)] = append([]byte{}, file...)
+		}
+	}
+	for key := range s.Dirs {
+		if strings.HasPrefix(key, prefix) {
+			s.Dirs[dstName+"/"+strings.TrimPrefix(key, prefix)] = struct{}{}
+		}
+	}
+	s.Dirs[dstName] = struct{}{}
+
+	return nil
+}
+
+// checkTarget checks whether src can be moved or copied to dst
+func (s *StorageProvider) checkTarget(src, dst string) error {
+	if !s.exists(src) {
+		return fmt.Errorf("%w: %s", storage.ErrNotExist, src)
+	}
+	if s.exists(dst) {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	}
+	if !s.isDir(path.Dir(dst)) {
+		return fmt.Errorf("%w: %s", storage.ErrNotExist, path.Dir(dst))
+	}
+	if strings.HasPrefix(dst, dirPrefix(src)) {
+		return fmt.Errorf("%w: %s is inside of %s", storage.ErrInvalidPath, dst, src)
 	}
-	s.Data[s.joinPath(user, dst)] = srcFile
-	delete(s.Data, s.joinPath(user, src))
 	return nil
 }
 
 func (s *StorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
-	out := make(chan storage.FileInfo)
-
-	go func(out chan<- storage.FileInfo, dir string) {
-		for key, file := range s.Data {
-			if strings.HasPrefix(key, dir) {
-				out <- storage.FileInfo{
-					Name:     strings.TrimPrefix(key, dir),
-					FullPath: key,
-					Size:     uint64(len(file)),
-				}
-			}
+	dirName := s.joinPath(user, dir)
+	if _, ok := s.Data[dirName]; ok {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotDirectory, dir)
+	}
+	if !s.isDir(dirName) {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotExist, dir)
+	}
+
+	prefix := dirPrefix(dirName)
+
+	// we collect everything up front, as directories can show up multiple times
+	entries := make(map[string]storage.FileInfo)
+	addDir := func(key string) {
+		name := strings.SplitN(strings.TrimPrefix(key, prefix), "/", 2)[0]
+		entries[name] = storage.FileInfo{
+			Name:      name,
+			FullPath:  prefix + name,
+			Directory: true,
+		}
+	}
+	for key, file := range s.Data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
 		}
+		name := strings.TrimPrefix(key, prefix)
+		if strings.Contains(name, "/") {
+			addDir(key)
+			continue
+		}
+		entries[name] = storage.FileInfo{
+			Name:     name,
+			FullPath: key,
+			Size:     uint64(len(file)),
+			ETag:     etag(file),
+		}
+	}
+	for key := range s.Dirs {
+		if strings.HasPrefix(key, prefix) {
+			addDir(key)
+		}
+	}
 
-		close(out)
-	}(out, dir)
+	out := make(chan storage.FileInfo, len(entries))
+	for _, entry := range entries {
+		out <- entry
+	}
+	close(out)
 
 	return out, nil
 }
 
+func (s *StorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	filename := s.joinPath(user, fullpath)
+	if file, ok := s.Data[filename]; ok {
+		return storage.FileInfo{
+			Name:     path.Base(filename),
+			FullPath: filename,
+			Size:     uint64(len(file)),
+			ETag:     etag(file),
+		}, nil
+	}
+
+	if s.isDir(filename) {
+		return storage.FileInfo{
+			Name:      path.Base(filename),
+			FullPath:  filename,
+			Directory: true,
+		}, nil
+	}
+
+	return storage.FileInfo{}, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+}
+
 func (s *StorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	srcFile, ok := s.Data[s.joinPath(user, fullpath)]
 	if !ok {
@@ -79,7 +262,54 @@ func (s *StorageProvider) File(ctx context.Context, user *models.User, fullpath
 	}, nil
 }
 
+// AtomicWrites is always true, as files written from the start are only stored once closed
+func (s *StorageProvider) AtomicWrites() bool {
+	return true
+}
+
 func (s *StorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
-	delete(s.Data, s.joinPath(user, fullpath))
+	filename := s.joinPath(user, fullpath)
+	if _, ok := s.Data[filename]; ok {
+		delete(s.Data, filename)
+		delete(s.Metadata, filename)
+		return nil
+	}
+
+	if !s.isDir(filename) {
+		return fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+	if s.hasChildren(filename) {
+		return fmt.Errorf("%w: %s", storage.ErrNotEmpty, fullpath)
+	}
+	delete(s.Dirs, filename)
+	delete(s.Metadata, filename)
+	return nil
+}
+
+func (s *StorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	filename := s.joinPath(user, fullpath)
+	if !s.exists(filename) {
+		return fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+
+	prefix := dirPrefix(filename)
+	for key := range s.Data {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.Data, key)
+		}
+	}
+	for key := range s.Dirs {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.Dirs, key)
+		}
+	}
+	for key := range s.Metadata {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.Metadata, key)
+		}
+	}
+	delete(s.Data, filename)
+	delete(s.Dirs, filename)
+	delete(s.Metadata, filename)
 	return nil
 }
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+)
+
+// Copy copies src to dst using the Copier implementation of provider if there is one,
+// otherwise we fall back to reading src and writing it to dst ourselves
+func Copy(ctx context.Context, provider StorageProvider, user *models.User, src, dst string) error {
+	if copier, ok := provider.(Copier); ok {
+		return copier.Copy(ctx, user, src, dst)
+	}
+	return StreamCopy(ctx, provider, user, src, dst)
+}
+
+// StreamCopy is the fallback used by Copy, providers implementing Copier can use it for the cases
+// they can't handle natively
+func StreamCopy(ctx context.Context, provider StorageProvider, user *models.User, src, dst string) error {
+	if isWithin(dst, src) {
+		return fmt.Errorf("%w: can't copy %s into itself", ErrInvalidPath, src)
+	}
+
+	info, err := provider.Stat(ctx, user, src)
+	if err != nil {
+		return err
+	}
+
+	_, err = provider.Stat(ctx, user, dst)
+	if err == nil {
+		return fmt.Errorf("%w: %s", ErrExist, dst)
+	} else if !errors.Is(err, ErrNotExist) {
+		return err
+	}
+
+	parent, err := provider.Stat(ctx, user, path.Dir(dst))
+	if err != nil {
+		return err
+	} else if !parent.Directory {
+		return fmt.Errorf("%w: %s", ErrNotDirectory, path.Dir(dst))
+	}
+
+	return streamCopy(ctx, provider, user, info, src, dst)
+}
+
+func streamCopy(ctx context.Context, provider StorageProvider, user *models.User, info FileInfo, src, dst string) error {
+	if !info.Directory {
+		err := copyFile(ctx, provider, user, src, dst)
+		if err != nil {
+			return err
+		}
+		return copyMetadata(ctx, provider, user, src, dst)
+	}
+
+	err := provider.Mkdir(ctx, user, dst)
+	if err != nil {
+		return err
+	}
+	err = copyMetadata(ctx, provider, user, src, dst)
+	if err != nil {
+		return err
+	}
+
+	files, err := provider.ListDirectory(ctx, user, src)
+	if err != nil {
+		return err
+	}
+
+	// we drain the whole listing first, as some providers don't like being written to while listing
+	entries := make([]FileInfo, 0)
+	for file := range files {
+		entries = append(entries, file)
+	}
+
+	for _, entry := range entries {
+		err = streamCopy(ctx, provider, user, entry, path.Join(src, entry.Name), path.Join(dst, entry.Name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(ctx context.Context, provider StorageProvider, user *models.User, src, dst string) error {
+	in, err := provider.File(ctx, user, src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := provider.File(ctx, user, dst)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		Abort(out)
+		return err
+	}
+	return out.Close()
+}
+
+// isWithin reports whether name is dir itself or somewhere inside of it
+func isWithin(name, dir string) bool {
+	name = path.Clean("/" + name)
+	dir = path.Clean("/" + dir)
+	return name == dir || dir == "/" || strings.HasPrefix(name, dir+"/")
+}
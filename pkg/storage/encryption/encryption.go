@@ -0,0 +1,338 @@
+package encryption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidKey = fmt.Errorf("The encryption key has to be %d bytes", KeySize)
+
+// EncryptedStorageProvider encrypts the contents of all files, and optionally their names, before
+// passing them on to the underlying provider. Every user gets their own keys derived from the master key.
+type EncryptedStorageProvider struct {
+	proxy        storage.StorageProvider
+	key          []byte
+	encryptNames bool
+}
+
+// Encrypt wraps around provider, encrypting everything with keys derived from key. Keep in mind that
+// encrypted names are longer than the original, so the underlying provider may refuse long names.
+func Encrypt(provider storage.StorageProvider, key []byte, encryptNames bool) (*EncryptedStorageProvider, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return &EncryptedStorageProvider{
+		proxy:        provider,
+		key:          key,
+		encryptNames: encryptNames,
+	}, nil
+}
+
+// names returns the cipher for the names of user, this is nil in case we're not encrypting names
+func (e *EncryptedStorageProvider) names(user *models.User) (*nameCipher, error) {
+	if !e.encryptNames {
+		return nil, nil
+	}
+
+	key, err := e.userKey(user, "names")
+	if err != nil {
+		return nil, err
+	}
+	ivKey, err := e.userKey(user, "names-iv")
+	if err != nil {
+		return nil, err
+	}
+	return newNameCipher(key, ivKey)
+}
+
+// toProxyPath returns the path as the underlying provider knows it
+func (e *EncryptedStorageProvider) toProxyPath(user *models.User, fullpath string) (string, error) {
+	names, err := e.names(user)
+	if err != nil || names == nil {
+		return fullpath, err
+	}
+	return names.encryptPath(fullpath), nil
+}
+
+// these are the errors we rewrite, so their message contains the path the user knows instead of the encrypted one
+var storageErrors = []error{
+	storage.ErrNotExist,
+	storage.ErrExist,
+	storage.ErrNotDirectory,
+	storage.ErrNotEmpty,
+	storage.ErrReadOnly,
+	storage.ErrQuotaExceeded,
+	storage.ErrInvalidPath,
+	storage.ErrNotSupported,
+}
+
+func (e *EncryptedStorageProvider) toPlainError(err error, fullpath string) error {
+	if err == nil || !e.encryptNames {
+		return err
+	}
+	for _, target := range storageErrors {
+		if errors.Is(err, target) {
+			return fmt.Errorf("%w: %s", target, fullpath)
+		}
+	}
+	return err
+}
+
+func (e *EncryptedStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return e.proxy.InitUser(ctx, user)
+}
+
+func (e *EncryptedStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	proxyPath, err := e.toProxyPath(user, dir)
+	if err != nil {
+		return err
+	}
+	return e.toPlainError(e.proxy.Mkdir(ctx, user, proxyPath), dir)
+}
+
+func (e *EncryptedStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	proxySrc, err := e.toProxyPath(user, src)
+	if err != nil {
+		return err
+	}
+	proxyDst, err := e.toProxyPath(user, dst)
+	if err != nil {
+		return err
+	}
+	return e.toPlainError(e.proxy.Move(ctx, user, proxySrc, proxyDst), src)
+}
+
+// Copy can just copy the encrypted data, as every file carries the salt its key is derived from
+func (e *EncryptedStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	proxySrc, err := e.toProxyPath(user, src)
+	if err != nil {
+		return err
+	}
+	proxyDst, err := e.toProxyPath(user, dst)
+	if err != nil {
+		return err
+	}
+	return e.toPlainError(storage.Copy(ctx, e.proxy, user, proxySrc, proxyDst), src)
+}
+
+func toPlainInfo(info storage.FileInfo, fullpath string) storage.FileInfo {
+	info.Name = path.Base(fullpath)
+	info.FullPath = fullpath
+	if !info.Directory {
+		info.Size = plainSize(info.Size)
+	}
+	return info
+}
+
+func (e *EncryptedStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	return e.list(ctx, user, dir, storage.ListOptions{})
+}
+
+// List can only leave the options to the underlying provider if we don't change what they depend on, which is
+// the case for the names unless we encrypt those, but never for the size of files
+func (e *EncryptedStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	if e.encryptNames || opts.Sort == storage.SortSize {
+		return storage.StreamList(ctx, e, user, dir, opts)
+	}
+	return e.list(ctx, user, dir, opts)
+}
+
+func (e *EncryptedStorageProvider) list(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	names, err := e.names(user)
+	if err != nil {
+		return nil, err
+	}
+	proxyPath, err := e.toProxyPath(user, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := storage.List(ctx, e.proxy, user, proxyPath, opts)
+	if err != nil {
+		return nil, e.toPlainError(err, dir)
+	}
+
+	out := make(chan storage.FileInfo)
+
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+		for file := range files {
+			name := file.Name
+			if names != nil {
+				var err error
+				name, err = names.decryptName(file.Name)
+				if err != nil {
+					// most likely put there without going through us, there's no sensible name to show
+					logrus.Warnf("Skipping %s in %s: %s", file.Name, dir, err)
+					continue
+				}
+			}
+
+			select {
+			case out <- toPlainInfo(file, path.Join(dir, name)):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+
+	return out, nil
+}
+
+func (e *EncryptedStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+
+	info, err := e.proxy.Stat(ctx, user, proxyPath)
+	if err != nil {
+		return storage.FileInfo{}, e.toPlainError(err, fullpath)
+	}
+	return toPlainInfo(info, fullpath), nil
+}
+
+// File never returns a SeekableFile, as rewriting part of a chunk would mean reusing its nonce
+func (e *EncryptedStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.userKey(user, "contents")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := e.proxy.File(ctx, user, proxyPath)
+	if err != nil {
+		return nil, e.toPlainError(err, fullpath)
+	}
+
+	return &encryptedFile{
+		proxy:    file,
+		provider: e,
+		name:     fullpath,
+		key:      key,
+	}, nil
+}
+
+func (e *EncryptedStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return err
+	}
+	return e.toPlainError(e.proxy.Delete(ctx, user, proxyPath), fullpath)
+}
+
+func (e *EncryptedStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return err
+	}
+	return e.toPlainError(e.proxy.DeleteTree(ctx, user, proxyPath), fullpath)
+}
+
+// Watch passes on the changes of the underlying provider, with their names decrypted
+func (e *EncryptedStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	names, err := e.names(user)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := storage.Watch(ctx, e.proxy, user)
+	if err != nil || names == nil {
+		return events, err
+	}
+
+	return storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+		plain, err := names.decryptPath(fullpath)
+		if err != nil {
+			// most likely put there without going through us, like in ListDirectory we just skip these
+			return fullpath, false
+		}
+		return plain, true
+	}), nil
+}
+
+func (e *EncryptedStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(e.proxy)
+}
+
+// metadata returns the cipher for the metadata values of user
+func (e *EncryptedStorageProvider) metadata(user *models.User) (*metadataCipher, error) {
+	key, err := e.userKey(user, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	return newMetadataCipher(key)
+}
+
+// GetMetadata and the other metadata calls encrypt the values, the keys are only encrypted as part of the path
+func (e *EncryptedStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return "", err
+	}
+	values, err := e.metadata(user)
+	if err != nil {
+		return "", err
+	}
+	value, err := storage.GetMetadata(ctx, e.proxy, user, proxyPath, key)
+	if err != nil {
+		return "", e.toPlainError(err, fullpath)
+	}
+	return values.decrypt(key, value)
+}
+
+func (e *EncryptedStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return err
+	}
+	// an empty value removes the key, so there's nothing to encrypt
+	if value != "" {
+		values, err := e.metadata(user)
+		if err != nil {
+			return err
+		}
+		value, err = values.encrypt(key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return e.toPlainError(storage.SetMetadata(ctx, e.proxy, user, proxyPath, key, value), fullpath)
+}
+
+func (e *EncryptedStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	proxyPath, err := e.toProxyPath(user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+	values, err := e.metadata(user)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := storage.ListMetadata(ctx, e.proxy, user, proxyPath)
+	if err != nil {
+		return nil, e.toPlainError(err, fullpath)
+	}
+
+	out := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		plain, err := values.decrypt(key, value)
+		if err != nil {
+			// most likely put there without going through us, like in ListDirectory we just skip these
+			logrus.Debug(err)
+			continue
+		}
+		out[key] = plain
+	}
+	return out, nil
+}
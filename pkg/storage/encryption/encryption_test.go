@@ -0,0 +1,226 @@
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = bytes.Repeat([]byte{0x42}, KeySize)
+
+func TestEncryption(t *testing.T) {
+	for _, encryptNames := range []bool{false, true} {
+		name := "Contents"
+		if encryptNames {
+			name = "ContentsAndNames"
+		}
+
+		t.Run(name, func(t *testing.T) {
+			t.Run("Local", func(t *testing.T) {
+				provider, err := Encrypt(local.NewStorageProvider(t.TempDir()), testKey, encryptNames)
+				if assert.NoError(t, err) {
+					storage.TestStorageProvider(provider, t)
+				}
+			})
+			t.Run("Memory", func(t *testing.T) {
+				provider, err := Encrypt(memory.NewStorageProvider(), testKey, encryptNames)
+				if assert.NoError(t, err) {
+					storage.TestStorageProvider(provider, t)
+				}
+			})
+		})
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	_, err := Encrypt(memory.NewStorageProvider(), []byte("too short"), false)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func readAll(t *testing.T, provider storage.StorageProvider, user *models.User, filename string) ([]byte, error) {
+	file, err := provider.File(context.Background(), user, filename)
+	if !assert.NoError(t, err) {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+func TestEncryptedAtRest(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+	other := &models.User{ID: 2}
+	contents := []byte("this is a secret")
+
+	underlying := memory.NewStorageProvider()
+	provider, err := Encrypt(underlying, testKey, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// we want to cover multiple chunks, with the last chunk being exactly full
+	large := bytes.Repeat([]byte("x"), chunkSize*3)
+
+	for _, u := range []*models.User{user, other} {
+		assert.NoError(t, provider.Mkdir(ctx, u, "dir"))
+		file, err := provider.File(ctx, u, "dir/secret.txt")
+		if assert.NoError(t, err) {
+			_, err = file.Write(contents)
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+		}
+	}
+
+	file, err := provider.File(ctx, user, "dir/large")
+	if assert.NoError(t, err) {
+		_, err = file.Write(large)
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+	}
+
+	info, err := provider.Stat(ctx, user, "dir/large")
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(len(large)), info.Size)
+	}
+	data, err := readAll(t, provider, user, "dir/large")
+	assert.NoError(t, err)
+	assert.Equal(t, large, data)
+
+	names, err := provider.names(user)
+	if !assert.NoError(t, err) {
+		return
+	}
+	encrypted := names.encryptPath("dir/secret.txt")
+	assert.NotContains(t, encrypted, "secret")
+
+	t.Run("ContentsEncrypted", func(t *testing.T) {
+		raw, err := readAll(t, underlying, user, encrypted)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(raw), string(contents))
+
+		// the same contents should never end up as the same ciphertext
+		otherNames, err := provider.names(other)
+		if assert.NoError(t, err) {
+			otherRaw, err := readAll(t, underlying, other, otherNames.encryptPath("dir/secret.txt"))
+			assert.NoError(t, err)
+			assert.NotEqual(t, raw, otherRaw)
+		}
+	})
+
+	t.Run("NamesEncrypted", func(t *testing.T) {
+		dir, err := underlying.ListDirectory(ctx, user, "/")
+		if assert.NoError(t, err) {
+			for file := range dir {
+				assert.NotEqual(t, "dir", file.Name)
+			}
+		}
+
+		_, err = provider.Stat(ctx, user, "dir/missing")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Equal(t, storage.ErrNotExist.Error()+": dir/missing", err.Error())
+	})
+
+	t.Run("MetadataEncrypted", func(t *testing.T) {
+		assert.NoError(t, provider.SetMetadata(ctx, user, "dir/secret.txt", "tag", "classified"))
+		assert.NoError(t, provider.SetMetadata(ctx, user, "dir/secret.txt", "other", "public"))
+
+		raw, err := storage.ListMetadata(ctx, underlying, user, encrypted)
+		if assert.NoError(t, err) {
+			assert.NotContains(t, raw["tag"], "classified")
+
+			// a value moved over to another key doesn't decrypt
+			assert.NoError(t, storage.SetMetadata(ctx, underlying, user, encrypted, "other", raw["tag"]))
+		}
+
+		value, err := provider.GetMetadata(ctx, user, "dir/secret.txt", "tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "classified", value)
+		_, err = provider.GetMetadata(ctx, user, "dir/secret.txt", "other")
+		assert.ErrorIs(t, err, ErrCorrupted)
+
+		metadata, err := provider.ListMetadata(ctx, user, "dir/secret.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"tag": "classified"}, metadata)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		file, err := underlying.File(ctx, user, encrypted)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = file.(storage.SeekableFile).WriteAt([]byte{0xff}, int64(headerSize+1))
+		assert.NoError(t, err)
+		assert.NoError(t, file.Close())
+
+		_, err = readAll(t, provider, user, "dir/secret.txt")
+		assert.ErrorIs(t, err, ErrCorrupted)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		raw, err := readAll(t, underlying, user, names.encryptPath("dir/large"))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// dropping the final chunk leaves a file ending in a full chunk that isn't marked as final
+		assert.NoError(t, underlying.Delete(ctx, user, names.encryptPath("dir/large")))
+		file, err := underlying.File(ctx, user, names.encryptPath("dir/large"))
+		if assert.NoError(t, err) {
+			_, err = file.Write(raw[:headerSize+2*(chunkSize+overhead)])
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+		}
+
+		_, err = readAll(t, provider, user, "dir/large")
+		assert.ErrorIs(t, err, ErrCorrupted)
+	})
+}
+
+func TestPlainSize(t *testing.T) {
+	for _, size := range []uint64{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, chunkSize * 5} {
+		chunks := size / chunkSize
+		if size%chunkSize != 0 || size == 0 {
+			chunks++
+		}
+		encrypted := uint64(headerSize) + size + chunks*overhead
+		assert.Equal(t, size, plainSize(encrypted), size)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	local := local.NewStorageProvider(t.TempDir())
+	assert.NoError(t, local.InitUser(ctx, user))
+	provider, err := Encrypt(local, testKey, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	events, err := storage.Watch(ctx, provider, user)
+	if errors.Is(err, storage.ErrNotSupported) {
+		t.Skip("The local provider can't watch for changes on this platform")
+	} else if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/secret/folder"))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/secret", Directory: true}, storagetest.NextEvent(t, events))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/secret/folder", Directory: true}, storagetest.NextEvent(t, events))
+
+	// not something we're able to decrypt, so this shouldn't show up
+	assert.NoError(t, local.Mkdir(ctx, user, "/plain"))
+	assert.NoError(t, provider.Move(ctx, user, "/secret/folder", "/secret/renamed"))
+	assert.Equal(t, storage.Event{Type: storage.EventMove, Path: "/secret/renamed", OldPath: "/secret/folder", Directory: true}, storagetest.NextEvent(t, events))
+}
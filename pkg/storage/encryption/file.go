@@ -0,0 +1,246 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// Every file starts with a header consisting of a magic value and a random salt, the salt is used
+// to derive a key for just this file. After this the contents follow in chunks of chunkSize, each
+// sealed on its own so we can decrypt them while streaming. The nonce of every chunk is its index,
+// with the last byte marking the final chunk so a truncated file fails to decrypt instead of
+// silently returning less data.
+const (
+	chunkSize  = 64 * 1024
+	magic      = "LCE\x01"
+	saltSize   = 32
+	headerSize = len(magic) + saltSize
+	overhead   = 16
+)
+
+var ErrCorrupted = errors.New("Encrypted file is corrupted or was tampered with")
+
+// plainSize calculates the size of the plaintext from the size of the encrypted file
+func plainSize(size uint64) uint64 {
+	if size <= uint64(headerSize) {
+		return 0
+	}
+	size -= uint64(headerSize)
+	chunks := (size + chunkSize + overhead - 1) / (chunkSize + overhead)
+	if chunks*overhead > size {
+		return 0
+	}
+	return size - chunks*overhead
+}
+
+func chunkNonce(aead cipher.AEAD, index uint64, final bool) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce, index)
+	if final {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+type encryptedFile struct {
+	proxy    storage.File
+	provider *EncryptedStorageProvider
+	// the path as the user sees it, used in errors
+	name string
+	// the key of the user, from which we derive the key of this specific file
+	key []byte
+
+	aead  cipher.AEAD
+	index uint64
+	buf   []byte
+
+	reader  *bufio.Reader
+	reading bool
+	writing bool
+	eof     bool
+	// plaintext of the current chunk that hasn't been read yet
+	plain []byte
+}
+
+func (f *encryptedFile) fileCipher(salt []byte) error {
+	key, err := deriveKey(f.key, salt, "file")
+	if err != nil {
+		return err
+	}
+	f.aead, err = newGCM(key)
+	return err
+}
+
+func (f *encryptedFile) openWrite() error {
+	if f.reading {
+		return errors.New("File is already opened in read mode")
+	}
+	if f.writing {
+		return nil
+	}
+
+	header := make([]byte, headerSize)
+	copy(header, magic)
+	_, err := io.ReadFull(rand.Reader, header[len(magic):])
+	if err != nil {
+		return err
+	}
+	err = f.fileCipher(header[len(magic):])
+	if err != nil {
+		return err
+	}
+
+	_, err = f.proxy.Write(header)
+	if err != nil {
+		return f.provider.toPlainError(err, f.name)
+	}
+	f.buf = make([]byte, 0, chunkSize)
+	f.writing = true
+	return nil
+}
+
+func (f *encryptedFile) Write(p []byte) (int, error) {
+	err := f.openWrite()
+	if err != nil {
+		return 0, err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// we only flush a full chunk once we know more data follows, as the last chunk has to be marked as such
+		if len(f.buf) == chunkSize {
+			err = f.flush(false)
+			if err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(f.buf[len(f.buf):chunkSize], p)
+		f.buf = f.buf[:len(f.buf)+n]
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (f *encryptedFile) flush(final bool) error {
+	sealed := f.aead.Seal(nil, chunkNonce(f.aead, f.index, final), f.buf, nil)
+	_, err := f.proxy.Write(sealed)
+	if err != nil {
+		return f.provider.toPlainError(err, f.name)
+	}
+	f.index++
+	f.buf = f.buf[:0]
+	return nil
+}
+
+func (f *encryptedFile) openRead() error {
+	if f.writing {
+		return errors.New("File is already opened in write mode")
+	}
+	if f.reading {
+		return nil
+	}
+
+	header := make([]byte, headerSize)
+	n, err := io.ReadFull(f.proxy, header)
+	if n == 0 && err == io.EOF {
+		// an empty file was never written by us, but there's nothing to decrypt either
+		f.reading = true
+		f.eof = true
+		return nil
+	} else if err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrCorrupted
+		}
+		return f.provider.toPlainError(err, f.name)
+	}
+	if string(header[:len(magic)]) != magic {
+		return ErrCorrupted
+	}
+
+	err = f.fileCipher(header[len(magic):])
+	if err != nil {
+		return err
+	}
+
+	f.reader = bufio.NewReaderSize(f.proxy, chunkSize+overhead)
+	f.reading = true
+	return nil
+}
+
+// nextChunk reads and decrypts the next chunk into f.plain
+func (f *encryptedFile) nextChunk() error {
+	sealed := make([]byte, chunkSize+overhead)
+	n, err := io.ReadFull(f.reader, sealed)
+	final := false
+	switch {
+	case err == io.EOF:
+		// we reached the end without ever seeing a final chunk
+		return ErrCorrupted
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		final = true
+	case err != nil:
+		return err
+	default:
+		// a full chunk is only the last one if nothing follows it
+		_, err = f.reader.Peek(1)
+		if err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+
+	plain, err := f.aead.Open(sealed[:0], chunkNonce(f.aead, f.index, final), sealed[:n], nil)
+	if err != nil {
+		return ErrCorrupted
+	}
+	f.index++
+	f.plain = plain
+	f.eof = final
+	return nil
+}
+
+func (f *encryptedFile) Read(p []byte) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
+
+	for len(f.plain) == 0 {
+		if f.eof {
+			return 0, io.EOF
+		}
+		err = f.nextChunk()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, f.plain)
+	f.plain = f.plain[n:]
+	return n, nil
+}
+
+func (f *encryptedFile) Close() error {
+	if f.writing {
+		err := f.flush(true)
+		if err != nil {
+			f.proxy.Close()
+			return err
+		}
+	}
+	return f.provider.toPlainError(f.proxy.Close(), f.name)
+}
+
+// Abort skips sealing the final chunk, a provider without atomic writes is left with a file that fails to decrypt
+func (f *encryptedFile) Abort() error {
+	return f.provider.toPlainError(storage.Abort(f.proxy), f.name)
+}
@@ -0,0 +1,38 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeySize is the size the master key is required to be, the derived keys are of the same size
+const KeySize = 32
+
+// deriveKey derives a key from secret for a single purpose, using salt to make it unique
+func deriveKey(secret, salt []byte, purpose string) ([]byte, error) {
+	key := make([]byte, KeySize)
+	_, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(purpose)), key)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// userKey derives the key of a single user, so users never share the key their files are encrypted with
+func (e *EncryptedStorageProvider) userKey(user *models.User, purpose string) ([]byte, error) {
+	return deriveKey(e.key, []byte(fmt.Sprintf("%d", user.ID)), purpose)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,46 @@
+package encryption
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// metadataCipher encrypts the values of the metadata of a user, unlike names these get a random nonce as
+// they're never looked up by their value. The key is used as additional data, so a value can't be moved
+// over to another key unnoticed. The keys themselves are stored as is, as they're what we look values up by
+type metadataCipher struct {
+	aead cipher.AEAD
+}
+
+func newMetadataCipher(key []byte) (*metadataCipher, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &metadataCipher{aead: aead}, nil
+}
+
+func (m *metadataCipher) encrypt(key, value string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(m.aead.Seal(nonce, nonce, []byte(value), []byte(key))), nil
+}
+
+func (m *metadataCipher) decrypt(key, value string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) < m.aead.NonceSize() {
+		return "", fmt.Errorf("%w: metadata %s", ErrCorrupted, key)
+	}
+
+	nonce := data[:m.aead.NonceSize()]
+	plain, err := m.aead.Open(nil, nonce, data[m.aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("%w: metadata %s", ErrCorrupted, key)
+	}
+	return string(plain), nil
+}
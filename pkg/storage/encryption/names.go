@@ -0,0 +1,81 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"path"
+	"strings"
+)
+
+var ErrInvalidName = errors.New("Filename isn't encrypted with this key")
+
+// nameCipher encrypts filenames deterministically, as we need the same name to always map to
+// the same encrypted name in order to look files up. The nonce is derived from the name itself
+// (a synthetic iv), so the only thing this leaks is whether two names within the same user are equal.
+type nameCipher struct {
+	aead  cipher.AEAD
+	ivKey []byte
+}
+
+func newNameCipher(key, ivKey []byte) (*nameCipher, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &nameCipher{aead: aead, ivKey: ivKey}, nil
+}
+
+func (n *nameCipher) nonce(name string) []byte {
+	mac := hmac.New(sha256.New, n.ivKey)
+	mac.Write([]byte(name))
+	return mac.Sum(nil)[:n.aead.NonceSize()]
+}
+
+func (n *nameCipher) encryptName(name string) string {
+	nonce := n.nonce(name)
+	return base64.RawURLEncoding.EncodeToString(n.aead.Seal(nonce, nonce, []byte(name), nil))
+}
+
+func (n *nameCipher) decryptName(name string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(name)
+	if err != nil || len(data) < n.aead.NonceSize() {
+		return "", ErrInvalidName
+	}
+
+	nonce := data[:n.aead.NonceSize()]
+	plain, err := n.aead.Open(nil, nonce, data[n.aead.NonceSize():], nil)
+	if err != nil || !bytes.Equal(nonce, n.nonce(string(plain))) {
+		return "", ErrInvalidName
+	}
+	return string(plain), nil
+}
+
+// encryptPath encrypts every element of fullpath on its own, so the directory structure stays intact
+func (n *nameCipher) encryptPath(fullpath string) string {
+	parts := strings.Split(path.Join("/", fullpath), "/")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = n.encryptName(part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// decryptPath is the counterpart of encryptPath, it fails if any of the elements fails to decrypt
+func (n *nameCipher) decryptPath(fullpath string) (string, error) {
+	parts := strings.Split(path.Join("/", fullpath), "/")
+	for i, part := range parts {
+		if part != "" {
+			plain, err := n.decryptName(part)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = plain
+		}
+	}
+	return strings.Join(parts, "/"), nil
+}
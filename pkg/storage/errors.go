@@ -0,0 +1,16 @@
+package storage
+
+import "errors"
+
+// These are the errors a StorageProvider is expected to return (or wrap) in the matching situations,
+// they are carried over the plugin boundary so callers can always rely on errors.Is to check for them
+var (
+	ErrNotExist      = errors.New("File does not exist")
+	ErrExist         = errors.New("File already exists")
+	ErrNotDirectory  = errors.New("Not a directory")
+	ErrNotEmpty      = errors.New("Directory not empty")
+	ErrReadOnly      = errors.New("Readonly storage")
+	ErrQuotaExceeded = errors.New("Quota exceeded")
+	ErrInvalidPath   = errors.New("Invalid path")
+	ErrNotSupported  = errors.New("Not supported by this storage")
+)
@@ -2,15 +2,15 @@ package firewall
 
 import (
 	"context"
-	"errors"
 	"path/filepath"
+	"strings"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage/utils"
 )
 
-var ErrReadOnly = errors.New("Readonly storage")
+var ErrReadOnly = storage.ErrReadOnly
 
 type FirewallStorageProvider struct {
 	proxy     storage.StorageProvider
@@ -47,6 +47,19 @@ func (f *FirewallStorageProvider) Move(ctx context.Context, user *models.User, s
 	return f.proxy.Move(ctx, user, filepath.Join(f.directory, src), filepath.Join(f.directory, dst))
 }
 
+func (f *FirewallStorageProvider) Copy(ctx context.Context, user *models.User, src string, dst string) error {
+	err := utils.ValidatePath(src)
+	if err != nil {
+		return err
+	}
+	err = utils.ValidatePath(dst)
+	if err != nil {
+		return err
+	}
+
+	return storage.Copy(ctx, f.proxy, user, filepath.Join(f.directory, src), filepath.Join(f.directory, dst))
+}
+
 func (f *FirewallStorageProvider) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
 	err := utils.ValidatePath(path)
 	if err != nil {
@@ -56,6 +69,24 @@ func (f *FirewallStorageProvider) ListDirectory(ctx context.Context, user *model
 	return f.proxy.ListDirectory(ctx, user, filepath.Join(f.directory, path))
 }
 
+func (f *FirewallStorageProvider) List(ctx context.Context, user *models.User, path string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	err := utils.ValidatePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.List(ctx, f.proxy, user, filepath.Join(f.directory, path), opts)
+}
+
+func (f *FirewallStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	err := utils.ValidatePath(fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+
+	return f.proxy.Stat(ctx, user, filepath.Join(f.directory, fullpath))
+}
+
 func (f *FirewallStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	err := utils.ValidatePath(fullpath)
 	if err != nil {
@@ -73,3 +104,64 @@ func (f *FirewallStorageProvider) Delete(ctx context.Context, user *models.User,
 
 	return f.proxy.Delete(ctx, user, filepath.Join(f.directory, fullpath))
 }
+
+func (f *FirewallStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := utils.ValidatePath(fullpath)
+	if err != nil {
+		return err
+	}
+
+	return f.proxy.DeleteTree(ctx, user, filepath.Join(f.directory, fullpath))
+}
+
+// Watch passes on the changes within our directory, with the paths relative to it
+func (f *FirewallStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	events, err := storage.Watch(ctx, f.proxy, user)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join("/", f.directory)
+	return storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+		if dir == "/" {
+			return fullpath, true
+		}
+		if fullpath == dir {
+			return "/", true
+		} else if strings.HasPrefix(fullpath, dir+"/") {
+			return strings.TrimPrefix(fullpath, dir), true
+		}
+		return fullpath, false
+	}), nil
+}
+
+func (f *FirewallStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(f.proxy)
+}
+
+func (f *FirewallStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	err := utils.ValidatePath(fullpath)
+	if err != nil {
+		return "", err
+	}
+
+	return storage.GetMetadata(ctx, f.proxy, user, filepath.Join(f.directory, fullpath), key)
+}
+
+func (f *FirewallStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	err := utils.ValidatePath(fullpath)
+	if err != nil {
+		return err
+	}
+
+	return storage.SetMetadata(ctx, f.proxy, user, filepath.Join(f.directory, fullpath), key, value)
+}
+
+func (f *FirewallStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	err := utils.ValidatePath(fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.ListMetadata(ctx, f.proxy, user, filepath.Join(f.directory, fullpath))
+}
@@ -27,6 +27,7 @@ func TestFirewallStorage(t *testing.T) {
 		if assert.Nil(t, err) {
 			_, err = file.Write(contents)
 			assert.Nil(t, err)
+			assert.Nil(t, file.Close())
 		}
 	}) {
 		return
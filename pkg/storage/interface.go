@@ -3,18 +3,29 @@ package storage
 import (
 	"context"
 	"io"
+	"io/fs"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 )
 
 type FileInfo struct {
-	Name      string    `json:"name"`
-	FullPath  string    `json:"full_path"`
+	Name     string `json:"name"`
+	FullPath string `json:"full_path"`
+	// CreatedAt is when the file was created, providers that don't keep track of this use the last modification
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	Size      uint64    `json:"size"`
 	Directory bool      `json:"directory"`
+	// Mode holds the permission bits, it's 0 for providers without a notion of permissions
+	Mode fs.FileMode `json:"mode"`
+	// ETag is an opaque string that changes whenever the contents do, either a hash of the contents or
+	// something derived from the size and modification time. It's empty if the provider can't tell
+	ETag string `json:"etag,omitempty"`
+	// Symlink is set for symbolic links, the other fields describe what it points to as long as that exists
+	Symlink bool `json:"symlink,omitempty"`
 }
 
 type File interface {
@@ -22,14 +33,38 @@ type File interface {
 	io.WriteCloser
 }
 
-// TODO: Add support for https://pkg.go.dev/io#ReaderAt and https://pkg.go.dev/io#WriterAt ?
+// SeekableFile can optionally be implemented by the File returned from your StorageProvider
+// in case you support random access. Callers are expected to check for this using a type assertion
+// and fall back to just streaming the whole file if it isn't implemented.
+type SeekableFile interface {
+	File
+	io.ReaderAt
+	io.WriterAt
+	io.Seeker
+}
+
 type StorageProvider interface {
 	InitUser(ctx context.Context, user *models.User) error
+	// Mkdir creates the directory along with any missing parents, it's not an error if it already exists
 	Mkdir(ctx context.Context, user *models.User, path string) error
+	// Move moves a file or a directory including everything in it, src and dst don't have to be in the same directory.
+	// The parent directory of dst has to exist already and dst itself may not, otherwise ErrExist should be returned
 	Move(ctx context.Context, user *models.User, src, dst string) error
 	ListDirectory(ctx context.Context, user *models.User, path string) (<-chan FileInfo, error)
+	// Stat returns the information of a single file or directory, if it doesn't exist ErrNotExist should be returned
+	Stat(ctx context.Context, user *models.User, fullpath string) (FileInfo, error)
 	File(ctx context.Context, user *models.User, fullpath string) (File, error)
+	// Delete removes a single file or an empty directory, ErrNotEmpty should be returned for a directory that still has contents
 	Delete(ctx context.Context, user *models.User, fullpath string) error
+	// DeleteTree removes a file or a directory along with everything in it
+	DeleteTree(ctx context.Context, user *models.User, fullpath string) error
+}
+
+// Copier can optionally be implemented by your StorageProvider if it's able to copy files (and directories)
+// without every byte going through the caller. The semantics are the same as those of Move, except src is
+// left in place. Callers should use Copy, which falls back to streaming the data if this isn't implemented.
+type Copier interface {
+	Copy(ctx context.Context, user *models.User, src, dst string) error
 }
 
 // Implement this interface if you want to be notified after your config is loaded, in case
@@ -37,3 +72,47 @@ type StorageProvider interface {
 type PostConfigure interface {
 	OnConfigure() error
 }
+
+// Unwrapper is implemented by providers wrapping around another provider, so callers are able to
+// look for a specific layer (the quota for example) without having to know the order they're stacked in
+type Unwrapper interface {
+	Unwrap() StorageProvider
+}
+
+// Unwrap returns the provider wrapped by provider, or nil if it doesn't implement Unwrapper
+func Unwrap(provider StorageProvider) StorageProvider {
+	if unwrapper, ok := provider.(Unwrapper); ok {
+		return unwrapper.Unwrap()
+	}
+	return nil
+}
+
+// Mounter is implemented by providers serving parts of the tree from other providers. Wrappers keeping
+// data of their own next to the files of the user use this to keep it on the same mount as those files
+type Mounter interface {
+	// MountPoints returns the path of every mount, including the root
+	MountPoints() []string
+}
+
+// MountPoints returns the mount points of the first layer of provider implementing Mounter, which is
+// just the root if there isn't any
+func MountPoints(provider StorageProvider) []string {
+	for ; provider != nil; provider = Unwrap(provider) {
+		if mounter, ok := provider.(Mounter); ok {
+			return mounter.MountPoints()
+		}
+	}
+	return []string{"/"}
+}
+
+// MountPoint returns which of mountPoints fullpath is on, that is the longest one containing it
+func MountPoint(mountPoints []string, fullpath string) string {
+	fullpath = path.Clean("/" + fullpath)
+	out := "/"
+	for _, mountPoint := range mountPoints {
+		if len(mountPoint) > len(out) && (fullpath == mountPoint || strings.HasPrefix(fullpath, mountPoint+"/")) {
+			out = mountPoint
+		}
+	}
+	return out
+}
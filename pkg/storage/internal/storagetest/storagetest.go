@@ -0,0 +1,75 @@
+// Package storagetest holds the helpers shared by the tests of the storage providers and wrappers. It's
+// only imported by tests, unlike storage.TestStorageProvider which is there for plugins as well, so it's
+// free to pull in whatever the tests need, like a database.
+package storagetest
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// WriteFile replaces the contents of filename. It returns the error rather than failing the test,
+// so refused writes can be tested as well
+func WriteFile(provider storage.StorageProvider, user *models.User, filename, contents string) error {
+	file, err := provider.File(context.Background(), user, filename)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write([]byte(contents))
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// ReadFile returns the contents of filename, failing the test if it can't be read
+func ReadFile(t *testing.T, provider storage.StorageProvider, user *models.User, filename string) string {
+	file, err := provider.File(context.Background(), user, filename)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	assert.NoError(t, err)
+	return string(data)
+}
+
+// NextEvent waits for the next event, failing the test if it takes too long or events gets closed
+func NextEvent(t *testing.T, events <-chan storage.Event) storage.Event {
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("Events channel got closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return storage.Event{}
+}
+
+// DB returns an empty database with all of the models set up
+func DB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = models.InitModels(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+)
+
+type SortKey string
+
+const (
+	SortNone    SortKey = ""
+	SortName    SortKey = "name"
+	SortSize    SortKey = "size"
+	SortCreated SortKey = "created"
+	SortUpdated SortKey = "updated"
+)
+
+// ListOptions changes what List returns, the zero value returns everything in no particular order just like ListDirectory.
+// Entries with the same value for the sort key are sorted by name, so the order is always the same.
+type ListOptions struct {
+	Sort       SortKey
+	Descending bool
+	// Cursor continues a previous listing right after the entry it was taken from, see CursorOf
+	Cursor string
+	// Limit is the maximum amount of entries to return, 0 means no limit
+	Limit int
+	// Filter only includes the entries with a name matching this pattern, the syntax is that of path.Match
+	Filter string
+}
+
+// Lister can optionally be implemented by your StorageProvider if it's able to apply the ListOptions more
+// efficiently than reading the whole directory. The options are checked by List before this is called, so
+// you can rely on them being valid and on Sort being set whenever Cursor or Limit is. Callers should use List,
+// which falls back to applying the options to the results of ListDirectory if this isn't implemented.
+type Lister interface {
+	List(ctx context.Context, user *models.User, dir string, opts ListOptions) (<-chan FileInfo, error)
+}
+
+// List lists dir according to opts, using the Lister implementation of provider if there is one
+func List(ctx context.Context, provider StorageProvider, user *models.User, dir string, opts ListOptions) (<-chan FileInfo, error) {
+	opts, err := opts.check()
+	if err != nil {
+		return nil, err
+	}
+	if lister, ok := provider.(Lister); ok {
+		return lister.List(ctx, user, dir, opts)
+	}
+	return StreamList(ctx, provider, user, dir, opts)
+}
+
+// StreamList is the fallback used by List, providers implementing Lister can use it for the cases they
+// can't handle natively. Keep in mind this reads the whole directory whenever the results are sorted.
+func StreamList(ctx context.Context, provider StorageProvider, user *models.User, dir string, opts ListOptions) (<-chan FileInfo, error) {
+	files, err := provider.ListDirectory(ctx, user, dir)
+	if err != nil || opts == (ListOptions{}) {
+		return files, err
+	}
+	return ApplyListOptions(ctx, files, opts), nil
+}
+
+// check validates opts, it returns them with Sort filled in if paging without sorting as paging needs a fixed order
+func (o ListOptions) check() (ListOptions, error) {
+	switch o.Sort {
+	case SortNone, SortName, SortSize, SortCreated, SortUpdated:
+	default:
+		return o, fmt.Errorf("%w: unknown sort key %q", ErrInvalidPath, o.Sort)
+	}
+	if o.Sort == SortNone && (o.Cursor != "" || o.Limit > 0) {
+		o.Sort = SortName
+	}
+	if o.Limit < 0 {
+		return o, fmt.Errorf("%w: negative limit", ErrInvalidPath)
+	}
+	if _, err := path.Match(o.Filter, ""); err != nil {
+		return o, fmt.Errorf("%w: invalid filter %q", ErrInvalidPath, o.Filter)
+	}
+	if _, err := ParseCursor(o.Sort, o.Cursor); err != nil {
+		return o, err
+	}
+	return o, nil
+}
+
+// Match returns whether an entry named name passes the filter
+func (o ListOptions) Match(name string) bool {
+	if o.Filter == "" {
+		return true
+	}
+	matched, _ := path.Match(o.Filter, name)
+	return matched
+}
+
+// compare returns whether a comes before (-1) or after (1) b when sorting by key, ignoring the order
+func compare(key SortKey, a, b FileInfo) int {
+	var cmp int
+	switch key {
+	case SortSize:
+		if a.Size < b.Size {
+			cmp = -1
+		} else if a.Size > b.Size {
+			cmp = 1
+		}
+	case SortCreated:
+		cmp = compareTime(a.CreatedAt, b.CreatedAt)
+	case SortUpdated:
+		cmp = compareTime(a.UpdatedAt, b.UpdatedAt)
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.Name, b.Name)
+	}
+	return cmp
+}
+
+func compareTime(a, b time.Time) int {
+	if a.Before(b) {
+		return -1
+	} else if a.After(b) {
+		return 1
+	}
+	return 0
+}
+
+// Less returns whether a should be listed before b
+func (o ListOptions) Less(a, b FileInfo) bool {
+	if o.Descending {
+		return compare(o.Sort, a, b) > 0
+	}
+	return compare(o.Sort, a, b) < 0
+}
+
+// After returns whether info should be listed after the entry the cursor was taken from, this is always
+// true if there is no cursor. Only the fields used for sorting have to be set on info.
+func (o ListOptions) After(info FileInfo) bool {
+	if o.Cursor == "" {
+		return true
+	}
+	// this can't fail, as the options are checked before they're passed to anyone
+	cursor, _ := ParseCursor(o.Sort, o.Cursor)
+	return o.Less(cursor, info)
+}
+
+// CursorOf returns the cursor to continue a listing sorted by key right after info. As names can't
+// contain a slash, we put the value of the sort key in front of the name separated by a slash.
+// Times that aren't set are left empty, as the zero time doesn't fit in nanoseconds.
+func CursorOf(key SortKey, info FileInfo) string {
+	switch key {
+	case SortSize:
+		return fmt.Sprintf("%d/%s", info.Size, info.Name)
+	case SortCreated:
+		return cursorTime(info.CreatedAt) + "/" + info.Name
+	case SortUpdated:
+		return cursorTime(info.UpdatedAt) + "/" + info.Name
+	}
+	return info.Name
+}
+
+func cursorTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// ParseCursor turns a cursor back into a FileInfo containing just the name and the field sorted by
+func ParseCursor(key SortKey, cursor string) (FileInfo, error) {
+	if cursor == "" || key == SortName {
+		return FileInfo{Name: cursor}, nil
+	}
+
+	split := strings.SplitN(cursor, "/", 2)
+	if len(split) != 2 {
+		return FileInfo{}, fmt.Errorf("%w: invalid cursor %q", ErrInvalidPath, cursor)
+	}
+	info := FileInfo{Name: split[1]}
+	// an empty value is a time that isn't set
+	if split[0] == "" && (key == SortCreated || key == SortUpdated) {
+		return info, nil
+	}
+
+	value, err := strconv.ParseInt(split[0], 10, 64)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("%w: invalid cursor %q", ErrInvalidPath, cursor)
+	}
+	switch key {
+	case SortSize:
+		info.Size = uint64(value)
+	case SortCreated:
+		info.CreatedAt = time.Unix(0, value)
+	case SortUpdated:
+		info.UpdatedAt = time.Unix(0, value)
+	}
+	return info, nil
+}
+
+// ApplyListOptions applies opts to the unsorted entries in files, this has to read all of them when sorting.
+// Applying the options to a listing they've already been applied to doesn't change it.
+func ApplyListOptions(ctx context.Context, files <-chan FileInfo, opts ListOptions) <-chan FileInfo {
+	out := make(chan FileInfo)
+
+	go func(out chan<- FileInfo) {
+		defer close(out)
+		// in case we stop early, so whoever is sending these isn't stuck forever
+		defer func() {
+			for range files {
+			}
+		}()
+
+		send := func(file FileInfo) bool {
+			select {
+			case out <- file:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		if opts.Sort == SortNone {
+			for file := range files {
+				if opts.Match(file.Name) && !send(file) {
+					return
+				}
+			}
+			return
+		}
+
+		entries := make([]FileInfo, 0)
+		for file := range files {
+			if opts.Match(file.Name) && opts.After(file) {
+				entries = append(entries, file)
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return opts.Less(entries[i], entries[j])
+		})
+		if opts.Limit > 0 && len(entries) > opts.Limit {
+			entries = entries[:opts.Limit]
+		}
+
+		for _, entry := range entries {
+			if !send(entry) {
+				return
+			}
+		}
+	}(out)
+
+	return out
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+)
+
+// MaxMetadataKey is the longest key we accept, which leaves room for a prefix within the 255 bytes
+// most filesystems allow for the name of an extended attribute
+const MaxMetadataKey = 200
+
+// MetadataStore can optionally be implemented by your StorageProvider to store arbitrary key/value metadata,
+// like tags or a description, along with files and directories. It should stay with the file when it's
+// moved or copied. Callers should use GetMetadata, SetMetadata and ListMetadata, which check the keys and
+// return ErrNotSupported if this isn't implemented.
+type MetadataStore interface {
+	// GetMetadata returns the value of key, ErrNotExist should be returned if either fullpath or key doesn't exist
+	GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error)
+	// SetMetadata sets key to value, an empty value removes the key
+	SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error
+	// ListMetadata returns all the metadata of fullpath, which is empty rather than nil if there isn't any
+	ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error)
+}
+
+// checkMetadataKey makes sure key is something every MetadataStore is able to store
+func checkMetadataKey(key string) error {
+	if key == "" || len(key) > MaxMetadataKey || strings.ContainsRune(key, 0) {
+		return fmt.Errorf("%w: invalid metadata key %q", ErrInvalidPath, key)
+	}
+	return nil
+}
+
+func metadataStore(provider StorageProvider) (MetadataStore, error) {
+	store, ok := provider.(MetadataStore)
+	if !ok {
+		return nil, fmt.Errorf("%w: metadata", ErrNotSupported)
+	}
+	return store, nil
+}
+
+// copyMetadata copies all the metadata of src over to dst, for the providers that support it
+func copyMetadata(ctx context.Context, provider StorageProvider, user *models.User, src, dst string) error {
+	store, ok := provider.(MetadataStore)
+	if !ok {
+		return nil
+	}
+
+	metadata, err := store.ListMetadata(ctx, user, src)
+	if errors.Is(err, ErrNotSupported) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for key, value := range metadata {
+		err = store.SetMetadata(ctx, user, dst, key, value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMetadata returns the value of key for fullpath, using the MetadataStore implementation of provider
+func GetMetadata(ctx context.Context, provider StorageProvider, user *models.User, fullpath, key string) (string, error) {
+	store, err := metadataStore(provider)
+	if err != nil {
+		return "", err
+	}
+	if err = checkMetadataKey(key); err != nil {
+		return "", err
+	}
+	return store.GetMetadata(ctx, user, fullpath, key)
+}
+
+// SetMetadata sets key to value for fullpath, an empty value removes the key
+func SetMetadata(ctx context.Context, provider StorageProvider, user *models.User, fullpath, key, value string) error {
+	store, err := metadataStore(provider)
+	if err != nil {
+		return err
+	}
+	if err = checkMetadataKey(key); err != nil {
+		return err
+	}
+	return store.SetMetadata(ctx, user, fullpath, key, value)
+}
+
+// ListMetadata returns all the metadata of fullpath
+func ListMetadata(ctx context.Context, provider StorageProvider, user *models.User, fullpath string) (map[string]string, error) {
+	store, err := metadataStore(provider)
+	if err != nil {
+		return nil, err
+	}
+	return store.ListMetadata(ctx, user, fullpath)
+}
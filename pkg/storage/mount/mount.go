@@ -0,0 +1,347 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// Mount makes Provider show up at Path for every user, Path is absolute like /archive
+type Mount struct {
+	Path     string
+	Provider storage.StorageProvider
+}
+
+// CrossMountError is returned for moves from one mount to another, unless those are copied instead.
+// It matches storage.ErrNotSupported, as no single provider is able to do this
+type CrossMountError struct {
+	Src, Dst string
+}
+
+func (e *CrossMountError) Error() string {
+	return fmt.Sprintf("Can't move %s to %s, as they're on different mounts", e.Src, e.Dst)
+}
+
+func (e *CrossMountError) Is(target error) bool {
+	return target == storage.ErrNotSupported
+}
+
+// MountStorageProvider routes every call to the provider mounted at the longest prefix of the path,
+// with the root provider serving everything that isn't below any of the mounts
+type MountStorageProvider struct {
+	// ordered by the length of their path with the longest first, so the root comes last
+	mounts []Mount
+	// whether moves across mounts are done by copying and deleting rather than refused
+	copyAcross bool
+}
+
+// Mounts puts the providers of mounts on top of root. With copyAcross set, moves between mounts copy
+// everything over and delete the source afterwards, otherwise they fail with a CrossMountError
+func Mounts(root storage.StorageProvider, mounts []Mount, copyAcross bool) (*MountStorageProvider, error) {
+	out := &MountStorageProvider{
+		mounts:     make([]Mount, 0, len(mounts)+1),
+		copyAcross: copyAcross,
+	}
+
+	seen := make(map[string]bool)
+	for _, mount := range mounts {
+		if !path.IsAbs(mount.Path) || path.Clean(mount.Path) != mount.Path || mount.Path == "/" {
+			return nil, fmt.Errorf("Invalid mount path %q, it should be a clean absolute path other than /", mount.Path)
+		}
+		if seen[mount.Path] {
+			return nil, fmt.Errorf("Multiple providers mounted at %s", mount.Path)
+		}
+		seen[mount.Path] = true
+		out.mounts = append(out.mounts, mount)
+	}
+	sort.SliceStable(out.mounts, func(i, j int) bool {
+		return len(out.mounts[i].Path) > len(out.mounts[j].Path)
+	})
+	out.mounts = append(out.mounts, Mount{Path: "/", Provider: root})
+
+	return out, nil
+}
+
+func (m *MountStorageProvider) MountPoints() []string {
+	out := make([]string, 0, len(m.mounts))
+	for _, mount := range m.mounts {
+		out = append(out, mount.Path)
+	}
+	return out
+}
+
+// route returns the mount fullpath is on, along with the path within that mount
+func (m *MountStorageProvider) route(fullpath string) (Mount, string) {
+	fullpath = path.Clean("/" + fullpath)
+	for _, mount := range m.mounts {
+		switch {
+		case mount.Path == "/":
+			return mount, fullpath
+		case fullpath == mount.Path:
+			return mount, "/"
+		case strings.HasPrefix(fullpath, mount.Path+"/"):
+			return mount, strings.TrimPrefix(fullpath, mount.Path)
+		}
+	}
+	// the root is always last and matches everything
+	panic("No root mount")
+}
+
+// outer turns info as returned by the provider of mount into what it looks like from the outside
+func outer(mount Mount, info storage.FileInfo) storage.FileInfo {
+	if mount.Path == "/" {
+		return info
+	}
+	info.FullPath = path.Join(mount.Path, info.FullPath)
+	if info.FullPath == mount.Path {
+		info.Name = path.Base(mount.Path)
+	}
+	return info
+}
+
+// checkMounts refuses to touch fullpath as a whole if it's a mount point or has one somewhere below it,
+// as that would only affect whatever the mount is hiding
+func (m *MountStorageProvider) checkMounts(fullpath string) error {
+	fullpath = path.Clean("/" + fullpath)
+	for _, mount := range m.mounts {
+		if mount.Path == "/" {
+			continue
+		}
+		if fullpath == "/" || mount.Path == fullpath || strings.HasPrefix(mount.Path, fullpath+"/") {
+			return fmt.Errorf("%w: %s is or contains the mount point %s", storage.ErrReadOnly, fullpath, mount.Path)
+		}
+	}
+	return nil
+}
+
+func (m *MountStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	for _, mount := range m.mounts {
+		err := mount.Provider.InitUser(ctx, user)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the mount points should be reachable, so their parents have to exist
+	for _, mount := range m.mounts {
+		if mount.Path == "/" || path.Dir(mount.Path) == "/" {
+			continue
+		}
+		err := m.Mkdir(ctx, user, path.Dir(mount.Path))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MountStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	mount, inner := m.route(dir)
+	return mount.Provider.Mkdir(ctx, user, inner)
+}
+
+func (m *MountStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := m.checkMounts(src)
+	if err != nil {
+		return err
+	}
+
+	srcMount, srcPath := m.route(src)
+	dstMount, dstPath := m.route(dst)
+	if srcMount.Path == dstMount.Path {
+		return srcMount.Provider.Move(ctx, user, srcPath, dstPath)
+	}
+	if !m.copyAcross {
+		return &CrossMountError{Src: src, Dst: dst}
+	}
+
+	_, err = dstMount.Provider.Stat(ctx, user, dstPath)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	err = storage.StreamCopy(ctx, m, user, src, dst)
+	if err != nil {
+		// dst didn't exist before, so whatever made it over is ours to clean up
+		cleanupErr := dstMount.Provider.DeleteTree(ctx, user, dstPath)
+		if cleanupErr != nil && !errors.Is(cleanupErr, storage.ErrNotExist) {
+			return fmt.Errorf("%w, leaving %s behind: %s", err, dst, cleanupErr)
+		}
+		return err
+	}
+	return srcMount.Provider.DeleteTree(ctx, user, srcPath)
+}
+
+// Copy uses the provider of the mount if src and dst are on the same one, otherwise everything is streamed over
+func (m *MountStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	srcMount, srcPath := m.route(src)
+	dstMount, dstPath := m.route(dst)
+	if srcMount.Path == dstMount.Path && m.checkMounts(src) == nil {
+		return storage.Copy(ctx, srcMount.Provider, user, srcPath, dstPath)
+	}
+	return storage.StreamCopy(ctx, m, user, src, dst)
+}
+
+func (m *MountStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	mount, inner := m.route(dir)
+	files, err := mount.Provider.ListDirectory(ctx, user, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	// mount points right inside of dir show up as directories, hiding whatever has the same name
+	dir = path.Clean("/" + dir)
+	mounted := make(map[string]storage.FileInfo)
+	for _, below := range m.mounts {
+		if below.Path == "/" || path.Dir(below.Path) != dir {
+			continue
+		}
+		info, err := m.Stat(ctx, user, below.Path)
+		if err != nil {
+			// most likely mounted after the user got initialized, it should still show up though
+			info = storage.FileInfo{Name: path.Base(below.Path), FullPath: below.Path, Directory: true}
+		}
+		mounted[info.Name] = info
+	}
+
+	out := make(chan storage.FileInfo)
+
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+		send := func(info storage.FileInfo) bool {
+			select {
+			case out <- info:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for info := range files {
+			if _, ok := mounted[info.Name]; ok {
+				continue
+			}
+			if !send(outer(mount, info)) {
+				return
+			}
+		}
+		for _, info := range mounted {
+			if !send(info) {
+				return
+			}
+		}
+	}(out)
+
+	return out, nil
+}
+
+func (m *MountStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	mount, inner := m.route(fullpath)
+	info, err := mount.Provider.Stat(ctx, user, inner)
+	if err != nil {
+		return info, err
+	}
+	return outer(mount, info), nil
+}
+
+func (m *MountStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	mount, inner := m.route(fullpath)
+	return mount.Provider.File(ctx, user, inner)
+}
+
+func (m *MountStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	err := m.checkMounts(fullpath)
+	if err != nil {
+		return err
+	}
+	mount, inner := m.route(fullpath)
+	return mount.Provider.Delete(ctx, user, inner)
+}
+
+func (m *MountStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := m.checkMounts(fullpath)
+	if err != nil {
+		return err
+	}
+	mount, inner := m.route(fullpath)
+	return mount.Provider.DeleteTree(ctx, user, inner)
+}
+
+func (m *MountStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	mount, inner := m.route(fullpath)
+	return storage.GetMetadata(ctx, mount.Provider, user, inner, key)
+}
+
+func (m *MountStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	mount, inner := m.route(fullpath)
+	return storage.SetMetadata(ctx, mount.Provider, user, inner, key, value)
+}
+
+func (m *MountStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	mount, inner := m.route(fullpath)
+	return storage.ListMetadata(ctx, mount.Provider, user, inner)
+}
+
+// Watch is only supported if every mounted provider supports it, as the events have to cover every change
+func (m *MountStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	watching := make([]<-chan storage.Event, 0, len(m.mounts))
+	for _, mount := range m.mounts {
+		events, err := storage.Watch(ctx, mount.Provider, user)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+
+		mount := mount
+		watching = append(watching, storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+			fullpath = path.Join(mount.Path, fullpath)
+			// changes hidden by a mount on top of this one aren't visible
+			visible, _ := m.route(fullpath)
+			return fullpath, visible.Path == mount.Path
+		}))
+	}
+
+	out := make(chan storage.Event)
+	var wg sync.WaitGroup
+	for _, events := range watching {
+		wg.Add(1)
+		go func(events <-chan storage.Event) {
+			defer wg.Done()
+			for event := range events {
+				select {
+				case out <- event:
+				case <-ctx.Done():
+				}
+			}
+		}(events)
+	}
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out)
+	}()
+
+	return out, nil
+}
+
+// AtomicWrites is only true if it's true for every mounted provider
+func (m *MountStorageProvider) AtomicWrites() bool {
+	for _, mount := range m.mounts {
+		if !storage.AtomicWrites(mount.Provider) {
+			return false
+		}
+	}
+	return true
+}
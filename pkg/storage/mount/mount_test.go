@@ -0,0 +1,179 @@
+package mount
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMountStorage(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		provider, err := Mounts(local.NewStorageProvider(t.TempDir()), []Mount{
+			{Path: "/archive", Provider: local.NewStorageProvider(t.TempDir())},
+		}, false)
+		if assert.NoError(t, err) {
+			storage.TestStorageProvider(provider, t)
+		}
+	})
+	t.Run("Memory", func(t *testing.T) {
+		provider, err := Mounts(memory.NewStorageProvider(), []Mount{
+			{Path: "/archive", Provider: memory.NewStorageProvider()},
+		}, false)
+		if assert.NoError(t, err) {
+			storage.TestStorageProvider(provider, t)
+		}
+	})
+}
+
+func TestInvalidMounts(t *testing.T) {
+	for _, mounts := range [][]Mount{
+		{{Path: "/"}},
+		{{Path: "archive"}},
+		{{Path: "/archive/"}},
+		{{Path: "/archive"}, {Path: "/archive"}},
+	} {
+		_, err := Mounts(memory.NewStorageProvider(), mounts, false)
+		assert.Error(t, err, mounts)
+	}
+}
+
+func TestRouting(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	root := memory.NewStorageProvider()
+	archive := memory.NewStorageProvider()
+	nested := memory.NewStorageProvider()
+	provider, err := Mounts(root, []Mount{
+		{Path: "/archive", Provider: archive},
+		{Path: "/archive/old/nested", Provider: nested},
+	}, false)
+	if !assert.NoError(t, err) || !assert.NoError(t, provider.InitUser(ctx, user)) {
+		return
+	}
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "root"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/archive/file", "archive"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/archive/old/nested/file", "nested"))
+
+	// every file ends up on the provider its mount is for
+	assert.Equal(t, "root", storagetest.ReadFile(t, root, user, "/file"))
+	assert.Equal(t, "archive", storagetest.ReadFile(t, archive, user, "/file"))
+	assert.Equal(t, "nested", storagetest.ReadFile(t, nested, user, "/file"))
+
+	t.Run("Stat", func(t *testing.T) {
+		info, err := provider.Stat(ctx, user, "/archive/old/nested/file")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/archive/old/nested/file", info.FullPath)
+			assert.Equal(t, "file", info.Name)
+		}
+
+		info, err = provider.Stat(ctx, user, "/archive")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/archive", info.FullPath)
+			assert.Equal(t, "archive", info.Name)
+			assert.True(t, info.Directory)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		for dir, expected := range map[string]map[string]string{
+			"/":            {"file": "/file", "archive": "/archive"},
+			"/archive":     {"file": "/archive/file", "old": "/archive/old"},
+			"/archive/old": {"nested": "/archive/old/nested"},
+		} {
+			files, err := provider.ListDirectory(ctx, user, dir)
+			if !assert.NoError(t, err) {
+				continue
+			}
+			listed := make(map[string]string)
+			for file := range files {
+				listed[file.Name] = file.FullPath
+			}
+			assert.Equal(t, expected, listed, dir)
+		}
+	})
+
+	t.Run("MountPoints", func(t *testing.T) {
+		for _, fullpath := range []string{"/", "/archive", "/archive/old"} {
+			assert.ErrorIs(t, provider.DeleteTree(ctx, user, fullpath), storage.ErrReadOnly, fullpath)
+		}
+		assert.ErrorIs(t, provider.Delete(ctx, user, "/archive/old/nested"), storage.ErrReadOnly)
+		assert.ErrorIs(t, provider.Move(ctx, user, "/archive", "/moved"), storage.ErrReadOnly)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		// moves within a single mount are left to its provider
+		assert.NoError(t, provider.Move(ctx, user, "/archive/file", "/archive/renamed"))
+		assert.Equal(t, "archive", storagetest.ReadFile(t, archive, user, "/renamed"))
+
+		err := provider.Move(ctx, user, "/file", "/archive/file")
+		var crossMount *CrossMountError
+		if assert.True(t, errors.As(err, &crossMount), err) {
+			assert.Equal(t, "/file", crossMount.Src)
+			assert.Equal(t, "/archive/file", crossMount.Dst)
+		}
+		assert.ErrorIs(t, err, storage.ErrNotSupported)
+	})
+
+	t.Run("CrossMountCopy", func(t *testing.T) {
+		copying, err := Mounts(root, []Mount{{Path: "/archive", Provider: archive}}, true)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.NoError(t, copying.Mkdir(ctx, user, "/dir"))
+		assert.NoError(t, storagetest.WriteFile(copying, user, "/dir/file", "moved"))
+		assert.NoError(t, copying.Move(ctx, user, "/dir", "/archive/dir"))
+
+		assert.Equal(t, "moved", storagetest.ReadFile(t, archive, user, "/dir/file"))
+		_, err = root.Stat(ctx, user, "/dir")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+
+		// the destination isn't touched if it exists already
+		assert.ErrorIs(t, copying.Move(ctx, user, "/file", "/archive/dir"), storage.ErrExist)
+		assert.Equal(t, "root", storagetest.ReadFile(t, root, user, "/file"))
+	})
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	// memory doesn't support watching, which means we can't either
+	provider, err := Mounts(local.NewStorageProvider(t.TempDir()), []Mount{
+		{Path: "/archive", Provider: memory.NewStorageProvider()},
+	}, false)
+	if assert.NoError(t, err) {
+		_, err = provider.Watch(ctx, user)
+		assert.ErrorIs(t, err, storage.ErrNotSupported)
+	}
+
+	provider, err = Mounts(local.NewStorageProvider(t.TempDir()), []Mount{
+		{Path: "/archive", Provider: local.NewStorageProvider(t.TempDir())},
+	}, false)
+	if !assert.NoError(t, err) || !assert.NoError(t, provider.InitUser(ctx, user)) {
+		return
+	}
+	events, err := provider.Watch(ctx, user)
+	if errors.Is(err, storage.ErrNotSupported) {
+		t.Skip("The local provider can't watch for changes on this platform")
+	} else if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/archive/dir"))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/archive/dir", Directory: true}, storagetest.NextEvent(t, events))
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// notifyFile reports the file as created or modified once it's closed after being written to
+type notifyFile struct {
+	proxy    storage.File
+	provider *NotifyStorageProvider
+	user     *models.User
+	fullpath string
+
+	written bool
+	created bool
+}
+
+// beforeWrite checks whether the file exists yet, this has to happen before the first write creates it
+func (f *notifyFile) beforeWrite() {
+	if f.written {
+		return
+	}
+	f.written = true
+
+	if f.provider.reporting(f.user) {
+		// the first write may come in a later request, like the chunks of a tus upload, so this can't be
+		// tied to the context the file got opened with
+		exists, _ := f.provider.stat(context.Background(), f.user, f.fullpath)
+		f.created = !exists
+	}
+}
+
+func (f *notifyFile) Read(p []byte) (int, error) {
+	return f.proxy.Read(p)
+}
+
+func (f *notifyFile) Write(p []byte) (int, error) {
+	f.beforeWrite()
+	return f.proxy.Write(p)
+}
+
+func (f *notifyFile) Close() error {
+	err := f.proxy.Close()
+	if err == nil && f.written {
+		event := storage.Event{Type: storage.EventModify, Path: f.fullpath}
+		if f.created {
+			event.Type = storage.EventCreate
+		}
+		f.provider.emit(f.user, event)
+	}
+	return err
+}
+
+// Abort doesn't report anything, as the file is left as it was
+func (f *notifyFile) Abort() error {
+	return storage.Abort(f.proxy)
+}
+
+type notifySeekableFile struct {
+	*notifyFile
+	seeker storage.SeekableFile
+}
+
+func (f *notifySeekableFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.seeker.ReadAt(p, off)
+}
+
+func (f *notifySeekableFile) WriteAt(p []byte, off int64) (int, error) {
+	f.beforeWrite()
+	return f.seeker.WriteAt(p, off)
+}
+
+func (f *notifySeekableFile) Seek(offset int64, whence int) (int64, error) {
+	return f.seeker.Seek(offset, whence)
+}
@@ -0,0 +1,274 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// how many events a subscriber can fall behind before it starts missing them
+const subscriberBuffer = 64
+
+// NotifyStorageProvider reports the changes made to the files of a user to everyone watching them.
+// If the underlying provider is able to notice changes itself, like the local provider using inotify,
+// those events are used as they also cover changes made without going through us. Otherwise we
+// report the changes made through this provider ourselves.
+type NotifyStorageProvider struct {
+	proxy storage.StorageProvider
+
+	mutex sync.Mutex
+	users map[uint64]*hub
+}
+
+// hub keeps track of everyone watching a single user
+type hub struct {
+	subscribers map[chan storage.Event]struct{}
+	// set while the underlying provider is reporting the events, in which case we shouldn't
+	watching bool
+	cancel   context.CancelFunc
+}
+
+func Notify(provider storage.StorageProvider) *NotifyStorageProvider {
+	return &NotifyStorageProvider{
+		proxy: provider,
+		users: make(map[uint64]*hub),
+	}
+}
+
+func (n *NotifyStorageProvider) Unwrap() storage.StorageProvider {
+	return n.proxy
+}
+
+// Watch returns the changes to the files of user until ctx is done. The events are sent to every watcher
+// without waiting on any of them, so a watcher that doesn't keep up will miss events.
+func (n *NotifyStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	ch := make(chan storage.Event, subscriberBuffer)
+
+	n.mutex.Lock()
+	h, ok := n.users[user.ID]
+	if !ok {
+		h = &hub{subscribers: make(map[chan storage.Event]struct{})}
+
+		// the watch outlives the context of this caller, as it's shared with everyone watching after them
+		watchCtx, cancel := context.WithCancel(context.Background())
+		events, err := storage.Watch(watchCtx, n.proxy, user)
+		switch {
+		case err == nil:
+			h.watching = true
+			h.cancel = cancel
+			go n.forward(user.ID, h, events)
+		case errors.Is(err, storage.ErrNotSupported):
+			cancel()
+		default:
+			cancel()
+			n.mutex.Unlock()
+			return nil, err
+		}
+		n.users[user.ID] = h
+	}
+	h.subscribers[ch] = struct{}{}
+	n.mutex.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		n.unsubscribe(user.ID, h, ch)
+	}()
+
+	return ch, nil
+}
+
+func (n *NotifyStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(n.proxy)
+}
+
+func (n *NotifyStorageProvider) unsubscribe(id uint64, h *hub, ch chan storage.Event) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	delete(h.subscribers, ch)
+	close(ch)
+
+	if len(h.subscribers) == 0 {
+		if h.cancel != nil {
+			h.cancel()
+		}
+		if n.users[id] == h {
+			delete(n.users, id)
+		}
+	}
+}
+
+// forward passes on the events of the underlying provider to the subscribers of h
+func (n *NotifyStorageProvider) forward(id uint64, h *hub, events <-chan storage.Event) {
+	for event := range events {
+		n.mutex.Lock()
+		h.publish(event)
+		n.mutex.Unlock()
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.users[id] == h && h.watching {
+		// still in use, so the underlying provider stopped on its own
+		logrus.Warnf("Stopped receiving changes from the storage for user %d, only reporting our own changes from now on", id)
+		h.watching = false
+	}
+}
+
+// publish should only be called while holding the mutex of the provider
+func (h *hub) publish(event storage.Event) {
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			logrus.Warnf("Dropped %s event for %s, watcher isn't keeping up", event.Type, event.Path)
+		}
+	}
+}
+
+// reporting returns whether we should report the changes to the files of user ourselves,
+// this can be used to skip the work needed to build the event
+func (n *NotifyStorageProvider) reporting(user *models.User) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	h, ok := n.users[user.ID]
+	return ok && !h.watching
+}
+
+func (n *NotifyStorageProvider) emit(user *models.User, event storage.Event) {
+	event.Path = path.Join("/", event.Path)
+	if event.OldPath != "" {
+		event.OldPath = path.Join("/", event.OldPath)
+	}
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	h, ok := n.users[user.ID]
+	if ok && !h.watching {
+		h.publish(event)
+	}
+}
+
+// stat returns whether fullpath exists and if so whether it's a directory
+func (n *NotifyStorageProvider) stat(ctx context.Context, user *models.User, fullpath string) (exists bool, directory bool) {
+	info, err := n.proxy.Stat(ctx, user, fullpath)
+	if err != nil {
+		return false, false
+	}
+	return true, info.Directory
+}
+
+func (n *NotifyStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return n.proxy.InitUser(ctx, user)
+}
+
+func (n *NotifyStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	if !n.reporting(user) {
+		return n.proxy.Mkdir(ctx, user, dir)
+	}
+
+	existed, _ := n.stat(ctx, user, dir)
+	err := n.proxy.Mkdir(ctx, user, dir)
+	if err == nil && !existed {
+		n.emit(user, storage.Event{Type: storage.EventCreate, Path: dir, Directory: true})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := n.proxy.Move(ctx, user, src, dst)
+	if err == nil && n.reporting(user) {
+		_, directory := n.stat(ctx, user, dst)
+		n.emit(user, storage.Event{Type: storage.EventMove, Path: dst, OldPath: src, Directory: directory})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	err := storage.Copy(ctx, n.proxy, user, src, dst)
+	if err == nil && n.reporting(user) {
+		_, directory := n.stat(ctx, user, dst)
+		n.emit(user, storage.Event{Type: storage.EventCreate, Path: dst, Directory: directory})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	return n.proxy.ListDirectory(ctx, user, dir)
+}
+
+func (n *NotifyStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	return storage.List(ctx, n.proxy, user, dir, opts)
+}
+
+func (n *NotifyStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	return n.proxy.Stat(ctx, user, fullpath)
+}
+
+func (n *NotifyStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	file, err := n.proxy.File(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &notifyFile{
+		proxy:    file,
+		provider: n,
+		user:     user,
+		fullpath: fullpath,
+	}
+	if seekable, ok := file.(storage.SeekableFile); ok {
+		return &notifySeekableFile{notifyFile: out, seeker: seekable}, nil
+	}
+	return out, nil
+}
+
+func (n *NotifyStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	if !n.reporting(user) {
+		return n.proxy.Delete(ctx, user, fullpath)
+	}
+
+	_, directory := n.stat(ctx, user, fullpath)
+	err := n.proxy.Delete(ctx, user, fullpath)
+	if err == nil {
+		n.emit(user, storage.Event{Type: storage.EventDelete, Path: fullpath, Directory: directory})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	if !n.reporting(user) {
+		return n.proxy.DeleteTree(ctx, user, fullpath)
+	}
+
+	_, directory := n.stat(ctx, user, fullpath)
+	err := n.proxy.DeleteTree(ctx, user, fullpath)
+	if err == nil {
+		n.emit(user, storage.Event{Type: storage.EventDelete, Path: fullpath, Directory: directory})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	return storage.GetMetadata(ctx, n.proxy, user, fullpath, key)
+}
+
+func (n *NotifyStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	err := storage.SetMetadata(ctx, n.proxy, user, fullpath, key, value)
+	if err == nil && n.reporting(user) {
+		_, directory := n.stat(ctx, user, fullpath)
+		n.emit(user, storage.Event{Type: storage.EventModify, Path: fullpath, Directory: directory})
+	}
+	return err
+}
+
+func (n *NotifyStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	return storage.ListMetadata(ctx, n.proxy, user, fullpath)
+}
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotify(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		storage.TestStorageProvider(Notify(local.NewStorageProvider(t.TempDir())), t)
+	})
+	t.Run("Memory", func(t *testing.T) {
+		storage.TestStorageProvider(Notify(memory.NewStorageProvider()), t)
+	})
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+	other := &models.User{ID: 2}
+
+	provider := Notify(memory.NewStorageProvider())
+
+	events, err := provider.Watch(ctx, user)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// a second watcher gets the same events
+	second, err := provider.Watch(ctx, user)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder"))
+	// creating it again changes nothing, so there's no event for it
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/folder/file", "first"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/folder/file", "second"))
+	assert.NoError(t, storagetest.WriteFile(provider, other, "/someone/else", "data"))
+	assert.NoError(t, provider.Copy(ctx, user, "/folder/file", "/copy"))
+	assert.NoError(t, provider.Move(ctx, user, "/folder", "/moved"))
+	assert.NoError(t, provider.DeleteTree(ctx, user, "/moved"))
+	assert.Error(t, provider.Delete(ctx, user, "/missing"))
+
+	expected := []storage.Event{
+		{Type: storage.EventCreate, Path: "/folder", Directory: true},
+		{Type: storage.EventCreate, Path: "/folder/file"},
+		{Type: storage.EventModify, Path: "/folder/file"},
+		{Type: storage.EventCreate, Path: "/copy"},
+		{Type: storage.EventMove, Path: "/moved", OldPath: "/folder", Directory: true},
+		{Type: storage.EventDelete, Path: "/moved", Directory: true},
+	}
+	for _, event := range expected {
+		assert.Equal(t, event, storagetest.NextEvent(t, events))
+		assert.Equal(t, event, storagetest.NextEvent(t, second))
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected event %+v", event)
+	default:
+	}
+
+	// the channels are closed once we stop watching, after which we no longer keep track of the user
+	cancel()
+	for range events {
+	}
+	for range second {
+	}
+	assert.False(t, provider.reporting(user))
+}
+
+func TestEventsFromWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	local := local.NewStorageProvider(t.TempDir())
+	assert.NoError(t, local.InitUser(ctx, user))
+	provider := Notify(local)
+
+	events, err := provider.Watch(ctx, user)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !provider.users[user.ID].watching {
+		t.Skip("The local provider can't watch for changes on this platform")
+	}
+
+	// as the events come from the watcher now, we should only see them once
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder"))
+	assert.Equal(t, storage.Event{Type: storage.EventCreate, Path: "/folder", Directory: true}, storagetest.NextEvent(t, events))
+	assert.NoError(t, provider.Delete(ctx, user, "/folder"))
+	assert.Equal(t, storage.Event{Type: storage.EventDelete, Path: "/folder", Directory: true}, storagetest.NextEvent(t, events))
+
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -6,14 +6,18 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"sync"
+	"time"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 	"github.com/leicht-cloud/leicht-cloud/pkg/system"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
 	"gopkg.in/yaml.v3"
 )
 
@@ -38,11 +42,63 @@ func toError(err error) *Error {
 	if err != nil {
 		return &Error{
 			Message: err.Error(),
+			Code:    toErrorCode(err),
 		}
 	}
 	return &Error{}
 }
 
+// toTimestamp splits t up in seconds and nanoseconds as documented in storage.proto, anything before
+// the unix epoch (including the zero time) is sent as unknown
+func toTimestamp(t time.Time) (uint64, uint32) {
+	if t.Unix() <= 0 {
+		return 0, 0
+	}
+	return uint64(t.Unix()), uint32(t.Nanosecond())
+}
+
+func toFileInfo(info storage.FileInfo) *FileInfo {
+	createdAt, createdAtNanos := toTimestamp(info.CreatedAt)
+	updatedAt, updatedAtNanos := toTimestamp(info.UpdatedAt)
+	return &FileInfo{
+		Name:           info.Name,
+		FullPath:       info.FullPath,
+		CreatedAt:      createdAt,
+		CreatedAtNanos: createdAtNanos,
+		UpdatedAt:      updatedAt,
+		UpdatedAtNanos: updatedAtNanos,
+		Size:           info.Size,
+		Directory:      info.Directory,
+		Mode:           uint32(info.Mode.Perm()),
+		Etag:           info.ETag,
+		Symlink:        info.Symlink,
+	}
+}
+
+func toEvent(event storage.Event) *Event {
+	return &Event{
+		Type:      eventTypes[event.Type],
+		Path:      event.Path,
+		OldPath:   event.OldPath,
+		Directory: event.Directory,
+	}
+}
+
+func toListOptions(req *ListDirectoryQuery) storage.ListOptions {
+	out := storage.ListOptions{
+		Descending: req.GetDescending(),
+		Cursor:     req.GetCursor(),
+		Limit:      int(req.GetLimit()),
+		Filter:     req.GetFilter(),
+	}
+	for key, code := range sortKeys {
+		if code == req.GetSort() {
+			out.Sort = key
+		}
+	}
+	return out
+}
+
 func toUser(req *User) *models.User {
 	if req == nil {
 		return nil
@@ -54,6 +110,11 @@ func toUser(req *User) *models.User {
 
 var ErrNoUser = &Error{Message: "No user specified"}
 
+// watchingHeader is sent by Watch once it's watching, see GrpcStorage.Watch
+const watchingHeader = "watching"
+
+var ErrNotSeekable = errors.New("File does not support random access")
+
 func (s *BridgeStorageProviderServer) Configure(ctx context.Context, req *ConfigData) (*Error, error) {
 	err := yaml.Unmarshal(req.GetYaml(), s.Storage)
 	if err != nil {
@@ -92,26 +153,28 @@ func (s *BridgeStorageProviderServer) Move(ctx context.Context, req *MoveQuery)
 	return toError(s.Storage.Move(ctx, user, req.GetSrc(), req.GetDst())), nil
 }
 
+// Copy runs in the plugin, so even if the storage doesn't implement Copier the data doesn't go through the host
+func (s *BridgeStorageProviderServer) Copy(ctx context.Context, req *CopyQuery) (*Error, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return ErrNoUser, nil
+	}
+	return toError(storage.Copy(ctx, s.Storage, user, req.GetSrc(), req.GetDst())), nil
+}
+
 func (s *BridgeStorageProviderServer) ListDirectory(req *ListDirectoryQuery, srv StorageProvider_ListDirectoryServer) error {
 	user := toUser(req.GetUser())
 	if user == nil {
 		return nil
 	}
 
-	files, err := s.Storage.ListDirectory(srv.Context(), user, req.GetPath())
+	files, err := storage.List(srv.Context(), s.Storage, user, req.GetPath(), toListOptions(req))
 	if err != nil {
-		return err
+		return toStatusError(err)
 	}
 
 	for f := range files {
-		err = srv.Send(&FileInfo{
-			Name:      f.Name,
-			FullPath:  f.FullPath,
-			CreatedAt: uint64(f.CreatedAt.Unix()),
-			UpdatedAt: uint64(f.UpdatedAt.Unix()),
-			Size:      f.Size,
-			Directory: f.Directory,
-		})
+		err = srv.Send(toFileInfo(f))
 		if err != nil {
 			return err
 		}
@@ -120,6 +183,26 @@ func (s *BridgeStorageProviderServer) ListDirectory(req *ListDirectoryQuery, srv
 	return nil
 }
 
+func (s *BridgeStorageProviderServer) Stat(ctx context.Context, req *StatQuery) (*StatReply, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return &StatReply{
+			Error: ErrNoUser,
+		}, nil
+	}
+
+	info, err := s.Storage.Stat(ctx, user, req.GetFullPath())
+	if err != nil {
+		return &StatReply{
+			Error: toError(err),
+		}, nil
+	}
+
+	return &StatReply{
+		Info: toFileInfo(info),
+	}, nil
+}
+
 func (s *BridgeStorageProviderServer) OpenFile(ctx context.Context, req *OpenFileQuery) (*OpenFileReply, error) {
 	user := toUser(req.GetUser())
 	if user == nil {
@@ -140,8 +223,11 @@ func (s *BridgeStorageProviderServer) OpenFile(ctx context.Context, req *OpenFil
 	s.nextID++
 	s.openFiles[s.nextID] = file
 
+	_, seekable := file.(storage.SeekableFile)
+
 	return &OpenFileReply{
-		Id: s.nextID,
+		Id:       s.nextID,
+		Seekable: seekable,
 	}, nil
 }
 
@@ -163,8 +249,12 @@ func (s *BridgeStorageProviderServer) CloseFile(ctx context.Context, req *CloseF
 		return toError(err), nil
 	}
 
-	// close the actual underlying file
-	err = file.Close()
+	// close the actual underlying file, throwing away what was written if we're asked to
+	if req.GetAbort() {
+		err = storage.Abort(file)
+	} else {
+		err = file.Close()
+	}
 	if err != nil {
 		return toError(err), nil
 	}
@@ -185,7 +275,18 @@ func (s *BridgeStorageProviderServer) WriteFile(ctx context.Context, req *WriteF
 		}, nil
 	}
 
-	n, err := file.Write(req.GetData())
+	var n int
+	if req.GetRanged() {
+		seekable, ok := file.(storage.SeekableFile)
+		if !ok {
+			return &WriteFileReply{
+				Error: toError(ErrNotSeekable),
+			}, nil
+		}
+		n, err = seekable.WriteAt(req.GetData(), req.GetOffset())
+	} else {
+		n, err = file.Write(req.GetData())
+	}
 	if err != nil {
 		return &WriteFileReply{
 			Error: toError(err),
@@ -206,8 +307,23 @@ func (s *BridgeStorageProviderServer) ReadFile(req *ReadFileQuery, srv StoragePr
 	buffer := make([]byte, system.GetBufferSize())
 	logrus.Debugf("buffer size: %d", len(buffer))
 
+	var reader io.Reader = file
+	if req.GetRanged() {
+		seekable, ok := file.(storage.SeekableFile)
+		if !ok {
+			return srv.Send(&ReadFileReply{
+				Error: toError(ErrNotSeekable),
+			})
+		}
+		length := req.GetLength()
+		if length <= 0 {
+			length = math.MaxInt64 - req.GetOffset()
+		}
+		reader = io.NewSectionReader(seekable, req.GetOffset(), length)
+	}
+
 	for {
-		n, err := file.Read(buffer)
+		n, err := reader.Read(buffer)
 		if err != nil {
 			if err == io.EOF {
 				// empty message indicate end of file
@@ -236,6 +352,33 @@ func (s *BridgeStorageProviderServer) ReadFile(req *ReadFileQuery, srv StoragePr
 	}
 }
 
+func (s *BridgeStorageProviderServer) SeekFile(ctx context.Context, req *SeekFileQuery) (*SeekFileReply, error) {
+	file, err := s.getFile(req.GetId())
+	if err != nil {
+		return &SeekFileReply{
+			Error: toError(err),
+		}, nil
+	}
+
+	seekable, ok := file.(storage.SeekableFile)
+	if !ok {
+		return &SeekFileReply{
+			Error: toError(ErrNotSeekable),
+		}, nil
+	}
+
+	offset, err := seekable.Seek(req.GetOffset(), int(req.GetWhence()))
+	if err != nil {
+		return &SeekFileReply{
+			Error: toError(err),
+		}, nil
+	}
+
+	return &SeekFileReply{
+		Offset: offset,
+	}, nil
+}
+
 func (s *BridgeStorageProviderServer) Delete(ctx context.Context, req *DeleteQuery) (*Error, error) {
 	user := toUser(req.GetUser())
 	if user == nil {
@@ -244,5 +387,98 @@ func (s *BridgeStorageProviderServer) Delete(ctx context.Context, req *DeleteQue
 	return toError(s.Storage.Delete(ctx, user, req.GetFullPath())), nil
 }
 
+func (s *BridgeStorageProviderServer) DeleteTree(ctx context.Context, req *DeleteQuery) (*Error, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return ErrNoUser, nil
+	}
+	return toError(s.Storage.DeleteTree(ctx, user, req.GetFullPath())), nil
+}
+
+func (s *BridgeStorageProviderServer) Watch(req *User, srv StorageProvider_WatchServer) error {
+	user := toUser(req)
+	if user == nil {
+		return nil
+	}
+
+	events, err := storage.Watch(srv.Context(), s.Storage, user)
+	if err != nil {
+		return toStatusError(err)
+	}
+
+	// as there may not be an event for a long time, we send the headers right away so the client
+	// knows we're watching. The context of the stream is done as soon as we return, stopping the watch
+	err = srv.SendHeader(metadata.Pairs(watchingHeader, "true"))
+	if err != nil {
+		return err
+	}
+
+	for event := range events {
+		err = srv.Send(toEvent(event))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BridgeStorageProviderServer) GetMetadata(ctx context.Context, req *MetadataQuery) (*MetadataReply, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return &MetadataReply{
+			Error: ErrNoUser,
+		}, nil
+	}
+
+	value, err := storage.GetMetadata(ctx, s.Storage, user, req.GetFullPath(), req.GetKey())
+	if err != nil {
+		return &MetadataReply{
+			Error: toError(err),
+		}, nil
+	}
+
+	return &MetadataReply{
+		Value: value,
+	}, nil
+}
+
+func (s *BridgeStorageProviderServer) SetMetadata(ctx context.Context, req *MetadataQuery) (*Error, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return ErrNoUser, nil
+	}
+	return toError(storage.SetMetadata(ctx, s.Storage, user, req.GetFullPath(), req.GetKey(), req.GetValue())), nil
+}
+
+func (s *BridgeStorageProviderServer) ListMetadata(ctx context.Context, req *StatQuery) (*ListMetadataReply, error) {
+	user := toUser(req.GetUser())
+	if user == nil {
+		return &ListMetadataReply{
+			Error: ErrNoUser,
+		}, nil
+	}
+
+	metadata, err := storage.ListMetadata(ctx, s.Storage, user, req.GetFullPath())
+	if err != nil {
+		return &ListMetadataReply{
+			Error: toError(err),
+		}, nil
+	}
+
+	out := make([]*Metadata, 0, len(metadata))
+	for key, value := range metadata {
+		out = append(out, &Metadata{Key: key, Value: value})
+	}
+	return &ListMetadataReply{
+		Metadata: out,
+	}, nil
+}
+
 func (s *BridgeStorageProviderServer) mustEmbedUnimplementedStorageProviderServer() {
 }
+
+func (s *BridgeStorageProviderServer) AtomicWrites(ctx context.Context, req *AtomicWritesQuery) (*AtomicWritesReply, error) {
+	return &AtomicWritesReply{
+		Atomic: storage.AtomicWrites(s.Storage),
+	}, nil
+}
@@ -5,8 +5,9 @@ package plugin
 
 import (
 	context "context"
-	"errors"
+	"fmt"
 	"io"
+	"io/fs"
 	"sync"
 	"time"
 
@@ -23,16 +24,52 @@ type GrpcStorage struct {
 
 	mutex     sync.RWMutex
 	openFiles map[int32]*File
+
+	// asked once when we connect, as this can't change
+	atomicWrites bool
 }
 
 func toError2(err *Error, Err error) error {
 	if Err != nil {
 		return Err
 	}
-	if err != nil && err.GetMessage() != "" {
-		return errors.New(err.GetMessage())
+	return fromError(err)
+}
+
+// fromTimestamp is the counterpart of toTimestamp, unknown times become the zero time
+func fromTimestamp(seconds uint64, nanos uint32) time.Time {
+	if seconds == 0 {
+		return time.Time{}
 	}
-	return nil
+	return time.Unix(int64(seconds), int64(nanos))
+}
+
+func fromFileInfo(info *FileInfo) storage.FileInfo {
+	return storage.FileInfo{
+		Name:      info.GetName(),
+		FullPath:  info.GetFullPath(),
+		CreatedAt: fromTimestamp(info.GetCreatedAt(), info.GetCreatedAtNanos()),
+		UpdatedAt: fromTimestamp(info.GetUpdatedAt(), info.GetUpdatedAtNanos()),
+		Size:      info.GetSize(),
+		Directory: info.GetDirectory(),
+		Mode:      fs.FileMode(info.GetMode()).Perm(),
+		ETag:      info.GetEtag(),
+		Symlink:   info.GetSymlink(),
+	}
+}
+
+func fromEvent(event *Event) storage.Event {
+	out := storage.Event{
+		Path:      event.GetPath(),
+		OldPath:   event.GetOldPath(),
+		Directory: event.GetDirectory(),
+	}
+	for eventType, code := range eventTypes {
+		if code == event.GetType() {
+			out.Type = eventType
+		}
+	}
+	return out
 }
 
 func NewGrpcStorage(conn *grpc.ClientConn, config map[interface{}]interface{}) (*GrpcStorage, error) {
@@ -46,13 +83,23 @@ func NewGrpcStorage(conn *grpc.ClientConn, config map[interface{}]interface{}) (
 	if err != nil {
 		return nil, err
 	}
+
+	// plugins built before this was added don't implement it, which comes down to the writes not being atomic
+	reply, err := out.Client.AtomicWrites(context.Background(), &AtomicWritesQuery{})
+	out.atomicWrites = err == nil && reply.GetAtomic()
 	return out, nil
 }
 
 func (s *GrpcStorage) Close() error {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
+	// closing a file removes it from openFiles, so we copy them out first
+	s.mutex.RLock()
+	files := make([]*File, 0, len(s.openFiles))
 	for _, f := range s.openFiles {
+		files = append(files, f)
+	}
+	s.mutex.RUnlock()
+
+	for _, f := range files {
 		f.Close()
 	}
 	return nil
@@ -104,59 +151,108 @@ func (s *GrpcStorage) Move(ctx context.Context, user *models.User, src, dst stri
 	return toError2(err, Err)
 }
 
-func (s *GrpcStorage) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
-	dir, err := s.Client.ListDirectory(ctx,
-		&ListDirectoryQuery{
+func (s *GrpcStorage) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	err, Err := s.Client.Copy(ctx,
+		&CopyQuery{
 			User: &User{
 				Id: user.ID,
 			},
-			Path: path,
+			Src: src,
+			Dst: dst,
 		},
 	)
+	return toError2(err, Err)
+}
+
+func (s *GrpcStorage) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
+	return s.list(ctx, &ListDirectoryQuery{
+		User: &User{
+			Id: user.ID,
+		},
+		Path: path,
+	})
+}
+
+func (s *GrpcStorage) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	files, err := s.list(ctx, &ListDirectoryQuery{
+		User: &User{
+			Id: user.ID,
+		},
+		Path:       dir,
+		Sort:       sortKeys[opts.Sort],
+		Descending: opts.Descending,
+		Cursor:     opts.Cursor,
+		Limit:      int32(opts.Limit),
+		Filter:     opts.Filter,
+	})
+	if err != nil || opts == (storage.ListOptions{}) {
+		return files, err
+	}
+	// plugins built before the options existed simply ignore them, applying them again doesn't change anything otherwise
+	return storage.ApplyListOptions(ctx, files, opts), nil
+}
+
+func (s *GrpcStorage) list(ctx context.Context, query *ListDirectoryQuery) (<-chan storage.FileInfo, error) {
+	dir, err := s.Client.ListDirectory(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
 	out := make(chan storage.FileInfo, 1)
 
+	// we wait for the first reply, so errors from the plugin are returned here rather than swallowed
 	reply, err := dir.Recv()
-	if err != nil {
-		return nil, err
-	} else {
-		out <- storage.FileInfo{
-			Name:      reply.Name,
-			FullPath:  reply.FullPath,
-			CreatedAt: time.Unix(int64(reply.CreatedAt), 0),
-			UpdatedAt: time.Unix(int64(reply.UpdatedAt), 0),
-			Size:      reply.Size,
-		}
+	if err == io.EOF {
+		close(out)
+		return out, nil
+	} else if err != nil {
+		return nil, fromStatusError(err)
 	}
+	out <- fromFileInfo(reply)
 
 	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
 		for {
 			reply, err := dir.Recv()
 			if err == io.EOF {
-				break
+				return
 			} else if err != nil {
 				logrus.Error(err)
-				break
+				return
 			}
 
-			out <- storage.FileInfo{
-				Name:      reply.Name,
-				FullPath:  reply.FullPath,
-				CreatedAt: time.Unix(int64(reply.CreatedAt), 0),
-				UpdatedAt: time.Unix(int64(reply.UpdatedAt), 0),
-				Size:      reply.Size,
+			select {
+			case out <- fromFileInfo(reply):
+			case <-ctx.Done():
+				return
 			}
 		}
-
-		close(out)
 	}(out)
 
 	return out, nil
 }
 
+func (s *GrpcStorage) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	reply, err := s.Client.Stat(ctx,
+		&StatQuery{
+			User: &User{
+				Id: user.ID,
+			},
+			FullPath: fullpath,
+		},
+	)
+	err = toError2(reply.GetError(), err)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	if reply.GetInfo() == nil {
+		return storage.FileInfo{}, storage.ErrNotExist
+	}
+
+	return fromFileInfo(reply.GetInfo()), nil
+}
+
 func (s *GrpcStorage) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	reply, err := s.Client.OpenFile(ctx,
 		&OpenFileQuery{
@@ -166,6 +262,7 @@ func (s *GrpcStorage) File(ctx context.Context, user *models.User, fullpath stri
 			FullPath: fullpath,
 		},
 	)
+	err = toError2(reply.GetError(), err)
 	if err != nil {
 		logrus.Errorf("%s opening %s for %d", err, fullpath, user.ID)
 		return nil, err
@@ -180,6 +277,10 @@ func (s *GrpcStorage) File(ctx context.Context, user *models.User, fullpath stri
 	defer s.mutex.Unlock()
 	s.openFiles[reply.Id] = file
 
+	if reply.GetSeekable() {
+		return &seekableFile{File: file}, nil
+	}
+
 	return file, nil
 }
 
@@ -195,10 +296,23 @@ func (s *GrpcStorage) Delete(ctx context.Context, user *models.User, fullpath st
 	return toError2(err, Err)
 }
 
-func (s *GrpcStorage) closeFile(id int32) error {
+func (s *GrpcStorage) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err, Err := s.Client.DeleteTree(ctx,
+		&DeleteQuery{
+			User: &User{
+				Id: user.ID,
+			},
+			FullPath: fullpath,
+		},
+	)
+	return toError2(err, Err)
+}
+
+func (s *GrpcStorage) closeFile(id int32, abort bool) error {
 	err, Err := s.Client.CloseFile(context.TODO(),
 		&CloseFileQuery{
-			Id: id,
+			Id:    id,
+			Abort: abort,
 		},
 	)
 
@@ -208,3 +322,113 @@ func (s *GrpcStorage) closeFile(id int32) error {
 
 	return toError2(err, Err)
 }
+
+func (s *GrpcStorage) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	stream, err := s.Client.Watch(ctx,
+		&User{
+			Id: user.ID,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// the plugin lets us know once it's watching, if it fails to do so the stream ends with the error instead
+	md, err := stream.Header()
+	if err != nil {
+		return nil, fromStatusError(err)
+	} else if len(md.Get(watchingHeader)) == 0 {
+		_, err = stream.Recv()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: watching for changes", storage.ErrNotSupported)
+		}
+		return nil, fromStatusError(err)
+	}
+
+	out := make(chan storage.Event)
+
+	go func(out chan<- storage.Event) {
+		defer close(out)
+
+		for {
+			reply, err := stream.Recv()
+			if err == io.EOF {
+				return
+			} else if err != nil {
+				if ctx.Err() == nil {
+					logrus.Error(err)
+				}
+				return
+			}
+
+			select {
+			case out <- fromEvent(reply):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+
+	return out, nil
+}
+
+func (s *GrpcStorage) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	reply, err := s.Client.GetMetadata(ctx,
+		&MetadataQuery{
+			User: &User{
+				Id: user.ID,
+			},
+			FullPath: fullpath,
+			Key:      key,
+		},
+	)
+	if err != nil {
+		return "", fromStatusError(err)
+	}
+	return reply.GetValue(), fromError(reply.GetError())
+}
+
+func (s *GrpcStorage) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	reply, err := s.Client.SetMetadata(ctx,
+		&MetadataQuery{
+			User: &User{
+				Id: user.ID,
+			},
+			FullPath: fullpath,
+			Key:      key,
+			Value:    value,
+		},
+	)
+	if err != nil {
+		return fromStatusError(err)
+	}
+	return fromError(reply)
+}
+
+func (s *GrpcStorage) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	reply, err := s.Client.ListMetadata(ctx,
+		&StatQuery{
+			User: &User{
+				Id: user.ID,
+			},
+			FullPath: fullpath,
+		},
+	)
+	if err != nil {
+		return nil, fromStatusError(err)
+	}
+	err = fromError(reply.GetError())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(reply.GetMetadata()))
+	for _, metadata := range reply.GetMetadata() {
+		out[metadata.GetKey()] = metadata.GetValue()
+	}
+	return out, nil
+}
+
+func (s *GrpcStorage) AtomicWrites() bool {
+	return s.atomicWrites
+}
@@ -0,0 +1,109 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errorCodes = map[ErrorCode]error{
+	ErrorCode_NOT_EXIST:      storage.ErrNotExist,
+	ErrorCode_EXIST:          storage.ErrExist,
+	ErrorCode_NOT_DIRECTORY:  storage.ErrNotDirectory,
+	ErrorCode_READ_ONLY:      storage.ErrReadOnly,
+	ErrorCode_QUOTA_EXCEEDED: storage.ErrQuotaExceeded,
+	ErrorCode_INVALID_PATH:   storage.ErrInvalidPath,
+	ErrorCode_NOT_EMPTY:      storage.ErrNotEmpty,
+	ErrorCode_NOT_SUPPORTED:  storage.ErrNotSupported,
+}
+
+var eventTypes = map[storage.EventType]EventType{
+	storage.EventCreate: EventType_CREATE,
+	storage.EventModify: EventType_MODIFY,
+	storage.EventMove:   EventType_MOVE,
+	storage.EventDelete: EventType_DELETE,
+}
+
+var sortKeys = map[storage.SortKey]SortKey{
+	storage.SortNone:    SortKey_SORT_NONE,
+	storage.SortName:    SortKey_SORT_NAME,
+	storage.SortSize:    SortKey_SORT_SIZE,
+	storage.SortCreated: SortKey_SORT_CREATED,
+	storage.SortUpdated: SortKey_SORT_UPDATED,
+}
+
+// codedError is what we rebuild on the client side out of an Error with a known code, it keeps the
+// original message of the plugin while errors.Is still matches on the storage error
+type codedError struct {
+	message string
+	err     error
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func toErrorCode(err error) ErrorCode {
+	for code, target := range errorCodes {
+		if errors.Is(err, target) {
+			return code
+		}
+	}
+	return ErrorCode_UNKNOWN
+}
+
+func fromError(err *Error) error {
+	if err == nil || (err.GetMessage() == "" && err.GetCode() == ErrorCode_UNKNOWN) {
+		return nil
+	}
+
+	target, ok := errorCodes[err.GetCode()]
+	if !ok {
+		return errors.New(err.GetMessage())
+	}
+	if err.GetMessage() == "" || err.GetMessage() == target.Error() {
+		return target
+	}
+	return &codedError{
+		message: err.GetMessage(),
+		err:     target,
+	}
+}
+
+// toStatusError is used by the streaming calls that have no reply of their own to put an Error in,
+// instead we attach it to the grpc status as a detail
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, detailErr := status.New(codes.Unknown, err.Error()).WithDetails(toError(err))
+	if detailErr != nil {
+		return err
+	}
+	return st.Err()
+}
+
+// fromStatusError is the client side counterpart of toStatusError
+func fromStatusError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	// plugins built before a call was added don't know about it at all
+	if st.Code() == codes.Unimplemented {
+		return fmt.Errorf("%w: %s", storage.ErrNotSupported, st.Message())
+	}
+	for _, detail := range st.Details() {
+		if e, ok := detail.(*Error); ok {
+			return fromError(e)
+		}
+	}
+	return err
+}
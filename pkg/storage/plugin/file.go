@@ -13,8 +13,14 @@ type File struct {
 
 	readbuf bytes.Buffer
 	reader  StorageProvider_ReadFileClient
+	cancel  context.CancelFunc
 	isEOF   bool
 	writing bool
+
+	// once we've been seeked we keep track of the position ourselves and
+	// switch over to ranged reads and writes
+	ranged bool
+	pos    int64
 }
 
 func (f *File) Read(p []byte) (int, error) {
@@ -22,20 +28,31 @@ func (f *File) Read(p []byte) (int, error) {
 		return 0, errors.New("File is already opened in write mode")
 	}
 
+	n, err := f.read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *File) read(p []byte) (int, error) {
 	if f.isEOF {
 		return f.readTilEOF(p)
 	}
 
 	if f.reader == nil {
-		reader, err := f.Storage.Client.ReadFile(context.Background(),
+		ctx, cancel := context.WithCancel(context.Background())
+		reader, err := f.Storage.Client.ReadFile(ctx,
 			&ReadFileQuery{
-				Id: f.Id,
+				Id:     f.Id,
+				Ranged: f.ranged,
+				Offset: f.pos,
 			},
 		)
 		if err != nil {
+			cancel()
 			return 0, err
 		}
 		f.reader = reader
+		f.cancel = cancel
 	}
 
 	// while our buffer doesn't have enough data left we read from the stream first
@@ -44,8 +61,8 @@ func (f *File) Read(p []byte) (int, error) {
 		if err != nil {
 			return 0, err
 		}
-		if reply.GetError() != nil && reply.GetError().GetMessage() != "" {
-			return 0, errors.New(reply.GetError().GetMessage())
+		if err := fromError(reply.GetError()); err != nil {
+			return 0, err
 		}
 
 		_, err = f.readbuf.Write(reply.Data)
@@ -74,11 +91,34 @@ func (f *File) readTilEOF(p []byte) (int, error) {
 	return 0, io.EOF
 }
 
-func (f *File) Close() (err error) {
+// stops the current read stream, if any, and throws away whatever we buffered from it
+func (f *File) resetReader() {
+	if f.cancel != nil {
+		f.cancel()
+		f.cancel = nil
+	}
+	f.reader = nil
+	f.readbuf.Reset()
+	f.isEOF = false
+}
+
+func (f *File) Close() error {
+	return f.close(false)
+}
+
+// Abort throws away what was written, as far as the storage of the plugin supports that
+func (f *File) Abort() error {
+	return f.close(true)
+}
+
+func (f *File) close(abort bool) (err error) {
 	if f.reader != nil {
 		err = f.reader.CloseSend()
 	}
-	err2 := f.Storage.closeFile(f.Id)
+	if f.cancel != nil {
+		f.cancel()
+	}
+	err2 := f.Storage.closeFile(f.Id, abort)
 	if err != nil {
 		return err
 	} else if err2 != nil {
@@ -94,18 +134,111 @@ func (f *File) Write(p []byte) (int, error) {
 
 	f.writing = true
 
+	n, err := f.write(p, f.ranged, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *File) write(p []byte, ranged bool, offset int64) (int, error) {
 	query := &WriteFileQuery{
-		Id:   f.Id,
-		Data: p,
+		Id:     f.Id,
+		Data:   p,
+		Ranged: ranged,
+		Offset: offset,
 	}
 
 	reply, err := f.Storage.Client.WriteFile(context.TODO(), query)
 	if err != nil {
 		return 0, err
 	}
-	if reply.GetError().GetMessage() != "" {
-		return 0, errors.New(reply.GetError().GetMessage())
+	if err := fromError(reply.GetError()); err != nil {
+		return 0, err
 	}
 
 	return int(reply.SizeWritten), nil
 }
+
+// seekableFile is what we return in case the plugin told us the opened file supports random access
+type seekableFile struct {
+	*File
+}
+
+func (f *seekableFile) ReadAt(p []byte, off int64) (int, error) {
+	// we stop reading once p is full, cancelling makes sure the stream doesn't stay open on either side
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reader, err := f.Storage.Client.ReadFile(ctx,
+		&ReadFileQuery{
+			Id:     f.Id,
+			Ranged: true,
+			Offset: off,
+			Length: int64(len(p)),
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for n < len(p) {
+		reply, err := reader.Recv()
+		if err != nil {
+			return n, err
+		}
+		if err := fromError(reply.GetError()); err != nil {
+			return n, err
+		}
+
+		n += copy(p[n:], reply.Data)
+
+		if reply.GetEOF() {
+			break
+		}
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *seekableFile) WriteAt(p []byte, off int64) (int, error) {
+	return f.write(p, true, off)
+}
+
+func (f *seekableFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		// we have no idea how large the file is, so we let the plugin figure this out
+		reply, err := f.Storage.Client.SeekFile(context.TODO(),
+			&SeekFileQuery{
+				Id:     f.Id,
+				Offset: offset,
+				Whence: int32(whence),
+			},
+		)
+		if err != nil {
+			return 0, err
+		}
+		if err := fromError(reply.GetError()); err != nil {
+			return 0, err
+		}
+		offset = reply.GetOffset()
+	default:
+		return 0, errors.New("Invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("Negative position")
+	}
+
+	f.resetReader()
+	f.ranged = true
+	f.pos = offset
+
+	return offset, nil
+}
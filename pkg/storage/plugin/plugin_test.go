@@ -0,0 +1,139 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// setupGrpcStorage runs the bridge around provider in memory and returns a client connected to it
+func setupGrpcStorage(t *testing.T, provider storage.StorageProvider) *GrpcStorage {
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(1024 * 1024 * 128),
+	)
+	RegisterStorageProviderServer(server, NewStorageBridge(provider))
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewGrpcStorage(conn, map[interface{}]interface{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		assert.NoError(t, store.Close())
+		assert.NoError(t, conn.Close())
+	})
+
+	return store
+}
+
+func TestGrpcStorage(t *testing.T) {
+	store := setupGrpcStorage(t, local.NewStorageProvider(t.TempDir()))
+
+	storage.TestStorageProvider(store, t)
+}
+
+func TestGrpcStorageErrors(t *testing.T) {
+	user := &models.User{ID: 1}
+	ctx := context.Background()
+
+	store := setupGrpcStorage(t, local.NewStorageProvider(t.TempDir()))
+	assert.NoError(t, store.InitUser(ctx, user))
+
+	_, err := store.Stat(ctx, user, "/missing")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+	assert.Contains(t, err.Error(), "/missing")
+
+	_, err = store.ListDirectory(ctx, user, "/missing")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	assert.NoError(t, store.Mkdir(ctx, user, "/dir"))
+	err = store.Move(ctx, user, "/missing", "/dir/missing")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	readonly := setupGrpcStorage(t, storage.ReadOnly(memory.NewStorageProvider()))
+	assert.ErrorIs(t, readonly.Mkdir(ctx, user, "/dir"), storage.ErrReadOnly)
+
+	for code, target := range errorCodes {
+		err := fromError(toError(fmt.Errorf("%w: /some/path", target)))
+		assert.ErrorIs(t, err, target, code.String())
+		assert.Equal(t, target.Error()+": /some/path", err.Error())
+	}
+}
+
+func TestGrpcWatch(t *testing.T) {
+	user := &models.User{ID: 1}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := setupGrpcStorage(t, memory.NewStorageProvider())
+	_, err := store.Watch(ctx, user)
+	assert.ErrorIs(t, err, storage.ErrNotSupported)
+
+	provider := local.NewStorageProvider(t.TempDir())
+	store = setupGrpcStorage(t, provider)
+	assert.NoError(t, store.InitUser(ctx, user))
+
+	events, err := storage.Watch(ctx, store, user)
+	if errors.Is(err, storage.ErrNotSupported) {
+		t.Skip("The local provider can't watch for changes on this platform")
+	} else if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, store.Mkdir(ctx, user, "/dir"))
+	assert.NoError(t, store.Move(ctx, user, "/dir", "/moved"))
+
+	expected := []storage.Event{
+		{Type: storage.EventCreate, Path: "/dir", Directory: true},
+		{Type: storage.EventMove, Path: "/moved", OldPath: "/dir", Directory: true},
+	}
+	for _, event := range expected {
+		select {
+		case got := <-events:
+			assert.Equal(t, event, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Now()
+	assert.True(t, now.Equal(fromTimestamp(toTimestamp(now))), "nanoseconds should survive")
+	assert.True(t, fromTimestamp(toTimestamp(time.Time{})).IsZero())
+
+	seconds, nanos := toTimestamp(time.Time{})
+	assert.Equal(t, uint64(0), seconds)
+	assert.Equal(t, uint32(0), nanos)
+}
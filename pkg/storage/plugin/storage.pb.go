@@ -20,6 +20,182 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Maps to the errors defined in pkg/storage/errors.go, so the client can rebuild them
+type ErrorCode int32
+
+const (
+	ErrorCode_UNKNOWN        ErrorCode = 0
+	ErrorCode_NOT_EXIST      ErrorCode = 1
+	ErrorCode_EXIST          ErrorCode = 2
+	ErrorCode_NOT_DIRECTORY  ErrorCode = 3
+	ErrorCode_READ_ONLY      ErrorCode = 4
+	ErrorCode_QUOTA_EXCEEDED ErrorCode = 5
+	ErrorCode_INVALID_PATH   ErrorCode = 6
+	ErrorCode_NOT_EMPTY      ErrorCode = 7
+	ErrorCode_NOT_SUPPORTED  ErrorCode = 8
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "NOT_EXIST",
+		2: "EXIST",
+		3: "NOT_DIRECTORY",
+		4: "READ_ONLY",
+		5: "QUOTA_EXCEEDED",
+		6: "INVALID_PATH",
+		7: "NOT_EMPTY",
+		8: "NOT_SUPPORTED",
+	}
+	ErrorCode_value = map[string]int32{
+		"UNKNOWN":        0,
+		"NOT_EXIST":      1,
+		"EXIST":          2,
+		"NOT_DIRECTORY":  3,
+		"READ_ONLY":      4,
+		"QUOTA_EXCEEDED": 5,
+		"INVALID_PATH":   6,
+		"NOT_EMPTY":      7,
+		"NOT_SUPPORTED":  8,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_storage_proto_enumTypes[0].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_storage_proto_enumTypes[0]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{0}
+}
+
+type SortKey int32
+
+const (
+	SortKey_SORT_NONE    SortKey = 0
+	SortKey_SORT_NAME    SortKey = 1
+	SortKey_SORT_SIZE    SortKey = 2
+	SortKey_SORT_CREATED SortKey = 3
+	SortKey_SORT_UPDATED SortKey = 4
+)
+
+// Enum value maps for SortKey.
+var (
+	SortKey_name = map[int32]string{
+		0: "SORT_NONE",
+		1: "SORT_NAME",
+		2: "SORT_SIZE",
+		3: "SORT_CREATED",
+		4: "SORT_UPDATED",
+	}
+	SortKey_value = map[string]int32{
+		"SORT_NONE":    0,
+		"SORT_NAME":    1,
+		"SORT_SIZE":    2,
+		"SORT_CREATED": 3,
+		"SORT_UPDATED": 4,
+	}
+)
+
+func (x SortKey) Enum() *SortKey {
+	p := new(SortKey)
+	*p = x
+	return p
+}
+
+func (x SortKey) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortKey) Descriptor() protoreflect.EnumDescriptor {
+	return file_storage_proto_enumTypes[1].Descriptor()
+}
+
+func (SortKey) Type() protoreflect.EnumType {
+	return &file_storage_proto_enumTypes[1]
+}
+
+func (x SortKey) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortKey.Descriptor instead.
+func (SortKey) EnumDescriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{1}
+}
+
+// Maps to the EventType values in pkg/storage/watch.go
+type EventType int32
+
+const (
+	EventType_CREATE EventType = 0
+	EventType_MODIFY EventType = 1
+	EventType_MOVE   EventType = 2
+	EventType_DELETE EventType = 3
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "CREATE",
+		1: "MODIFY",
+		2: "MOVE",
+		3: "DELETE",
+	}
+	EventType_value = map[string]int32{
+		"CREATE": 0,
+		"MODIFY": 1,
+		"MOVE":   2,
+		"DELETE": 3,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_storage_proto_enumTypes[2].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_storage_proto_enumTypes[2]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{2}
+}
+
 type ConfigData struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -67,18 +243,27 @@ func (x *ConfigData) GetYaml() []byte {
 	return nil
 }
 
-// This basically maps to the FileInfo struct
+// This basically maps to the FileInfo struct.
+// Timestamps are split up like google.protobuf.Timestamp: createdAt and updatedAt hold the seconds since the
+// unix epoch in UTC, the nanos fields the fraction of a second on top of that. 0 seconds means unknown
 type FileInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name      string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	FullPath  string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
-	CreatedAt uint64 `protobuf:"varint,3,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
-	UpdatedAt uint64 `protobuf:"varint,4,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
-	Size      uint64 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
-	Directory bool   `protobuf:"varint,6,opt,name=directory,proto3" json:"directory,omitempty"`
+	Name           string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	FullPath       string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
+	CreatedAt      uint64 `protobuf:"varint,3,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	UpdatedAt      uint64 `protobuf:"varint,4,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	Size           uint64 `protobuf:"varint,5,opt,name=size,proto3" json:"size,omitempty"`
+	Directory      bool   `protobuf:"varint,6,opt,name=directory,proto3" json:"directory,omitempty"`
+	CreatedAtNanos uint32 `protobuf:"varint,7,opt,name=createdAtNanos,proto3" json:"createdAtNanos,omitempty"`
+	UpdatedAtNanos uint32 `protobuf:"varint,8,opt,name=updatedAtNanos,proto3" json:"updatedAtNanos,omitempty"`
+	// The unix permission bits, 0 if the storage has no notion of permissions
+	Mode uint32 `protobuf:"varint,9,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Changes whenever the contents do, empty if the storage can't tell
+	Etag    string `protobuf:"bytes,10,opt,name=etag,proto3" json:"etag,omitempty"`
+	Symlink bool   `protobuf:"varint,11,opt,name=symlink,proto3" json:"symlink,omitempty"`
 }
 
 func (x *FileInfo) Reset() {
@@ -155,6 +340,41 @@ func (x *FileInfo) GetDirectory() bool {
 	return false
 }
 
+func (x *FileInfo) GetCreatedAtNanos() uint32 {
+	if x != nil {
+		return x.CreatedAtNanos
+	}
+	return 0
+}
+
+func (x *FileInfo) GetUpdatedAtNanos() uint32 {
+	if x != nil {
+		return x.UpdatedAtNanos
+	}
+	return 0
+}
+
+func (x *FileInfo) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *FileInfo) GetEtag() string {
+	if x != nil {
+		return x.Etag
+	}
+	return ""
+}
+
+func (x *FileInfo) GetSymlink() bool {
+	if x != nil {
+		return x.Symlink
+	}
+	return false
+}
+
 // This sort of maps to the User struct, although we only give the id as that should be the only relevant thing.
 type User struct {
 	state         protoimpl.MessageState
@@ -208,7 +428,8 @@ type Error struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Message string `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Message string    `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Code    ErrorCode `protobuf:"varint,2,opt,name=code,proto3,enum=leichtcloud.storage.plugin.ErrorCode" json:"code,omitempty"`
 }
 
 func (x *Error) Reset() {
@@ -250,6 +471,13 @@ func (x *Error) GetMessage() string {
 	return ""
 }
 
+func (x *Error) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_UNKNOWN
+}
+
 type MkdirQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -368,17 +596,18 @@ func (x *MoveQuery) GetDst() string {
 	return ""
 }
 
-type ListDirectoryQuery struct {
+type CopyQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
 	User *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	Path string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Src  string `protobuf:"bytes,2,opt,name=src,proto3" json:"src,omitempty"`
+	Dst  string `protobuf:"bytes,3,opt,name=dst,proto3" json:"dst,omitempty"`
 }
 
-func (x *ListDirectoryQuery) Reset() {
-	*x = ListDirectoryQuery{}
+func (x *CopyQuery) Reset() {
+	*x = CopyQuery{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -386,13 +615,13 @@ func (x *ListDirectoryQuery) Reset() {
 	}
 }
 
-func (x *ListDirectoryQuery) String() string {
+func (x *CopyQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListDirectoryQuery) ProtoMessage() {}
+func (*CopyQuery) ProtoMessage() {}
 
-func (x *ListDirectoryQuery) ProtoReflect() protoreflect.Message {
+func (x *CopyQuery) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -404,36 +633,48 @@ func (x *ListDirectoryQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListDirectoryQuery.ProtoReflect.Descriptor instead.
-func (*ListDirectoryQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use CopyQuery.ProtoReflect.Descriptor instead.
+func (*CopyQuery) Descriptor() ([]byte, []int) {
 	return file_storage_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ListDirectoryQuery) GetUser() *User {
+func (x *CopyQuery) GetUser() *User {
 	if x != nil {
 		return x.User
 	}
 	return nil
 }
 
-func (x *ListDirectoryQuery) GetPath() string {
+func (x *CopyQuery) GetSrc() string {
 	if x != nil {
-		return x.Path
+		return x.Src
 	}
 	return ""
 }
 
-type OpenFileQuery struct {
+func (x *CopyQuery) GetDst() string {
+	if x != nil {
+		return x.Dst
+	}
+	return ""
+}
+
+type ListDirectoryQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
+	User       *User   `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Path       string  `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Sort       SortKey `protobuf:"varint,3,opt,name=sort,proto3,enum=leichtcloud.storage.plugin.SortKey" json:"sort,omitempty"`
+	Descending bool    `protobuf:"varint,4,opt,name=descending,proto3" json:"descending,omitempty"`
+	Cursor     string  `protobuf:"bytes,5,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Limit      int32   `protobuf:"varint,6,opt,name=limit,proto3" json:"limit,omitempty"`
+	Filter     string  `protobuf:"bytes,7,opt,name=filter,proto3" json:"filter,omitempty"`
 }
 
-func (x *OpenFileQuery) Reset() {
-	*x = OpenFileQuery{}
+func (x *ListDirectoryQuery) Reset() {
+	*x = ListDirectoryQuery{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -441,13 +682,13 @@ func (x *OpenFileQuery) Reset() {
 	}
 }
 
-func (x *OpenFileQuery) String() string {
+func (x *ListDirectoryQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OpenFileQuery) ProtoMessage() {}
+func (*ListDirectoryQuery) ProtoMessage() {}
 
-func (x *OpenFileQuery) ProtoReflect() protoreflect.Message {
+func (x *ListDirectoryQuery) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -459,36 +700,71 @@ func (x *OpenFileQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OpenFileQuery.ProtoReflect.Descriptor instead.
-func (*OpenFileQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListDirectoryQuery.ProtoReflect.Descriptor instead.
+func (*ListDirectoryQuery) Descriptor() ([]byte, []int) {
 	return file_storage_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *OpenFileQuery) GetUser() *User {
+func (x *ListDirectoryQuery) GetUser() *User {
 	if x != nil {
 		return x.User
 	}
 	return nil
 }
 
-func (x *OpenFileQuery) GetFullPath() string {
+func (x *ListDirectoryQuery) GetPath() string {
 	if x != nil {
-		return x.FullPath
+		return x.Path
 	}
 	return ""
 }
 
-type OpenFileReply struct {
+func (x *ListDirectoryQuery) GetSort() SortKey {
+	if x != nil {
+		return x.Sort
+	}
+	return SortKey_SORT_NONE
+}
+
+func (x *ListDirectoryQuery) GetDescending() bool {
+	if x != nil {
+		return x.Descending
+	}
+	return false
+}
+
+func (x *ListDirectoryQuery) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListDirectoryQuery) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListDirectoryQuery) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+type StatQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id    int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Error *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
 }
 
-func (x *OpenFileReply) Reset() {
-	*x = OpenFileReply{}
+func (x *StatQuery) Reset() {
+	*x = StatQuery{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -496,13 +772,13 @@ func (x *OpenFileReply) Reset() {
 	}
 }
 
-func (x *OpenFileReply) String() string {
+func (x *StatQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*OpenFileReply) ProtoMessage() {}
+func (*StatQuery) ProtoMessage() {}
 
-func (x *OpenFileReply) ProtoReflect() protoreflect.Message {
+func (x *StatQuery) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -514,35 +790,37 @@ func (x *OpenFileReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use OpenFileReply.ProtoReflect.Descriptor instead.
-func (*OpenFileReply) Descriptor() ([]byte, []int) {
+// Deprecated: Use StatQuery.ProtoReflect.Descriptor instead.
+func (*StatQuery) Descriptor() ([]byte, []int) {
 	return file_storage_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *OpenFileReply) GetId() int32 {
+func (x *StatQuery) GetUser() *User {
 	if x != nil {
-		return x.Id
+		return x.User
 	}
-	return 0
+	return nil
 }
 
-func (x *OpenFileReply) GetError() *Error {
+func (x *StatQuery) GetFullPath() string {
 	if x != nil {
-		return x.Error
+		return x.FullPath
 	}
-	return nil
+	return ""
 }
 
-type CloseFileQuery struct {
+// If the requested file doesn't exist error will have the NOT_EXIST code
+type StatReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Info  *FileInfo `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Error *Error    `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
 }
 
-func (x *CloseFileQuery) Reset() {
-	*x = CloseFileQuery{}
+func (x *StatReply) Reset() {
+	*x = StatReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -550,13 +828,13 @@ func (x *CloseFileQuery) Reset() {
 	}
 }
 
-func (x *CloseFileQuery) String() string {
+func (x *StatReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CloseFileQuery) ProtoMessage() {}
+func (*StatReply) ProtoMessage() {}
 
-func (x *CloseFileQuery) ProtoReflect() protoreflect.Message {
+func (x *StatReply) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -568,29 +846,36 @@ func (x *CloseFileQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CloseFileQuery.ProtoReflect.Descriptor instead.
-func (*CloseFileQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use StatReply.ProtoReflect.Descriptor instead.
+func (*StatReply) Descriptor() ([]byte, []int) {
 	return file_storage_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *CloseFileQuery) GetId() int32 {
+func (x *StatReply) GetInfo() *FileInfo {
 	if x != nil {
-		return x.Id
+		return x.Info
 	}
-	return 0
+	return nil
 }
 
-type WriteFileQuery struct {
+func (x *StatReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type OpenFileQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Id   int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
 }
 
-func (x *WriteFileQuery) Reset() {
-	*x = WriteFileQuery{}
+func (x *OpenFileQuery) Reset() {
+	*x = OpenFileQuery{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -598,13 +883,13 @@ func (x *WriteFileQuery) Reset() {
 	}
 }
 
-func (x *WriteFileQuery) String() string {
+func (x *OpenFileQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WriteFileQuery) ProtoMessage() {}
+func (*OpenFileQuery) ProtoMessage() {}
 
-func (x *WriteFileQuery) ProtoReflect() protoreflect.Message {
+func (x *OpenFileQuery) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -616,36 +901,39 @@ func (x *WriteFileQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WriteFileQuery.ProtoReflect.Descriptor instead.
-func (*WriteFileQuery) Descriptor() ([]byte, []int) {
+// Deprecated: Use OpenFileQuery.ProtoReflect.Descriptor instead.
+func (*OpenFileQuery) Descriptor() ([]byte, []int) {
 	return file_storage_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *WriteFileQuery) GetId() int32 {
+func (x *OpenFileQuery) GetUser() *User {
 	if x != nil {
-		return x.Id
+		return x.User
 	}
-	return 0
+	return nil
 }
 
-func (x *WriteFileQuery) GetData() []byte {
+func (x *OpenFileQuery) GetFullPath() string {
 	if x != nil {
-		return x.Data
+		return x.FullPath
 	}
-	return nil
+	return ""
 }
 
-type WriteFileReply struct {
+type OpenFileReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	SizeWritten int32  `protobuf:"varint,1,opt,name=size_written,json=sizeWritten,proto3" json:"size_written,omitempty"`
-	Error       *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Id    int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Error *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Whether the opened file supports random access, meaning SeekFile
+	// and the offset fields of ReadFile and WriteFile can be used
+	Seekable bool `protobuf:"varint,3,opt,name=seekable,proto3" json:"seekable,omitempty"`
 }
 
-func (x *WriteFileReply) Reset() {
-	*x = WriteFileReply{}
+func (x *OpenFileReply) Reset() {
+	*x = OpenFileReply{}
 	if protoimpl.UnsafeEnabled {
 		mi := &file_storage_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -653,13 +941,13 @@ func (x *WriteFileReply) Reset() {
 	}
 }
 
-func (x *WriteFileReply) String() string {
+func (x *OpenFileReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WriteFileReply) ProtoMessage() {}
+func (*OpenFileReply) ProtoMessage() {}
 
-func (x *WriteFileReply) ProtoReflect() protoreflect.Message {
+func (x *OpenFileReply) ProtoReflect() protoreflect.Message {
 	mi := &file_storage_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -671,9 +959,199 @@ func (x *WriteFileReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WriteFileReply.ProtoReflect.Descriptor instead.
-func (*WriteFileReply) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use OpenFileReply.ProtoReflect.Descriptor instead.
+func (*OpenFileReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *OpenFileReply) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *OpenFileReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *OpenFileReply) GetSeekable() bool {
+	if x != nil {
+		return x.Seekable
+	}
+	return false
+}
+
+type CloseFileQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Throw away everything written to the file instead of keeping it, see storage.Aborter
+	Abort bool `protobuf:"varint,2,opt,name=abort,proto3" json:"abort,omitempty"`
+}
+
+func (x *CloseFileQuery) Reset() {
+	*x = CloseFileQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CloseFileQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseFileQuery) ProtoMessage() {}
+
+func (x *CloseFileQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseFileQuery.ProtoReflect.Descriptor instead.
+func (*CloseFileQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *CloseFileQuery) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *CloseFileQuery) GetAbort() bool {
+	if x != nil {
+		return x.Abort
+	}
+	return false
+}
+
+type WriteFileQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// If ranged is set, data is written at offset rather than at the current position
+	Ranged bool  `protobuf:"varint,3,opt,name=ranged,proto3" json:"ranged,omitempty"`
+	Offset int64 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (x *WriteFileQuery) Reset() {
+	*x = WriteFileQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WriteFileQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteFileQuery) ProtoMessage() {}
+
+func (x *WriteFileQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteFileQuery.ProtoReflect.Descriptor instead.
+func (*WriteFileQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WriteFileQuery) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *WriteFileQuery) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *WriteFileQuery) GetRanged() bool {
+	if x != nil {
+		return x.Ranged
+	}
+	return false
+}
+
+func (x *WriteFileQuery) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type WriteFileReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SizeWritten int32  `protobuf:"varint,1,opt,name=size_written,json=sizeWritten,proto3" json:"size_written,omitempty"`
+	Error       *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *WriteFileReply) Reset() {
+	*x = WriteFileReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *WriteFileReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteFileReply) ProtoMessage() {}
+
+func (x *WriteFileReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteFileReply.ProtoReflect.Descriptor instead.
+func (*WriteFileReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *WriteFileReply) GetSizeWritten() int32 {
@@ -696,12 +1174,17 @@ type ReadFileQuery struct {
 	unknownFields protoimpl.UnknownFields
 
 	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// If ranged is set, we read starting at offset rather than from the current position.
+	// A length of 0 means we read until the end of the file
+	Ranged bool  `protobuf:"varint,2,opt,name=ranged,proto3" json:"ranged,omitempty"`
+	Offset int64 `protobuf:"varint,3,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length int64 `protobuf:"varint,4,opt,name=length,proto3" json:"length,omitempty"`
 }
 
 func (x *ReadFileQuery) Reset() {
 	*x = ReadFileQuery{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_storage_proto_msgTypes[12]
+		mi := &file_storage_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -714,7 +1197,7 @@ func (x *ReadFileQuery) String() string {
 func (*ReadFileQuery) ProtoMessage() {}
 
 func (x *ReadFileQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[12]
+	mi := &file_storage_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -727,43 +1210,606 @@ func (x *ReadFileQuery) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReadFileQuery.ProtoReflect.Descriptor instead.
 func (*ReadFileQuery) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{12}
+	return file_storage_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ReadFileQuery) GetId() int32 {
 	if x != nil {
 		return x.Id
 	}
-	return 0
+	return 0
+}
+
+func (x *ReadFileQuery) GetRanged() bool {
+	if x != nil {
+		return x.Ranged
+	}
+	return false
+}
+
+func (x *ReadFileQuery) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ReadFileQuery) GetLength() int64 {
+	if x != nil {
+		return x.Length
+	}
+	return 0
+}
+
+type ReadFileReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data  []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	EOF   bool   `protobuf:"varint,2,opt,name=EOF,proto3" json:"EOF,omitempty"`
+	Error *Error `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ReadFileReply) Reset() {
+	*x = ReadFileReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReadFileReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadFileReply) ProtoMessage() {}
+
+func (x *ReadFileReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadFileReply.ProtoReflect.Descriptor instead.
+func (*ReadFileReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReadFileReply) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ReadFileReply) GetEOF() bool {
+	if x != nil {
+		return x.EOF
+	}
+	return false
+}
+
+func (x *ReadFileReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type SeekFileQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id     int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Offset int64 `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	// Same values as io.SeekStart, io.SeekCurrent and io.SeekEnd
+	Whence int32 `protobuf:"varint,3,opt,name=whence,proto3" json:"whence,omitempty"`
+}
+
+func (x *SeekFileQuery) Reset() {
+	*x = SeekFileQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SeekFileQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekFileQuery) ProtoMessage() {}
+
+func (x *SeekFileQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekFileQuery.ProtoReflect.Descriptor instead.
+func (*SeekFileQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SeekFileQuery) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SeekFileQuery) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SeekFileQuery) GetWhence() int32 {
+	if x != nil {
+		return x.Whence
+	}
+	return 0
+}
+
+type SeekFileReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Offset int64  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Error  *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *SeekFileReply) Reset() {
+	*x = SeekFileReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SeekFileReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SeekFileReply) ProtoMessage() {}
+
+func (x *SeekFileReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SeekFileReply.ProtoReflect.Descriptor instead.
+func (*SeekFileReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SeekFileReply) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SeekFileReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type DeleteQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
+}
+
+func (x *DeleteQuery) Reset() {
+	*x = DeleteQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteQuery) ProtoMessage() {}
+
+func (x *DeleteQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteQuery.ProtoReflect.Descriptor instead.
+func (*DeleteQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteQuery) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *DeleteQuery) GetFullPath() string {
+	if x != nil {
+		return x.FullPath
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type EventType `protobuf:"varint,1,opt,name=type,proto3,enum=leichtcloud.storage.plugin.EventType" json:"type,omitempty"`
+	Path string    `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	// Only set for MOVE
+	OldPath   string `protobuf:"bytes,3,opt,name=oldPath,proto3" json:"oldPath,omitempty"`
+	Directory bool   `protobuf:"varint,4,opt,name=directory,proto3" json:"directory,omitempty"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_CREATE
+}
+
+func (x *Event) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Event) GetOldPath() string {
+	if x != nil {
+		return x.OldPath
+	}
+	return ""
+}
+
+func (x *Event) GetDirectory() bool {
+	if x != nil {
+		return x.Directory
+	}
+	return false
+}
+
+// value is only used by SetMetadata, where an empty value removes the key
+type MetadataQuery struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
+	Key      string `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value    string `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *MetadataQuery) Reset() {
+	*x = MetadataQuery{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetadataQuery) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataQuery) ProtoMessage() {}
+
+func (x *MetadataQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataQuery.ProtoReflect.Descriptor instead.
+func (*MetadataQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MetadataQuery) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *MetadataQuery) GetFullPath() string {
+	if x != nil {
+		return x.FullPath
+	}
+	return ""
+}
+
+func (x *MetadataQuery) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *MetadataQuery) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type MetadataReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Value string `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Error *Error `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *MetadataReply) Reset() {
+	*x = MetadataReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetadataReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataReply) ProtoMessage() {}
+
+func (x *MetadataReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataReply.ProtoReflect.Descriptor instead.
+func (*MetadataReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *MetadataReply) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+func (x *MetadataReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+type Metadata struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Key   string `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *Metadata) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Metadata) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type ListMetadataReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Metadata []*Metadata `protobuf:"bytes,1,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	Error    *Error      `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ListMetadataReply) Reset() {
+	*x = ListMetadataReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storage_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListMetadataReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMetadataReply) ProtoMessage() {}
+
+func (x *ListMetadataReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMetadataReply.ProtoReflect.Descriptor instead.
+func (*ListMetadataReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListMetadataReply) GetMetadata() []*Metadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ListMetadataReply) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
 }
 
-type ReadFileReply struct {
+type AtomicWritesQuery struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	Data  []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	EOF   bool   `protobuf:"varint,2,opt,name=EOF,proto3" json:"EOF,omitempty"`
-	Error *Error `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
 }
 
-func (x *ReadFileReply) Reset() {
-	*x = ReadFileReply{}
+func (x *AtomicWritesQuery) Reset() {
+	*x = AtomicWritesQuery{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_storage_proto_msgTypes[13]
+		mi := &file_storage_proto_msgTypes[25]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ReadFileReply) String() string {
+func (x *AtomicWritesQuery) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ReadFileReply) ProtoMessage() {}
+func (*AtomicWritesQuery) ProtoMessage() {}
 
-func (x *ReadFileReply) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[13]
+func (x *AtomicWritesQuery) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[25]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -774,58 +1820,36 @@ func (x *ReadFileReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ReadFileReply.ProtoReflect.Descriptor instead.
-func (*ReadFileReply) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{13}
-}
-
-func (x *ReadFileReply) GetData() []byte {
-	if x != nil {
-		return x.Data
-	}
-	return nil
-}
-
-func (x *ReadFileReply) GetEOF() bool {
-	if x != nil {
-		return x.EOF
-	}
-	return false
-}
-
-func (x *ReadFileReply) GetError() *Error {
-	if x != nil {
-		return x.Error
-	}
-	return nil
+// Deprecated: Use AtomicWritesQuery.ProtoReflect.Descriptor instead.
+func (*AtomicWritesQuery) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{25}
 }
 
-type DeleteQuery struct {
+type AtomicWritesReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	User     *User  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
-	FullPath string `protobuf:"bytes,2,opt,name=fullPath,proto3" json:"fullPath,omitempty"`
+	Atomic bool `protobuf:"varint,1,opt,name=atomic,proto3" json:"atomic,omitempty"`
 }
 
-func (x *DeleteQuery) Reset() {
-	*x = DeleteQuery{}
+func (x *AtomicWritesReply) Reset() {
+	*x = AtomicWritesReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_storage_proto_msgTypes[14]
+		mi := &file_storage_proto_msgTypes[26]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *DeleteQuery) String() string {
+func (x *AtomicWritesReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteQuery) ProtoMessage() {}
+func (*AtomicWritesReply) ProtoMessage() {}
 
-func (x *DeleteQuery) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[14]
+func (x *AtomicWritesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[26]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -836,23 +1860,16 @@ func (x *DeleteQuery) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteQuery.ProtoReflect.Descriptor instead.
-func (*DeleteQuery) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{14}
-}
-
-func (x *DeleteQuery) GetUser() *User {
-	if x != nil {
-		return x.User
-	}
-	return nil
+// Deprecated: Use AtomicWritesReply.ProtoReflect.Descriptor instead.
+func (*AtomicWritesReply) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{26}
 }
 
-func (x *DeleteQuery) GetFullPath() string {
+func (x *AtomicWritesReply) GetAtomic() bool {
 	if x != nil {
-		return x.FullPath
+		return x.Atomic
 	}
-	return ""
+	return false
 }
 
 var File_storage_proto protoreflect.FileDescriptor
@@ -862,7 +1879,7 @@ var file_storage_proto_rawDesc = []byte{
 	0x1a, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f,
 	0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x22, 0x20, 0x0a, 0x0a, 0x43,
 	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x79, 0x61, 0x6d,
-	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x79, 0x61, 0x6d, 0x6c, 0x22, 0xa8, 0x01,
+	0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x79, 0x61, 0x6d, 0x6c, 0x22, 0xba, 0x02,
 	0x0a, 0x08, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
 	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1a,
 	0x0a, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
@@ -873,59 +1890,124 @@ var file_storage_proto_rawDesc = []byte{
 	0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05,
 	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x69,
 	0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x64,
-	0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x22, 0x16, 0x0a, 0x04, 0x55, 0x73, 0x65, 0x72,
-	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64,
-	0x22, 0x21, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65, 0x73,
-	0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73,
-	0x61, 0x67, 0x65, 0x22, 0x56, 0x0a, 0x0a, 0x4d, 0x6b, 0x64, 0x69, 0x72, 0x51, 0x75, 0x65, 0x72,
-	0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74,
-	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65,
-	0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x65, 0x0a, 0x09, 0x4d,
-	0x6f, 0x76, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63,
-	0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75,
-	0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x10,
-	0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63,
-	0x12, 0x10, 0x0a, 0x03, 0x64, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64,
-	0x73, 0x74, 0x22, 0x5e, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74,
-	0x6f, 0x72, 0x79, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63,
-	0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75,
-	0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x12,
-	0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61,
-	0x74, 0x68, 0x22, 0x61, 0x0a, 0x0d, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x63, 0x72, 0x65, 0x61,
+	0x74, 0x65, 0x64, 0x41, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x0e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73,
+	0x12, 0x26, 0x0a, 0x0e, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74, 0x4e, 0x61, 0x6e,
+	0x6f, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0e, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x6f, 0x64, 0x65,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x65, 0x74, 0x61, 0x67, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x65, 0x74, 0x61, 0x67,
+	0x12, 0x18, 0x0a, 0x07, 0x73, 0x79, 0x6d, 0x6c, 0x69, 0x6e, 0x6b, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x07, 0x73, 0x79, 0x6d, 0x6c, 0x69, 0x6e, 0x6b, 0x22, 0x16, 0x0a, 0x04, 0x55, 0x73,
+	0x65, 0x72, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02,
+	0x69, 0x64, 0x22, 0x5c, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x39, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65,
+	0x22, 0x56, 0x0a, 0x0a, 0x4d, 0x6b, 0x64, 0x69, 0x72, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34,
+	0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c,
+	0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x22, 0x65, 0x0a, 0x09, 0x4d, 0x6f, 0x76, 0x65,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x73,
+	0x72, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x73, 0x72, 0x63, 0x12, 0x10, 0x0a,
+	0x03, 0x64, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64, 0x73, 0x74, 0x22,
+	0x65, 0x0a, 0x09, 0x43, 0x6f, 0x70, 0x79, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04,
+	0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73,
+	0x65, 0x72, 0x12, 0x10, 0x0a, 0x03, 0x73, 0x72, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x73, 0x72, 0x63, 0x12, 0x10, 0x0a, 0x03, 0x64, 0x73, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x64, 0x73, 0x74, 0x22, 0xfd, 0x01, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x44,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a,
+	0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65,
+	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75,
+	0x73, 0x65, 0x72, 0x12, 0x12, 0x0a, 0x04, 0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68, 0x12, 0x37, 0x0a, 0x04, 0x73, 0x6f, 0x72, 0x74, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x23, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c,
+	0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67,
+	0x69, 0x6e, 0x2e, 0x53, 0x6f, 0x72, 0x74, 0x4b, 0x65, 0x79, 0x52, 0x04, 0x73, 0x6f, 0x72, 0x74,
+	0x12, 0x1e, 0x0a, 0x0a, 0x64, 0x65, 0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x0a, 0x64, 0x65, 0x73, 0x63, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67,
+	0x12, 0x16, 0x0a, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x69, 0x6d, 0x69,
+	0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x22, 0x5d, 0x0a, 0x09, 0x53, 0x74, 0x61, 0x74, 0x51, 0x75,
 	0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e,
 	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55,
 	0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6c,
 	0x6c, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6c,
-	0x6c, 0x50, 0x61, 0x74, 0x68, 0x22, 0x58, 0x0a, 0x0d, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c,
-	0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x37, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c,
-	0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67,
-	0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22,
-	0x20, 0x0a, 0x0e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72,
-	0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69,
-	0x64, 0x22, 0x34, 0x0a, 0x0e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75,
-	0x65, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x6c, 0x0a, 0x0e, 0x57, 0x72, 0x69, 0x74, 0x65,
-	0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x69, 0x7a,
-	0x65, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x74, 0x65, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52,
-	0x0b, 0x73, 0x69, 0x7a, 0x65, 0x57, 0x72, 0x69, 0x74, 0x74, 0x65, 0x6e, 0x12, 0x37, 0x0a, 0x05,
+	0x6c, 0x50, 0x61, 0x74, 0x68, 0x22, 0x7e, 0x0a, 0x09, 0x53, 0x74, 0x61, 0x74, 0x52, 0x65, 0x70,
+	0x6c, 0x79, 0x12, 0x38, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x24, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x46, 0x69,
+	0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x12, 0x37, 0x0a, 0x05,
 	0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c, 0x65,
 	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
 	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05,
-	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x1f, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c,
-	0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x22, 0x6e, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69,
-	0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x45,
-	0x4f, 0x46, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x45, 0x4f, 0x46, 0x12, 0x37, 0x0a,
-	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x61, 0x0a, 0x0d, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c,
+	0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x72, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f,
+	0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08,
+	0x66, 0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x66, 0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x22, 0x74, 0x0a, 0x0d, 0x4f, 0x70, 0x65, 0x6e,
+	0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x37, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68,
+	0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70,
+	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x65, 0x6b, 0x61, 0x62, 0x6c, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x73, 0x65, 0x65, 0x6b, 0x61, 0x62, 0x6c, 0x65, 0x22, 0x36,
+	0x0a, 0x0e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64,
+	0x12, 0x14, 0x0a, 0x05, 0x61, 0x62, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
+	0x05, 0x61, 0x62, 0x6f, 0x72, 0x74, 0x22, 0x64, 0x0a, 0x0e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46,
+	0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x61, 0x6e, 0x67, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x61,
+	0x6e, 0x67, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x22, 0x6c, 0x0a, 0x0e,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x21,
+	0x0a, 0x0c, 0x73, 0x69, 0x7a, 0x65, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x74, 0x65, 0x6e, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x73, 0x69, 0x7a, 0x65, 0x57, 0x72, 0x69, 0x74, 0x74, 0x65,
+	0x6e, 0x12, 0x37, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x67, 0x0a, 0x0d, 0x52, 0x65,
+	0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72,
+	0x61, 0x6e, 0x67, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x61, 0x6e,
+	0x67, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x6c,
+	0x65, 0x6e, 0x67, 0x74, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6c, 0x65, 0x6e,
+	0x67, 0x74, 0x68, 0x22, 0x6e, 0x0a, 0x0d, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x12, 0x10, 0x0a, 0x03, 0x45, 0x4f, 0x46, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x03, 0x45, 0x4f, 0x46, 0x12, 0x37, 0x0a, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63,
+	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65, 0x72,
+	0x72, 0x6f, 0x72, 0x22, 0x4f, 0x0a, 0x0d, 0x53, 0x65, 0x65, 0x6b, 0x46, 0x69, 0x6c, 0x65, 0x51,
+	0x75, 0x65, 0x72, 0x79, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x77, 0x68, 0x65, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x77, 0x68,
+	0x65, 0x6e, 0x63, 0x65, 0x22, 0x60, 0x0a, 0x0d, 0x53, 0x65, 0x65, 0x6b, 0x46, 0x69, 0x6c, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x37, 0x0a,
+	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c,
 	0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
 	0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52,
 	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x5f, 0x0a, 0x0b, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
@@ -934,71 +2016,184 @@ var file_storage_proto_rawDesc = []byte{
 	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
 	0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x66,
 	0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x66,
-	0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x32, 0xba, 0x07, 0x0a, 0x0f, 0x53, 0x74, 0x6f, 0x72,
-	0x61, 0x67, 0x65, 0x50, 0x72, 0x6f, 0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x58, 0x0a, 0x09, 0x43,
-	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75, 0x72, 0x65, 0x12, 0x26, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68,
+	0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x22, 0x8e, 0x01, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x39, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04,
+	0x70, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x70, 0x61, 0x74, 0x68,
+	0x12, 0x18, 0x0a, 0x07, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x07, 0x6f, 0x6c, 0x64, 0x50, 0x61, 0x74, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x09, 0x64,
+	0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x22, 0x89, 0x01, 0x0a, 0x0d, 0x4d, 0x65, 0x74,
+	0x61, 0x64, 0x61, 0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x12, 0x34, 0x0a, 0x04, 0x75, 0x73,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68,
 	0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70,
-	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x44, 0x61, 0x74, 0x61,
-	0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
-	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72,
-	0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x51, 0x0a, 0x08, 0x49, 0x6e, 0x69, 0x74, 0x55, 0x73, 0x65,
-	0x72, 0x12, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e,
-	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55,
-	0x73, 0x65, 0x72, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
-	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
-	0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x54, 0x0a, 0x05, 0x4d, 0x6b, 0x44, 0x69,
-	0x72, 0x12, 0x26, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e,
-	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4d,
-	0x6b, 0x64, 0x69, 0x72, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63,
-	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
-	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x52,
-	0x0a, 0x04, 0x4d, 0x6f, 0x76, 0x65, 0x12, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63,
-	0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75,
-	0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x6f, 0x76, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e,
+	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x52, 0x04, 0x75, 0x73, 0x65, 0x72,
+	0x12, 0x1a, 0x0a, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x66, 0x75, 0x6c, 0x6c, 0x50, 0x61, 0x74, 0x68, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x22, 0x5e, 0x0a, 0x0d, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x37, 0x0a, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x22, 0x32, 0x0a, 0x08, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
+	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x8e, 0x01, 0x0a, 0x11, 0x4c, 0x69, 0x73,
+	0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x40,
+	0x0a, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x24, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x65,
+	0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x08, 0x6d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x12, 0x37, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x13, 0x0a, 0x11, 0x41, 0x74, 0x6f,
+	0x6d, 0x69, 0x63, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x51, 0x75, 0x65, 0x72, 0x79, 0x22, 0x2b,
+	0x0a, 0x11, 0x41, 0x74, 0x6f, 0x6d, 0x69, 0x63, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x74, 0x6f, 0x6d, 0x69, 0x63, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x61, 0x74, 0x6f, 0x6d, 0x69, 0x63, 0x2a, 0x9c, 0x01, 0x0a, 0x09,
+	0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b,
+	0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x4f, 0x54, 0x5f, 0x45, 0x58,
+	0x49, 0x53, 0x54, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x45, 0x58, 0x49, 0x53, 0x54, 0x10, 0x02,
+	0x12, 0x11, 0x0a, 0x0d, 0x4e, 0x4f, 0x54, 0x5f, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x4f, 0x52,
+	0x59, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x52, 0x45, 0x41, 0x44, 0x5f, 0x4f, 0x4e, 0x4c, 0x59,
+	0x10, 0x04, 0x12, 0x12, 0x0a, 0x0e, 0x51, 0x55, 0x4f, 0x54, 0x41, 0x5f, 0x45, 0x58, 0x43, 0x45,
+	0x45, 0x44, 0x45, 0x44, 0x10, 0x05, 0x12, 0x10, 0x0a, 0x0c, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49,
+	0x44, 0x5f, 0x50, 0x41, 0x54, 0x48, 0x10, 0x06, 0x12, 0x0d, 0x0a, 0x09, 0x4e, 0x4f, 0x54, 0x5f,
+	0x45, 0x4d, 0x50, 0x54, 0x59, 0x10, 0x07, 0x12, 0x11, 0x0a, 0x0d, 0x4e, 0x4f, 0x54, 0x5f, 0x53,
+	0x55, 0x50, 0x50, 0x4f, 0x52, 0x54, 0x45, 0x44, 0x10, 0x08, 0x2a, 0x5a, 0x0a, 0x07, 0x53, 0x6f,
+	0x72, 0x74, 0x4b, 0x65, 0x79, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x4e, 0x4f,
+	0x4e, 0x45, 0x10, 0x00, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x4e, 0x41, 0x4d,
+	0x45, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x53, 0x49, 0x5a, 0x45,
+	0x10, 0x02, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x43, 0x52, 0x45, 0x41, 0x54,
+	0x45, 0x44, 0x10, 0x03, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x4f, 0x52, 0x54, 0x5f, 0x55, 0x50, 0x44,
+	0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x2a, 0x39, 0x0a, 0x09, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x54,
+	0x79, 0x70, 0x65, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x52, 0x45, 0x41, 0x54, 0x45, 0x10, 0x00, 0x12,
+	0x0a, 0x0a, 0x06, 0x4d, 0x4f, 0x44, 0x49, 0x46, 0x59, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x4d,
+	0x4f, 0x56, 0x45, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x45, 0x4c, 0x45, 0x54, 0x45, 0x10,
+	0x03, 0x32, 0x96, 0x0e, 0x0a, 0x0f, 0x53, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x50, 0x72, 0x6f,
+	0x76, 0x69, 0x64, 0x65, 0x72, 0x12, 0x58, 0x0a, 0x09, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x75,
+	0x72, 0x65, 0x12, 0x26, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x44, 0x61, 0x74, 0x61, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12,
+	0x51, 0x0a, 0x08, 0x49, 0x6e, 0x69, 0x74, 0x55, 0x73, 0x65, 0x72, 0x12, 0x20, 0x2e, 0x6c, 0x65,
+	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x21, 0x2e,
 	0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72,
 	0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72,
-	0x22, 0x00, 0x12, 0x69, 0x0a, 0x0d, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74,
-	0x6f, 0x72, 0x79, 0x12, 0x2e, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x22, 0x00, 0x12, 0x54, 0x0a, 0x05, 0x4d, 0x6b, 0x44, 0x69, 0x72, 0x12, 0x26, 0x2e, 0x6c, 0x65,
+	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x6b, 0x64, 0x69, 0x72, 0x51, 0x75,
+	0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
 	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
-	0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x51, 0x75,
-	0x65, 0x72, 0x79, 0x1a, 0x24, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x04, 0x4d, 0x6f, 0x76, 0x65,
+	0x12, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x6f,
+	0x76, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74,
+	0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c,
+	0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x52, 0x0a, 0x04,
+	0x43, 0x6f, 0x70, 0x79, 0x12, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f,
+	0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
+	0x6e, 0x2e, 0x43, 0x6f, 0x70, 0x79, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65,
+	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00,
+	0x12, 0x69, 0x0a, 0x0d, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x79, 0x12, 0x2e, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x51, 0x75, 0x65, 0x72,
+	0x79, 0x1a, 0x24, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e,
+	0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x46,
+	0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x30, 0x01, 0x12, 0x56, 0x0a, 0x04, 0x53,
+	0x74, 0x61, 0x74, 0x12, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
 	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
-	0x2e, 0x46, 0x69, 0x6c, 0x65, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x30, 0x01, 0x12, 0x62, 0x0a,
-	0x08, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63,
-	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
-	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x51,
-	0x75, 0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x25, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x62, 0x0a, 0x08, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x12,
+	0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74,
+	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4f, 0x70, 0x65,
+	0x6e, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65,
+	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x5c, 0x0a, 0x09, 0x43, 0x6c, 0x6f, 0x73, 0x65,
+	0x46, 0x69, 0x6c, 0x65, 0x12, 0x2a, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f,
 	0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69,
-	0x6e, 0x2e, 0x4f, 0x70, 0x65, 0x6e, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22,
-	0x00, 0x12, 0x5c, 0x0a, 0x09, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x2a,
+	0x6e, 0x2e, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79,
+	0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72,
+	0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x65, 0x0a, 0x09, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69,
+	0x6c, 0x65, 0x12, 0x2a, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e,
+	0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x2a,
 	0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f,
-	0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x43, 0x6c, 0x6f, 0x73,
-	0x65, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69,
-	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
-	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12,
-	0x65, 0x0a, 0x09, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x2a, 0x2e, 0x6c,
-	0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
-	0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46,
-	0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x2a, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68,
+	0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x57, 0x72, 0x69, 0x74,
+	0x65, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x08,
+	0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68,
 	0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70,
-	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x57, 0x72, 0x69, 0x74, 0x65, 0x46, 0x69, 0x6c, 0x65, 0x52,
-	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x64, 0x0a, 0x08, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69,
-	0x6c, 0x65, 0x12, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64,
+	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75,
+	0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2e, 0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00,
+	0x30, 0x01, 0x12, 0x62, 0x0a, 0x08, 0x53, 0x65, 0x65, 0x6b, 0x46, 0x69, 0x6c, 0x65, 0x12, 0x29,
+	0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f,
+	0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x53, 0x65, 0x65, 0x6b,
+	0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63,
+	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x53, 0x65, 0x65, 0x6b, 0x46, 0x69, 0x6c, 0x65, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x56, 0x0a, 0x06, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x12, 0x27, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73,
+	0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x44, 0x65,
+	0x6c, 0x65, 0x74, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63,
+	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x5a,
+	0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x54, 0x72, 0x65, 0x65, 0x12, 0x27, 0x2e, 0x6c,
+	0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61,
+	0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65,
+	0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c,
+	0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67,
+	0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x00, 0x12, 0x50, 0x0a, 0x05, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x12, 0x20, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75,
+	0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x2e, 0x55, 0x73, 0x65, 0x72, 0x1a, 0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c,
+	0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67,
+	0x69, 0x6e, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x00, 0x30, 0x01, 0x12, 0x65, 0x0a, 0x0b,
+	0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x29, 0x2e, 0x6c, 0x65,
+	0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67,
+	0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74,
+	0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63,
+	0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75,
+	0x67, 0x69, 0x6e, 0x2e, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x22, 0x00, 0x12, 0x5d, 0x0a, 0x0b, 0x53, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x12, 0x29, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64,
 	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e,
-	0x52, 0x65, 0x61, 0x64, 0x46, 0x69, 0x6c, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x29, 0x2e,
+	0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x21, 0x2e,
 	0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72,
-	0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x52, 0x65, 0x61, 0x64, 0x46,
-	0x69, 0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x30, 0x01, 0x12, 0x56, 0x0a, 0x06,
-	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x12, 0x27, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63,
-	0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75,
-	0x67, 0x69, 0x6e, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a,
-	0x21, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74,
-	0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72,
-	0x6f, 0x72, 0x22, 0x00, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x2d, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2f,
-	0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x2d, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2f, 0x70, 0x6b, 0x67,
-	0x2f, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2f, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x22, 0x00, 0x12, 0x66, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61,
+	0x74, 0x61, 0x12, 0x25, 0x2e, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x2d, 0x2e, 0x6c, 0x65, 0x69, 0x63,
+	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64,
+	0x61, 0x74, 0x61, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x12, 0x6e, 0x0a, 0x0c, 0x41, 0x74,
+	0x6f, 0x6d, 0x69, 0x63, 0x57, 0x72, 0x69, 0x74, 0x65, 0x73, 0x12, 0x2d, 0x2e, 0x6c, 0x65, 0x69,
+	0x63, 0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65,
+	0x2e, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x41, 0x74, 0x6f, 0x6d, 0x69, 0x63, 0x57, 0x72,
+	0x69, 0x74, 0x65, 0x73, 0x51, 0x75, 0x65, 0x72, 0x79, 0x1a, 0x2d, 0x2e, 0x6c, 0x65, 0x69, 0x63,
+	0x68, 0x74, 0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2e,
+	0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x2e, 0x41, 0x74, 0x6f, 0x6d, 0x69, 0x63, 0x57, 0x72, 0x69,
+	0x74, 0x65, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x2d,
+	0x63, 0x6c, 0x6f, 0x75, 0x64, 0x2f, 0x6c, 0x65, 0x69, 0x63, 0x68, 0x74, 0x2d, 0x63, 0x6c, 0x6f,
+	0x75, 0x64, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x73, 0x74, 0x6f, 0x72, 0x61, 0x67, 0x65, 0x2f, 0x70,
+	0x6c, 0x75, 0x67, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -1013,58 +2208,104 @@ func file_storage_proto_rawDescGZIP() []byte {
 	return file_storage_proto_rawDescData
 }
 
-var file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_storage_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_storage_proto_goTypes = []interface{}{
-	(*ConfigData)(nil),         // 0: leichtcloud.storage.plugin.ConfigData
-	(*FileInfo)(nil),           // 1: leichtcloud.storage.plugin.FileInfo
-	(*User)(nil),               // 2: leichtcloud.storage.plugin.User
-	(*Error)(nil),              // 3: leichtcloud.storage.plugin.Error
-	(*MkdirQuery)(nil),         // 4: leichtcloud.storage.plugin.MkdirQuery
-	(*MoveQuery)(nil),          // 5: leichtcloud.storage.plugin.MoveQuery
-	(*ListDirectoryQuery)(nil), // 6: leichtcloud.storage.plugin.ListDirectoryQuery
-	(*OpenFileQuery)(nil),      // 7: leichtcloud.storage.plugin.OpenFileQuery
-	(*OpenFileReply)(nil),      // 8: leichtcloud.storage.plugin.OpenFileReply
-	(*CloseFileQuery)(nil),     // 9: leichtcloud.storage.plugin.CloseFileQuery
-	(*WriteFileQuery)(nil),     // 10: leichtcloud.storage.plugin.WriteFileQuery
-	(*WriteFileReply)(nil),     // 11: leichtcloud.storage.plugin.WriteFileReply
-	(*ReadFileQuery)(nil),      // 12: leichtcloud.storage.plugin.ReadFileQuery
-	(*ReadFileReply)(nil),      // 13: leichtcloud.storage.plugin.ReadFileReply
-	(*DeleteQuery)(nil),        // 14: leichtcloud.storage.plugin.DeleteQuery
+	(ErrorCode)(0),             // 0: leichtcloud.storage.plugin.ErrorCode
+	(SortKey)(0),               // 1: leichtcloud.storage.plugin.SortKey
+	(EventType)(0),             // 2: leichtcloud.storage.plugin.EventType
+	(*ConfigData)(nil),         // 3: leichtcloud.storage.plugin.ConfigData
+	(*FileInfo)(nil),           // 4: leichtcloud.storage.plugin.FileInfo
+	(*User)(nil),               // 5: leichtcloud.storage.plugin.User
+	(*Error)(nil),              // 6: leichtcloud.storage.plugin.Error
+	(*MkdirQuery)(nil),         // 7: leichtcloud.storage.plugin.MkdirQuery
+	(*MoveQuery)(nil),          // 8: leichtcloud.storage.plugin.MoveQuery
+	(*CopyQuery)(nil),          // 9: leichtcloud.storage.plugin.CopyQuery
+	(*ListDirectoryQuery)(nil), // 10: leichtcloud.storage.plugin.ListDirectoryQuery
+	(*StatQuery)(nil),          // 11: leichtcloud.storage.plugin.StatQuery
+	(*StatReply)(nil),          // 12: leichtcloud.storage.plugin.StatReply
+	(*OpenFileQuery)(nil),      // 13: leichtcloud.storage.plugin.OpenFileQuery
+	(*OpenFileReply)(nil),      // 14: leichtcloud.storage.plugin.OpenFileReply
+	(*CloseFileQuery)(nil),     // 15: leichtcloud.storage.plugin.CloseFileQuery
+	(*WriteFileQuery)(nil),     // 16: leichtcloud.storage.plugin.WriteFileQuery
+	(*WriteFileReply)(nil),     // 17: leichtcloud.storage.plugin.WriteFileReply
+	(*ReadFileQuery)(nil),      // 18: leichtcloud.storage.plugin.ReadFileQuery
+	(*ReadFileReply)(nil),      // 19: leichtcloud.storage.plugin.ReadFileReply
+	(*SeekFileQuery)(nil),      // 20: leichtcloud.storage.plugin.SeekFileQuery
+	(*SeekFileReply)(nil),      // 21: leichtcloud.storage.plugin.SeekFileReply
+	(*DeleteQuery)(nil),        // 22: leichtcloud.storage.plugin.DeleteQuery
+	(*Event)(nil),              // 23: leichtcloud.storage.plugin.Event
+	(*MetadataQuery)(nil),      // 24: leichtcloud.storage.plugin.MetadataQuery
+	(*MetadataReply)(nil),      // 25: leichtcloud.storage.plugin.MetadataReply
+	(*Metadata)(nil),           // 26: leichtcloud.storage.plugin.Metadata
+	(*ListMetadataReply)(nil),  // 27: leichtcloud.storage.plugin.ListMetadataReply
+	(*AtomicWritesQuery)(nil),  // 28: leichtcloud.storage.plugin.AtomicWritesQuery
+	(*AtomicWritesReply)(nil),  // 29: leichtcloud.storage.plugin.AtomicWritesReply
 }
 var file_storage_proto_depIdxs = []int32{
-	2,  // 0: leichtcloud.storage.plugin.MkdirQuery.user:type_name -> leichtcloud.storage.plugin.User
-	2,  // 1: leichtcloud.storage.plugin.MoveQuery.user:type_name -> leichtcloud.storage.plugin.User
-	2,  // 2: leichtcloud.storage.plugin.ListDirectoryQuery.user:type_name -> leichtcloud.storage.plugin.User
-	2,  // 3: leichtcloud.storage.plugin.OpenFileQuery.user:type_name -> leichtcloud.storage.plugin.User
-	3,  // 4: leichtcloud.storage.plugin.OpenFileReply.error:type_name -> leichtcloud.storage.plugin.Error
-	3,  // 5: leichtcloud.storage.plugin.WriteFileReply.error:type_name -> leichtcloud.storage.plugin.Error
-	3,  // 6: leichtcloud.storage.plugin.ReadFileReply.error:type_name -> leichtcloud.storage.plugin.Error
-	2,  // 7: leichtcloud.storage.plugin.DeleteQuery.user:type_name -> leichtcloud.storage.plugin.User
-	0,  // 8: leichtcloud.storage.plugin.StorageProvider.Configure:input_type -> leichtcloud.storage.plugin.ConfigData
-	2,  // 9: leichtcloud.storage.plugin.StorageProvider.InitUser:input_type -> leichtcloud.storage.plugin.User
-	4,  // 10: leichtcloud.storage.plugin.StorageProvider.MkDir:input_type -> leichtcloud.storage.plugin.MkdirQuery
-	5,  // 11: leichtcloud.storage.plugin.StorageProvider.Move:input_type -> leichtcloud.storage.plugin.MoveQuery
-	6,  // 12: leichtcloud.storage.plugin.StorageProvider.ListDirectory:input_type -> leichtcloud.storage.plugin.ListDirectoryQuery
-	7,  // 13: leichtcloud.storage.plugin.StorageProvider.OpenFile:input_type -> leichtcloud.storage.plugin.OpenFileQuery
-	9,  // 14: leichtcloud.storage.plugin.StorageProvider.CloseFile:input_type -> leichtcloud.storage.plugin.CloseFileQuery
-	10, // 15: leichtcloud.storage.plugin.StorageProvider.WriteFile:input_type -> leichtcloud.storage.plugin.WriteFileQuery
-	12, // 16: leichtcloud.storage.plugin.StorageProvider.ReadFile:input_type -> leichtcloud.storage.plugin.ReadFileQuery
-	14, // 17: leichtcloud.storage.plugin.StorageProvider.Delete:input_type -> leichtcloud.storage.plugin.DeleteQuery
-	3,  // 18: leichtcloud.storage.plugin.StorageProvider.Configure:output_type -> leichtcloud.storage.plugin.Error
-	3,  // 19: leichtcloud.storage.plugin.StorageProvider.InitUser:output_type -> leichtcloud.storage.plugin.Error
-	3,  // 20: leichtcloud.storage.plugin.StorageProvider.MkDir:output_type -> leichtcloud.storage.plugin.Error
-	3,  // 21: leichtcloud.storage.plugin.StorageProvider.Move:output_type -> leichtcloud.storage.plugin.Error
-	1,  // 22: leichtcloud.storage.plugin.StorageProvider.ListDirectory:output_type -> leichtcloud.storage.plugin.FileInfo
-	8,  // 23: leichtcloud.storage.plugin.StorageProvider.OpenFile:output_type -> leichtcloud.storage.plugin.OpenFileReply
-	3,  // 24: leichtcloud.storage.plugin.StorageProvider.CloseFile:output_type -> leichtcloud.storage.plugin.Error
-	11, // 25: leichtcloud.storage.plugin.StorageProvider.WriteFile:output_type -> leichtcloud.storage.plugin.WriteFileReply
-	13, // 26: leichtcloud.storage.plugin.StorageProvider.ReadFile:output_type -> leichtcloud.storage.plugin.ReadFileReply
-	3,  // 27: leichtcloud.storage.plugin.StorageProvider.Delete:output_type -> leichtcloud.storage.plugin.Error
-	18, // [18:28] is the sub-list for method output_type
-	8,  // [8:18] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	0,  // 0: leichtcloud.storage.plugin.Error.code:type_name -> leichtcloud.storage.plugin.ErrorCode
+	5,  // 1: leichtcloud.storage.plugin.MkdirQuery.user:type_name -> leichtcloud.storage.plugin.User
+	5,  // 2: leichtcloud.storage.plugin.MoveQuery.user:type_name -> leichtcloud.storage.plugin.User
+	5,  // 3: leichtcloud.storage.plugin.CopyQuery.user:type_name -> leichtcloud.storage.plugin.User
+	5,  // 4: leichtcloud.storage.plugin.ListDirectoryQuery.user:type_name -> leichtcloud.storage.plugin.User
+	1,  // 5: leichtcloud.storage.plugin.ListDirectoryQuery.sort:type_name -> leichtcloud.storage.plugin.SortKey
+	5,  // 6: leichtcloud.storage.plugin.StatQuery.user:type_name -> leichtcloud.storage.plugin.User
+	4,  // 7: leichtcloud.storage.plugin.StatReply.info:type_name -> leichtcloud.storage.plugin.FileInfo
+	6,  // 8: leichtcloud.storage.plugin.StatReply.error:type_name -> leichtcloud.storage.plugin.Error
+	5,  // 9: leichtcloud.storage.plugin.OpenFileQuery.user:type_name -> leichtcloud.storage.plugin.User
+	6,  // 10: leichtcloud.storage.plugin.OpenFileReply.error:type_name -> leichtcloud.storage.plugin.Error
+	6,  // 11: leichtcloud.storage.plugin.WriteFileReply.error:type_name -> leichtcloud.storage.plugin.Error
+	6,  // 12: leichtcloud.storage.plugin.ReadFileReply.error:type_name -> leichtcloud.storage.plugin.Error
+	6,  // 13: leichtcloud.storage.plugin.SeekFileReply.error:type_name -> leichtcloud.storage.plugin.Error
+	5,  // 14: leichtcloud.storage.plugin.DeleteQuery.user:type_name -> leichtcloud.storage.plugin.User
+	2,  // 15: leichtcloud.storage.plugin.Event.type:type_name -> leichtcloud.storage.plugin.EventType
+	5,  // 16: leichtcloud.storage.plugin.MetadataQuery.user:type_name -> leichtcloud.storage.plugin.User
+	6,  // 17: leichtcloud.storage.plugin.MetadataReply.error:type_name -> leichtcloud.storage.plugin.Error
+	26, // 18: leichtcloud.storage.plugin.ListMetadataReply.metadata:type_name -> leichtcloud.storage.plugin.Metadata
+	6,  // 19: leichtcloud.storage.plugin.ListMetadataReply.error:type_name -> leichtcloud.storage.plugin.Error
+	3,  // 20: leichtcloud.storage.plugin.StorageProvider.Configure:input_type -> leichtcloud.storage.plugin.ConfigData
+	5,  // 21: leichtcloud.storage.plugin.StorageProvider.InitUser:input_type -> leichtcloud.storage.plugin.User
+	7,  // 22: leichtcloud.storage.plugin.StorageProvider.MkDir:input_type -> leichtcloud.storage.plugin.MkdirQuery
+	8,  // 23: leichtcloud.storage.plugin.StorageProvider.Move:input_type -> leichtcloud.storage.plugin.MoveQuery
+	9,  // 24: leichtcloud.storage.plugin.StorageProvider.Copy:input_type -> leichtcloud.storage.plugin.CopyQuery
+	10, // 25: leichtcloud.storage.plugin.StorageProvider.ListDirectory:input_type -> leichtcloud.storage.plugin.ListDirectoryQuery
+	11, // 26: leichtcloud.storage.plugin.StorageProvider.Stat:input_type -> leichtcloud.storage.plugin.StatQuery
+	13, // 27: leichtcloud.storage.plugin.StorageProvider.OpenFile:input_type -> leichtcloud.storage.plugin.OpenFileQuery
+	15, // 28: leichtcloud.storage.plugin.StorageProvider.CloseFile:input_type -> leichtcloud.storage.plugin.CloseFileQuery
+	16, // 29: leichtcloud.storage.plugin.StorageProvider.WriteFile:input_type -> leichtcloud.storage.plugin.WriteFileQuery
+	18, // 30: leichtcloud.storage.plugin.StorageProvider.ReadFile:input_type -> leichtcloud.storage.plugin.ReadFileQuery
+	20, // 31: leichtcloud.storage.plugin.StorageProvider.SeekFile:input_type -> leichtcloud.storage.plugin.SeekFileQuery
+	22, // 32: leichtcloud.storage.plugin.StorageProvider.Delete:input_type -> leichtcloud.storage.plugin.DeleteQuery
+	22, // 33: leichtcloud.storage.plugin.StorageProvider.DeleteTree:input_type -> leichtcloud.storage.plugin.DeleteQuery
+	5,  // 34: leichtcloud.storage.plugin.StorageProvider.Watch:input_type -> leichtcloud.storage.plugin.User
+	24, // 35: leichtcloud.storage.plugin.StorageProvider.GetMetadata:input_type -> leichtcloud.storage.plugin.MetadataQuery
+	24, // 36: leichtcloud.storage.plugin.StorageProvider.SetMetadata:input_type -> leichtcloud.storage.plugin.MetadataQuery
+	11, // 37: leichtcloud.storage.plugin.StorageProvider.ListMetadata:input_type -> leichtcloud.storage.plugin.StatQuery
+	28, // 38: leichtcloud.storage.plugin.StorageProvider.AtomicWrites:input_type -> leichtcloud.storage.plugin.AtomicWritesQuery
+	6,  // 39: leichtcloud.storage.plugin.StorageProvider.Configure:output_type -> leichtcloud.storage.plugin.Error
+	6,  // 40: leichtcloud.storage.plugin.StorageProvider.InitUser:output_type -> leichtcloud.storage.plugin.Error
+	6,  // 41: leichtcloud.storage.plugin.StorageProvider.MkDir:output_type -> leichtcloud.storage.plugin.Error
+	6,  // 42: leichtcloud.storage.plugin.StorageProvider.Move:output_type -> leichtcloud.storage.plugin.Error
+	6,  // 43: leichtcloud.storage.plugin.StorageProvider.Copy:output_type -> leichtcloud.storage.plugin.Error
+	4,  // 44: leichtcloud.storage.plugin.StorageProvider.ListDirectory:output_type -> leichtcloud.storage.plugin.FileInfo
+	12, // 45: leichtcloud.storage.plugin.StorageProvider.Stat:output_type -> leichtcloud.storage.plugin.StatReply
+	14, // 46: leichtcloud.storage.plugin.StorageProvider.OpenFile:output_type -> leichtcloud.storage.plugin.OpenFileReply
+	6,  // 47: leichtcloud.storage.plugin.StorageProvider.CloseFile:output_type -> leichtcloud.storage.plugin.Error
+	17, // 48: leichtcloud.storage.plugin.StorageProvider.WriteFile:output_type -> leichtcloud.storage.plugin.WriteFileReply
+	19, // 49: leichtcloud.storage.plugin.StorageProvider.ReadFile:output_type -> leichtcloud.storage.plugin.ReadFileReply
+	21, // 50: leichtcloud.storage.plugin.StorageProvider.SeekFile:output_type -> leichtcloud.storage.plugin.SeekFileReply
+	6,  // 51: leichtcloud.storage.plugin.StorageProvider.Delete:output_type -> leichtcloud.storage.plugin.Error
+	6,  // 52: leichtcloud.storage.plugin.StorageProvider.DeleteTree:output_type -> leichtcloud.storage.plugin.Error
+	23, // 53: leichtcloud.storage.plugin.StorageProvider.Watch:output_type -> leichtcloud.storage.plugin.Event
+	25, // 54: leichtcloud.storage.plugin.StorageProvider.GetMetadata:output_type -> leichtcloud.storage.plugin.MetadataReply
+	6,  // 55: leichtcloud.storage.plugin.StorageProvider.SetMetadata:output_type -> leichtcloud.storage.plugin.Error
+	27, // 56: leichtcloud.storage.plugin.StorageProvider.ListMetadata:output_type -> leichtcloud.storage.plugin.ListMetadataReply
+	29, // 57: leichtcloud.storage.plugin.StorageProvider.AtomicWrites:output_type -> leichtcloud.storage.plugin.AtomicWritesReply
+	39, // [39:58] is the sub-list for method output_type
+	20, // [20:39] is the sub-list for method input_type
+	20, // [20:20] is the sub-list for extension type_name
+	20, // [20:20] is the sub-list for extension extendee
+	0,  // [0:20] is the sub-list for field type_name
 }
 
 func init() { file_storage_proto_init() }
@@ -1146,7 +2387,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ListDirectoryQuery); i {
+			switch v := v.(*CopyQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1158,7 +2399,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*OpenFileQuery); i {
+			switch v := v.(*ListDirectoryQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1170,7 +2411,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*OpenFileReply); i {
+			switch v := v.(*StatQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1182,7 +2423,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CloseFileQuery); i {
+			switch v := v.(*StatReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1194,7 +2435,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WriteFileQuery); i {
+			switch v := v.(*OpenFileQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1206,7 +2447,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*WriteFileReply); i {
+			switch v := v.(*OpenFileReply); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1218,7 +2459,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadFileQuery); i {
+			switch v := v.(*CloseFileQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1230,7 +2471,7 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*ReadFileReply); i {
+			switch v := v.(*WriteFileQuery); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1242,6 +2483,66 @@ func file_storage_proto_init() {
 			}
 		}
 		file_storage_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*WriteFileReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadFileQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReadFileReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SeekFileQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SeekFileReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*DeleteQuery); i {
 			case 0:
 				return &v.state
@@ -1253,19 +2554,104 @@ func file_storage_proto_init() {
 				return nil
 			}
 		}
+		file_storage_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetadataQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MetadataReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Metadata); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListMetadataReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AtomicWritesQuery); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storage_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AtomicWritesReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_storage_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   15,
+			NumEnums:      3,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_storage_proto_goTypes,
 		DependencyIndexes: file_storage_proto_depIdxs,
+		EnumInfos:         file_storage_proto_enumTypes,
 		MessageInfos:      file_storage_proto_msgTypes,
 	}.Build()
 	File_storage_proto = out.File
@@ -26,12 +26,25 @@ type StorageProviderClient interface {
 	InitUser(ctx context.Context, in *User, opts ...grpc.CallOption) (*Error, error)
 	MkDir(ctx context.Context, in *MkdirQuery, opts ...grpc.CallOption) (*Error, error)
 	Move(ctx context.Context, in *MoveQuery, opts ...grpc.CallOption) (*Error, error)
+	Copy(ctx context.Context, in *CopyQuery, opts ...grpc.CallOption) (*Error, error)
 	ListDirectory(ctx context.Context, in *ListDirectoryQuery, opts ...grpc.CallOption) (StorageProvider_ListDirectoryClient, error)
+	Stat(ctx context.Context, in *StatQuery, opts ...grpc.CallOption) (*StatReply, error)
 	OpenFile(ctx context.Context, in *OpenFileQuery, opts ...grpc.CallOption) (*OpenFileReply, error)
 	CloseFile(ctx context.Context, in *CloseFileQuery, opts ...grpc.CallOption) (*Error, error)
 	WriteFile(ctx context.Context, in *WriteFileQuery, opts ...grpc.CallOption) (*WriteFileReply, error)
 	ReadFile(ctx context.Context, in *ReadFileQuery, opts ...grpc.CallOption) (StorageProvider_ReadFileClient, error)
+	SeekFile(ctx context.Context, in *SeekFileQuery, opts ...grpc.CallOption) (*SeekFileReply, error)
 	Delete(ctx context.Context, in *DeleteQuery, opts ...grpc.CallOption) (*Error, error)
+	DeleteTree(ctx context.Context, in *DeleteQuery, opts ...grpc.CallOption) (*Error, error)
+	// Streams the changes to the files of the user, for storages able to notice these themselves.
+	// The NOT_SUPPORTED code is returned for the ones that aren't
+	Watch(ctx context.Context, in *User, opts ...grpc.CallOption) (StorageProvider_WatchClient, error)
+	// The metadata calls return the NOT_SUPPORTED code for storages that can't store metadata
+	GetMetadata(ctx context.Context, in *MetadataQuery, opts ...grpc.CallOption) (*MetadataReply, error)
+	SetMetadata(ctx context.Context, in *MetadataQuery, opts ...grpc.CallOption) (*Error, error)
+	ListMetadata(ctx context.Context, in *StatQuery, opts ...grpc.CallOption) (*ListMetadataReply, error)
+	// Whether files written from the start only replace the old contents once closed, see storage.AtomicWriter
+	AtomicWrites(ctx context.Context, in *AtomicWritesQuery, opts ...grpc.CallOption) (*AtomicWritesReply, error)
 }
 
 type storageProviderClient struct {
@@ -78,6 +91,15 @@ func (c *storageProviderClient) Move(ctx context.Context, in *MoveQuery, opts ..
 	return out, nil
 }
 
+func (c *storageProviderClient) Copy(ctx context.Context, in *CopyQuery, opts ...grpc.CallOption) (*Error, error) {
+	out := new(Error)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/Copy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageProviderClient) ListDirectory(ctx context.Context, in *ListDirectoryQuery, opts ...grpc.CallOption) (StorageProvider_ListDirectoryClient, error) {
 	stream, err := c.cc.NewStream(ctx, &StorageProvider_ServiceDesc.Streams[0], "/leichtcloud.storage.plugin.StorageProvider/ListDirectory", opts...)
 	if err != nil {
@@ -110,6 +132,15 @@ func (x *storageProviderListDirectoryClient) Recv() (*FileInfo, error) {
 	return m, nil
 }
 
+func (c *storageProviderClient) Stat(ctx context.Context, in *StatQuery, opts ...grpc.CallOption) (*StatReply, error) {
+	out := new(StatReply)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/Stat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageProviderClient) OpenFile(ctx context.Context, in *OpenFileQuery, opts ...grpc.CallOption) (*OpenFileReply, error) {
 	out := new(OpenFileReply)
 	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/OpenFile", in, out, opts...)
@@ -169,6 +200,15 @@ func (x *storageProviderReadFileClient) Recv() (*ReadFileReply, error) {
 	return m, nil
 }
 
+func (c *storageProviderClient) SeekFile(ctx context.Context, in *SeekFileQuery, opts ...grpc.CallOption) (*SeekFileReply, error) {
+	out := new(SeekFileReply)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/SeekFile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageProviderClient) Delete(ctx context.Context, in *DeleteQuery, opts ...grpc.CallOption) (*Error, error) {
 	out := new(Error)
 	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/Delete", in, out, opts...)
@@ -178,6 +218,83 @@ func (c *storageProviderClient) Delete(ctx context.Context, in *DeleteQuery, opt
 	return out, nil
 }
 
+func (c *storageProviderClient) DeleteTree(ctx context.Context, in *DeleteQuery, opts ...grpc.CallOption) (*Error, error) {
+	out := new(Error)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/DeleteTree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageProviderClient) Watch(ctx context.Context, in *User, opts ...grpc.CallOption) (StorageProvider_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &StorageProvider_ServiceDesc.Streams[2], "/leichtcloud.storage.plugin.StorageProvider/Watch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &storageProviderWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StorageProvider_WatchClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type storageProviderWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *storageProviderWatchClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *storageProviderClient) GetMetadata(ctx context.Context, in *MetadataQuery, opts ...grpc.CallOption) (*MetadataReply, error) {
+	out := new(MetadataReply)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/GetMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageProviderClient) SetMetadata(ctx context.Context, in *MetadataQuery, opts ...grpc.CallOption) (*Error, error) {
+	out := new(Error)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/SetMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageProviderClient) ListMetadata(ctx context.Context, in *StatQuery, opts ...grpc.CallOption) (*ListMetadataReply, error) {
+	out := new(ListMetadataReply)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/ListMetadata", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageProviderClient) AtomicWrites(ctx context.Context, in *AtomicWritesQuery, opts ...grpc.CallOption) (*AtomicWritesReply, error) {
+	out := new(AtomicWritesReply)
+	err := c.cc.Invoke(ctx, "/leichtcloud.storage.plugin.StorageProvider/AtomicWrites", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StorageProviderServer is the server API for StorageProvider service.
 // All implementations must embed UnimplementedStorageProviderServer
 // for forward compatibility
@@ -186,12 +303,25 @@ type StorageProviderServer interface {
 	InitUser(context.Context, *User) (*Error, error)
 	MkDir(context.Context, *MkdirQuery) (*Error, error)
 	Move(context.Context, *MoveQuery) (*Error, error)
+	Copy(context.Context, *CopyQuery) (*Error, error)
 	ListDirectory(*ListDirectoryQuery, StorageProvider_ListDirectoryServer) error
+	Stat(context.Context, *StatQuery) (*StatReply, error)
 	OpenFile(context.Context, *OpenFileQuery) (*OpenFileReply, error)
 	CloseFile(context.Context, *CloseFileQuery) (*Error, error)
 	WriteFile(context.Context, *WriteFileQuery) (*WriteFileReply, error)
 	ReadFile(*ReadFileQuery, StorageProvider_ReadFileServer) error
+	SeekFile(context.Context, *SeekFileQuery) (*SeekFileReply, error)
 	Delete(context.Context, *DeleteQuery) (*Error, error)
+	DeleteTree(context.Context, *DeleteQuery) (*Error, error)
+	// Streams the changes to the files of the user, for storages able to notice these themselves.
+	// The NOT_SUPPORTED code is returned for the ones that aren't
+	Watch(*User, StorageProvider_WatchServer) error
+	// The metadata calls return the NOT_SUPPORTED code for storages that can't store metadata
+	GetMetadata(context.Context, *MetadataQuery) (*MetadataReply, error)
+	SetMetadata(context.Context, *MetadataQuery) (*Error, error)
+	ListMetadata(context.Context, *StatQuery) (*ListMetadataReply, error)
+	// Whether files written from the start only replace the old contents once closed, see storage.AtomicWriter
+	AtomicWrites(context.Context, *AtomicWritesQuery) (*AtomicWritesReply, error)
 	mustEmbedUnimplementedStorageProviderServer()
 }
 
@@ -211,9 +341,15 @@ func (UnimplementedStorageProviderServer) MkDir(context.Context, *MkdirQuery) (*
 func (UnimplementedStorageProviderServer) Move(context.Context, *MoveQuery) (*Error, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
 }
+func (UnimplementedStorageProviderServer) Copy(context.Context, *CopyQuery) (*Error, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Copy not implemented")
+}
 func (UnimplementedStorageProviderServer) ListDirectory(*ListDirectoryQuery, StorageProvider_ListDirectoryServer) error {
 	return status.Errorf(codes.Unimplemented, "method ListDirectory not implemented")
 }
+func (UnimplementedStorageProviderServer) Stat(context.Context, *StatQuery) (*StatReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stat not implemented")
+}
 func (UnimplementedStorageProviderServer) OpenFile(context.Context, *OpenFileQuery) (*OpenFileReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method OpenFile not implemented")
 }
@@ -226,9 +362,30 @@ func (UnimplementedStorageProviderServer) WriteFile(context.Context, *WriteFileQ
 func (UnimplementedStorageProviderServer) ReadFile(*ReadFileQuery, StorageProvider_ReadFileServer) error {
 	return status.Errorf(codes.Unimplemented, "method ReadFile not implemented")
 }
+func (UnimplementedStorageProviderServer) SeekFile(context.Context, *SeekFileQuery) (*SeekFileReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SeekFile not implemented")
+}
 func (UnimplementedStorageProviderServer) Delete(context.Context, *DeleteQuery) (*Error, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedStorageProviderServer) DeleteTree(context.Context, *DeleteQuery) (*Error, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTree not implemented")
+}
+func (UnimplementedStorageProviderServer) Watch(*User, StorageProvider_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedStorageProviderServer) GetMetadata(context.Context, *MetadataQuery) (*MetadataReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMetadata not implemented")
+}
+func (UnimplementedStorageProviderServer) SetMetadata(context.Context, *MetadataQuery) (*Error, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMetadata not implemented")
+}
+func (UnimplementedStorageProviderServer) ListMetadata(context.Context, *StatQuery) (*ListMetadataReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMetadata not implemented")
+}
+func (UnimplementedStorageProviderServer) AtomicWrites(context.Context, *AtomicWritesQuery) (*AtomicWritesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AtomicWrites not implemented")
+}
 func (UnimplementedStorageProviderServer) mustEmbedUnimplementedStorageProviderServer() {}
 
 // UnsafeStorageProviderServer may be embedded to opt out of forward compatibility for this service.
@@ -314,6 +471,24 @@ func _StorageProvider_Move_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageProvider_Copy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).Copy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/Copy",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).Copy(ctx, req.(*CopyQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageProvider_ListDirectory_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(ListDirectoryQuery)
 	if err := stream.RecvMsg(m); err != nil {
@@ -335,6 +510,24 @@ func (x *storageProviderListDirectoryServer) Send(m *FileInfo) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _StorageProvider_Stat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).Stat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/Stat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).Stat(ctx, req.(*StatQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageProvider_OpenFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(OpenFileQuery)
 	if err := dec(in); err != nil {
@@ -410,6 +603,24 @@ func (x *storageProviderReadFileServer) Send(m *ReadFileReply) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _StorageProvider_SeekFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SeekFileQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).SeekFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/SeekFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).SeekFile(ctx, req.(*SeekFileQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageProvider_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteQuery)
 	if err := dec(in); err != nil {
@@ -428,6 +639,117 @@ func _StorageProvider_Delete_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageProvider_DeleteTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).DeleteTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/DeleteTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).DeleteTree(ctx, req.(*DeleteQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageProvider_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(User)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StorageProviderServer).Watch(m, &storageProviderWatchServer{stream})
+}
+
+type StorageProvider_WatchServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type storageProviderWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *storageProviderWatchServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StorageProvider_GetMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetadataQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).GetMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/GetMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).GetMetadata(ctx, req.(*MetadataQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageProvider_SetMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetadataQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).SetMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/SetMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).SetMetadata(ctx, req.(*MetadataQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageProvider_ListMetadata_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).ListMetadata(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/ListMetadata",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).ListMetadata(ctx, req.(*StatQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageProvider_AtomicWrites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AtomicWritesQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageProviderServer).AtomicWrites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/leichtcloud.storage.plugin.StorageProvider/AtomicWrites",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageProviderServer).AtomicWrites(ctx, req.(*AtomicWritesQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // StorageProvider_ServiceDesc is the grpc.ServiceDesc for StorageProvider service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -451,6 +773,14 @@ var StorageProvider_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Move",
 			Handler:    _StorageProvider_Move_Handler,
 		},
+		{
+			MethodName: "Copy",
+			Handler:    _StorageProvider_Copy_Handler,
+		},
+		{
+			MethodName: "Stat",
+			Handler:    _StorageProvider_Stat_Handler,
+		},
 		{
 			MethodName: "OpenFile",
 			Handler:    _StorageProvider_OpenFile_Handler,
@@ -463,10 +793,34 @@ var StorageProvider_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "WriteFile",
 			Handler:    _StorageProvider_WriteFile_Handler,
 		},
+		{
+			MethodName: "SeekFile",
+			Handler:    _StorageProvider_SeekFile_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _StorageProvider_Delete_Handler,
 		},
+		{
+			MethodName: "DeleteTree",
+			Handler:    _StorageProvider_DeleteTree_Handler,
+		},
+		{
+			MethodName: "GetMetadata",
+			Handler:    _StorageProvider_GetMetadata_Handler,
+		},
+		{
+			MethodName: "SetMetadata",
+			Handler:    _StorageProvider_SetMetadata_Handler,
+		},
+		{
+			MethodName: "ListMetadata",
+			Handler:    _StorageProvider_ListMetadata_Handler,
+		},
+		{
+			MethodName: "AtomicWrites",
+			Handler:    _StorageProvider_AtomicWrites_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -479,6 +833,11 @@ var StorageProvider_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _StorageProvider_ReadFile_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Watch",
+			Handler:       _StorageProvider_Watch_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "storage.proto",
 }
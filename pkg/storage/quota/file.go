@@ -0,0 +1,120 @@
+package quota
+
+import (
+	"context"
+	"errors"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+type quotaFile struct {
+	proxy    storage.File
+	quota    *QuotaStorageProvider
+	user     *models.User
+	fullpath string
+
+	// the limit of user, only looked up once the file grows for the first time
+	limit *models.Quota
+
+	// the size of the file as we've charged it so far
+	size    int64
+	pos     int64
+	written bool
+}
+
+// grow charges the user for everything written beyond the current size of the file
+func (f *quotaFile) grow(end int64) error {
+	if end <= f.size {
+		return nil
+	}
+
+	if f.limit == nil {
+		limit, err := f.quota.limit(f.user)
+		if err != nil {
+			return err
+		}
+		f.limit = limit
+	}
+
+	err := f.quota.charge(f.user, f.limit, end-f.size)
+	if err != nil {
+		return err
+	}
+	f.size = end
+	return nil
+}
+
+func (f *quotaFile) Read(p []byte) (int, error) {
+	n, err := f.proxy.Read(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *quotaFile) Write(p []byte) (int, error) {
+	err := f.grow(f.pos + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	f.written = true
+
+	n, err := f.proxy.Write(p)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *quotaFile) Close() error {
+	return f.settle(f.proxy.Close())
+}
+
+// Abort charges the user for whatever is left of the file afterwards, like Close
+func (f *quotaFile) Abort() error {
+	return f.settle(storage.Abort(f.proxy))
+}
+
+// settle is called once the file is closed with the resulting error, which it returns
+func (f *quotaFile) settle(err error) error {
+	if !f.written {
+		return err
+	}
+
+	// what we charged for is only an estimate, whether a provider truncates files on write or how much
+	// actually made it to disk on errors differs. so we correct the usage with the actual size afterwards
+	var size int64
+	info, statErr := f.quota.proxy.Stat(context.Background(), f.user, f.fullpath)
+	if statErr == nil {
+		size = int64(info.Size)
+	} else if !errors.Is(statErr, storage.ErrNotExist) {
+		return err
+	}
+
+	f.quota.adjust(f.user, size-f.size)
+	return err
+}
+
+type quotaSeekableFile struct {
+	*quotaFile
+	seeker storage.SeekableFile
+}
+
+func (f *quotaSeekableFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.seeker.ReadAt(p, off)
+}
+
+func (f *quotaSeekableFile) WriteAt(p []byte, off int64) (int, error) {
+	err := f.grow(off + int64(len(p)))
+	if err != nil {
+		return 0, err
+	}
+	f.written = true
+
+	return f.seeker.WriteAt(p, off)
+}
+
+func (f *quotaSeekableFile) Seek(offset int64, whence int) (int64, error) {
+	pos, err := f.seeker.Seek(offset, whence)
+	if err == nil {
+		f.pos = pos
+	}
+	return pos, err
+}
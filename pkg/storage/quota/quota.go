@@ -0,0 +1,305 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// QuotaStorageProvider keeps track of how much every user is storing and refuses
+// anything that would bring them over the limit set in their models.Quota
+type QuotaStorageProvider struct {
+	proxy storage.StorageProvider
+	db    *gorm.DB
+	// fixed is the limit of every user if set, rather than the one in their models.Quota
+	fixed *models.Quota
+
+	mutex sync.Mutex
+	usage map[uint64]int64
+}
+
+// Usage is the current usage of a single user, Limit is only relevant if Unlimited is false
+type Usage struct {
+	Used      int64 `json:"used"`
+	Limit     int64 `json:"limit"`
+	Unlimited bool  `json:"unlimited"`
+}
+
+// Percentage returns how much of the limit is used, capped at 100
+func (u Usage) Percentage() int64 {
+	if u.Unlimited || u.Limit <= 0 {
+		return 0
+	}
+	if u.Used >= u.Limit {
+		return 100
+	}
+	return u.Used * 100 / u.Limit
+}
+
+// Quota wraps around provider and calculates the current usage of all users in the database
+func Quota(provider storage.StorageProvider, db *gorm.DB) (*QuotaStorageProvider, error) {
+	out := &QuotaStorageProvider{
+		proxy: provider,
+		db:    db,
+		usage: make(map[uint64]int64),
+	}
+
+	var users []models.User
+	tx := db.Find(&users)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	for i := range users {
+		used, err := out.sizeOf(context.Background(), &users[i], "/")
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		logrus.Debugf("User %d is using %d bytes", users[i].ID, used)
+		out.usage[users[i].ID] = used
+	}
+
+	return out, nil
+}
+
+// Limit is like Quota, except every user gets the same limit in bytes rather than the one in their models.Quota.
+// This is meant for limiting what's stored on a single provider, on top of the quota over everything
+func Limit(provider storage.StorageProvider, db *gorm.DB, limit int64) (*QuotaStorageProvider, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("Invalid quota limit %d, it should be a positive amount of bytes", limit)
+	}
+
+	out, err := Quota(provider, db)
+	if err != nil {
+		return nil, err
+	}
+	out.fixed = &models.Quota{Limit: limit}
+	return out, nil
+}
+
+// sizeOf returns the size of fullpath, including everything in it if it's a directory
+func (q *QuotaStorageProvider) sizeOf(ctx context.Context, user *models.User, fullpath string) (int64, error) {
+	info, err := q.proxy.Stat(ctx, user, fullpath)
+	if err != nil {
+		return 0, err
+	}
+	if !info.Directory {
+		return int64(info.Size), nil
+	}
+
+	files, err := q.proxy.ListDirectory(ctx, user, fullpath)
+	if err != nil {
+		return 0, err
+	}
+
+	// we drain the listing first, so we're not listing multiple directories at the same time
+	entries := make([]storage.FileInfo, 0)
+	for file := range files {
+		entries = append(entries, file)
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if !entry.Directory {
+			size += int64(entry.Size)
+			continue
+		}
+		dirSize, err := q.sizeOf(ctx, user, path.Join(fullpath, entry.Name))
+		if err != nil {
+			return 0, err
+		}
+		size += dirSize
+	}
+	return size, nil
+}
+
+// Usage returns the current usage and limit of user
+func (q *QuotaStorageProvider) Usage(user *models.User) (Usage, error) {
+	quota, err := q.limit(user)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return Usage{
+		Used:      q.usage[user.ID],
+		Limit:     quota.Limit,
+		Unlimited: quota.Unlimited,
+	}, nil
+}
+
+func (q *QuotaStorageProvider) limit(user *models.User) (*models.Quota, error) {
+	if q.fixed != nil {
+		return q.fixed, nil
+	}
+
+	quota := &models.Quota{}
+	tx := q.db.Limit(1).Find(quota, "user_id = ?", user.ID)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	// no entry for this user means there is no limit
+	if tx.RowsAffected == 0 {
+		quota.Unlimited = true
+	}
+	return quota, nil
+}
+
+// charge adds delta to the usage of user, unless that would bring them over the limit in quota
+func (q *QuotaStorageProvider) charge(user *models.User, quota *models.Quota, delta int64) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if delta > 0 && !quota.Unlimited && q.usage[user.ID]+delta > quota.Limit {
+		return fmt.Errorf("%w: %d of %d bytes used", storage.ErrQuotaExceeded, q.usage[user.ID], quota.Limit)
+	}
+	q.usage[user.ID] += delta
+	return nil
+}
+
+// adjust changes the usage of user without checking the limit, used for corrections and things getting removed
+func (q *QuotaStorageProvider) adjust(user *models.User, delta int64) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.usage[user.ID] += delta
+	if q.usage[user.ID] < 0 {
+		q.usage[user.ID] = 0
+	}
+}
+
+func (q *QuotaStorageProvider) Unwrap() storage.StorageProvider {
+	return q.proxy
+}
+
+func (q *QuotaStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return q.proxy.InitUser(ctx, user)
+}
+
+func (q *QuotaStorageProvider) Mkdir(ctx context.Context, user *models.User, path string) error {
+	return q.proxy.Mkdir(ctx, user, path)
+}
+
+func (q *QuotaStorageProvider) Move(ctx context.Context, user *models.User, src string, dst string) error {
+	return q.proxy.Move(ctx, user, src, dst)
+}
+
+func (q *QuotaStorageProvider) Copy(ctx context.Context, user *models.User, src string, dst string) error {
+	size, err := q.sizeOf(ctx, user, src)
+	if err != nil {
+		return err
+	}
+
+	quota, err := q.limit(user)
+	if err != nil {
+		return err
+	}
+	err = q.charge(user, quota, size)
+	if err != nil {
+		return err
+	}
+
+	err = storage.Copy(ctx, q.proxy, user, src, dst)
+	if err != nil {
+		q.adjust(user, -size)
+	}
+	return err
+}
+
+func (q *QuotaStorageProvider) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
+	return q.proxy.ListDirectory(ctx, user, path)
+}
+
+func (q *QuotaStorageProvider) List(ctx context.Context, user *models.User, path string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	return storage.List(ctx, q.proxy, user, path, opts)
+}
+
+func (q *QuotaStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	return q.proxy.Stat(ctx, user, fullpath)
+}
+
+func (q *QuotaStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	var size int64
+	info, err := q.proxy.Stat(ctx, user, fullpath)
+	if err == nil {
+		size = int64(info.Size)
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return nil, err
+	}
+
+	file, err := q.proxy.File(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &quotaFile{
+		proxy:    file,
+		quota:    q,
+		user:     user,
+		fullpath: fullpath,
+		size:     size,
+	}
+
+	if seekable, ok := file.(storage.SeekableFile); ok {
+		return &quotaSeekableFile{quotaFile: out, seeker: seekable}, nil
+	}
+	return out, nil
+}
+
+func (q *QuotaStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	size, err := q.sizeOf(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	err = q.proxy.Delete(ctx, user, fullpath)
+	if err == nil {
+		q.adjust(user, -size)
+	}
+	return err
+}
+
+func (q *QuotaStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	size, err := q.sizeOf(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	err = q.proxy.DeleteTree(ctx, user, fullpath)
+	if err == nil {
+		q.adjust(user, -size)
+	}
+	return err
+}
+
+func (q *QuotaStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	return storage.Watch(ctx, q.proxy, user)
+}
+
+func (q *QuotaStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(q.proxy)
+}
+
+func (q *QuotaStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	return storage.GetMetadata(ctx, q.proxy, user, fullpath, key)
+}
+
+func (q *QuotaStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	return storage.SetMetadata(ctx, q.proxy, user, fullpath, key, value)
+}
+
+func (q *QuotaStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	return storage.ListMetadata(ctx, q.proxy, user, fullpath)
+}
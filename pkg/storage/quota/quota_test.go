@@ -0,0 +1,115 @@
+package quota
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotaStorage(t *testing.T) {
+	provider, err := Quota(memory.NewStorageProvider(), storagetest.DB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.TestStorageProvider(provider, t)
+}
+
+func TestQuotaLimit(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.DB(t)
+	memfs := memory.NewStorageProvider()
+
+	user := &models.User{Email: "test@test.com"}
+	assert.NoError(t, db.Create(user).Error)
+	assert.NoError(t, db.Create(&models.Quota{UserID: user.ID, Limit: 10}).Error)
+
+	provider, err := Quota(memfs, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(filename string, data string) error {
+		file, err := provider.File(ctx, user, filename)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write([]byte(data))
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		return closeErr
+	}
+	used := func() int64 {
+		usage, err := provider.Usage(user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(10), usage.Limit)
+		assert.False(t, usage.Unlimited)
+		return usage.Used
+	}
+
+	assert.NoError(t, write("first", "12345678"))
+	assert.Equal(t, int64(8), used())
+
+	assert.ErrorIs(t, write("second", "1234"), storage.ErrQuotaExceeded)
+	assert.Equal(t, int64(8), used())
+
+	// overwriting a file only charges the difference
+	assert.NoError(t, write("first", "123456789"))
+	assert.Equal(t, int64(9), used())
+
+	assert.ErrorIs(t, provider.Copy(ctx, user, "first", "copy"), storage.ErrQuotaExceeded)
+	assert.Equal(t, int64(9), used())
+
+	assert.NoError(t, provider.Delete(ctx, user, "first"))
+	assert.Equal(t, int64(0), used())
+
+	assert.NoError(t, write("small", "12"))
+	assert.NoError(t, provider.Copy(ctx, user, "small", "copy"))
+	assert.Equal(t, int64(4), used())
+
+	// a fresh wrapper should end up with the same usage
+	recomputed, err := Quota(memfs, db)
+	if assert.NoError(t, err) {
+		usage, err := recomputed.Usage(user)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), usage.Used)
+	}
+}
+
+func TestFixedLimit(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.DB(t)
+
+	user := &models.User{Email: "test@test.com"}
+	assert.NoError(t, db.Create(user).Error)
+	// the limit of the user doesn't matter, only the one we pass in
+	assert.NoError(t, db.Create(&models.Quota{UserID: user.ID, Unlimited: true}).Error)
+
+	_, err := Limit(memory.NewStorageProvider(), db, 0)
+	assert.Error(t, err)
+
+	provider, err := Limit(memory.NewStorageProvider(), db, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := provider.File(ctx, user, "file")
+	if assert.NoError(t, err) {
+		_, err = file.Write([]byte("12345"))
+		assert.ErrorIs(t, err, storage.ErrQuotaExceeded)
+		assert.NoError(t, file.Close())
+	}
+
+	usage, err := provider.Usage(user)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(4), usage.Limit)
+		assert.False(t, usage.Unlimited)
+	}
+}
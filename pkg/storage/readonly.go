@@ -2,13 +2,10 @@ package storage
 
 import (
 	"context"
-	"errors"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 )
 
-var ErrReadOnly = errors.New("Readonly storage")
-
 type ReadonlyStorage struct {
 	proxy StorageProvider
 }
@@ -29,16 +26,32 @@ func (r *ReadonlyStorage) Move(ctx context.Context, user *models.User, src strin
 	return ErrReadOnly
 }
 
+func (r *ReadonlyStorage) Copy(ctx context.Context, user *models.User, src string, dst string) error {
+	return ErrReadOnly
+}
+
 func (r *ReadonlyStorage) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan FileInfo, error) {
 	return r.proxy.ListDirectory(ctx, user, path)
 }
 
+func (r *ReadonlyStorage) List(ctx context.Context, user *models.User, path string, opts ListOptions) (<-chan FileInfo, error) {
+	return List(ctx, r.proxy, user, path, opts)
+}
+
+func (r *ReadonlyStorage) Stat(ctx context.Context, user *models.User, fullpath string) (FileInfo, error) {
+	return r.proxy.Stat(ctx, user, fullpath)
+}
+
 func (r *ReadonlyStorage) File(ctx context.Context, user *models.User, fullpath string) (File, error) {
 	file, err := r.proxy.File(ctx, user, fullpath)
 	if err != nil {
 		return nil, err
 	}
 
+	if seekable, ok := file.(SeekableFile); ok {
+		return &readOnlySeekableFile{readOnlyFile: readOnlyFile{proxy: file}, seeker: seekable}, nil
+	}
+
 	return &readOnlyFile{proxy: file}, nil
 }
 
@@ -46,6 +59,22 @@ func (r *ReadonlyStorage) Delete(ctx context.Context, user *models.User, fullpat
 	return ErrReadOnly
 }
 
+func (r *ReadonlyStorage) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	return ErrReadOnly
+}
+
+func (r *ReadonlyStorage) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	return GetMetadata(ctx, r.proxy, user, fullpath, key)
+}
+
+func (r *ReadonlyStorage) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	return ErrReadOnly
+}
+
+func (r *ReadonlyStorage) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	return ListMetadata(ctx, r.proxy, user, fullpath)
+}
+
 type readOnlyFile struct {
 	proxy File
 }
@@ -61,3 +90,24 @@ func (r *readOnlyFile) Close() error {
 func (r *readOnlyFile) Write(p []byte) (n int, err error) {
 	return -1, ErrReadOnly
 }
+
+type readOnlySeekableFile struct {
+	readOnlyFile
+	seeker SeekableFile
+}
+
+func (r *readOnlySeekableFile) ReadAt(p []byte, off int64) (n int, err error) {
+	return r.seeker.ReadAt(p, off)
+}
+
+func (r *readOnlySeekableFile) WriteAt(p []byte, off int64) (n int, err error) {
+	return -1, ErrReadOnly
+}
+
+func (r *readOnlySeekableFile) Seek(offset int64, whence int) (int64, error) {
+	return r.seeker.Seek(offset, whence)
+}
+
+func (r *ReadonlyStorage) Watch(ctx context.Context, user *models.User) (<-chan Event, error) {
+	return Watch(ctx, r.proxy, user)
+}
@@ -0,0 +1,60 @@
+package search
+
+import (
+	"context"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// searchFile updates the index once the file is closed after being written to
+type searchFile struct {
+	proxy    storage.File
+	provider *SearchStorageProvider
+	user     *models.User
+	fullpath string
+
+	written bool
+}
+
+func (f *searchFile) Read(p []byte) (int, error) {
+	return f.proxy.Read(p)
+}
+
+func (f *searchFile) Write(p []byte) (int, error) {
+	f.written = true
+	return f.proxy.Write(p)
+}
+
+func (f *searchFile) Close() error {
+	err := f.proxy.Close()
+	if err == nil && f.written {
+		// the file is there now no matter what happened to the request that opened it, so it gets indexed
+		// even if that one got cancelled in the meantime
+		logIndexError(f.provider.update(context.Background(), f.user, f.fullpath), f.fullpath)
+	}
+	return err
+}
+
+// Abort leaves the index alone, as the file is left as it was
+func (f *searchFile) Abort() error {
+	return storage.Abort(f.proxy)
+}
+
+type searchSeekableFile struct {
+	*searchFile
+	seeker storage.SeekableFile
+}
+
+func (f *searchSeekableFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.seeker.ReadAt(p, off)
+}
+
+func (f *searchSeekableFile) WriteAt(p []byte, off int64) (int, error) {
+	f.written = true
+	return f.seeker.WriteAt(p, off)
+}
+
+func (f *searchSeekableFile) Seek(offset int64, whence int) (int64, error) {
+	return f.seeker.Seek(offset, whence)
+}
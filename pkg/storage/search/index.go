@@ -0,0 +1,340 @@
+package search
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// analyzeBatchSize is the amount of entries we load at once to analyze, and remove at once when stale
+const analyzeBatchSize = 100
+
+// inTree returns a query matching fullpath and everything inside of it. As '0' comes right after '/',
+// everything inside of the directory sorts between "fullpath/" and "fullpath0"
+func inTree(tx *gorm.DB, user *models.User, fullpath string) *gorm.DB {
+	prefix := strings.TrimSuffix(fullpath, "/") + "/"
+	end := strings.TrimSuffix(prefix, "/") + "0"
+	return tx.Where("user_id = ? AND (path = ? OR (path >= ? AND path < ?))", user.ID, fullpath, prefix, end)
+}
+
+// wake lets the Indexer know there's something new to analyze
+func (s *SearchStorageProvider) wake() {
+	select {
+	case s.pending <- struct{}{}:
+	default:
+	}
+}
+
+// update brings the entry of fullpath in line with what's actually stored
+func (s *SearchStorageProvider) update(ctx context.Context, user *models.User, fullpath string) error {
+	info, err := s.proxy.Stat(ctx, user, fullpath)
+	if errors.Is(err, storage.ErrNotExist) {
+		return s.remove(ctx, user, fullpath)
+	} else if err != nil {
+		return err
+	}
+	return s.save(s.db.WithContext(ctx), user, fullpath, info)
+}
+
+// save stores info as the entry of fullpath, files that changed have to be analyzed again
+func (s *SearchStorageProvider) save(tx *gorm.DB, user *models.User, fullpath string, info storage.FileInfo) error {
+	entry := Entry{}
+	res := tx.Limit(1).Find(&entry, "user_id = ? AND path = ?", user.ID, fullpath)
+	if res.Error != nil {
+		return res.Error
+	}
+
+	updatedAt := info.UpdatedAt.UTC()
+	if res.RowsAffected > 0 && entry.Directory == info.Directory && entry.Size == info.Size && entry.UpdatedAt.Equal(updatedAt) {
+		return nil
+	}
+
+	entry.UserID = user.ID
+	entry.Path = fullpath
+	entry.Name = path.Base(fullpath)
+	entry.Directory = info.Directory
+	entry.Size = info.Size
+	entry.CreatedAt = info.CreatedAt.UTC()
+	entry.UpdatedAt = updatedAt
+	entry.Mime = ""
+	entry.Analyzed = info.Directory
+	entry.Revision++
+
+	if entry.ID != 0 {
+		err := tx.Delete(&Hash{}, "entry_id = ?", entry.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	err := tx.Save(&entry).Error
+	if err != nil {
+		return err
+	}
+
+	if !entry.Analyzed {
+		s.wake()
+	}
+	return nil
+}
+
+// remove drops fullpath and everything in it from the index
+func (s *SearchStorageProvider) remove(ctx context.Context, user *models.User, fullpath string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return removeTree(tx, user, fullpath)
+	})
+}
+
+func removeTree(tx *gorm.DB, user *models.User, fullpath string) error {
+	ids := inTree(tx.Model(&Entry{}), user, fullpath).Select("id")
+	err := tx.Where("entry_id IN (?)", ids).Delete(&Hash{}).Error
+	if err != nil {
+		return err
+	}
+	return inTree(tx, user, fullpath).Delete(&Entry{}).Error
+}
+
+// move moves the entries of src and everything in it over to dst, they keep their mime type and hashes
+func (s *SearchStorageProvider) move(ctx context.Context, user *models.User, src, dst string) error {
+	moved := 0
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// whatever we still had at dst can't be there anymore
+		err := removeTree(tx, user, dst)
+		if err != nil {
+			return err
+		}
+
+		var entries []Entry
+		err = inTree(tx, user, src).Find(&entries).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range entries {
+			entry := &entries[i]
+			entry.Path = dst + strings.TrimPrefix(entry.Path, src)
+			entry.Name = path.Base(entry.Path)
+			err = tx.Save(entry).Error
+			if err != nil {
+				return err
+			}
+		}
+		moved = len(entries)
+		return nil
+	})
+	if err != nil || moved > 0 {
+		return err
+	}
+
+	// we never knew about src, so we'll have to look at what ended up at dst
+	return s.sync(ctx, user, dst)
+}
+
+// walk calls fn for fullpath and everything in it, except for the root directory which isn't indexed
+func (s *SearchStorageProvider) walk(ctx context.Context, user *models.User, fullpath string, info storage.FileInfo, fn func(string, storage.FileInfo) error) error {
+	if fullpath != "/" {
+		err := fn(fullpath, info)
+		if err != nil {
+			return err
+		}
+	}
+	if !info.Directory {
+		return nil
+	}
+
+	files, err := s.proxy.ListDirectory(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	// we read the whole directory first, so we don't keep it open while going deeper
+	children := make([]storage.FileInfo, 0)
+	for file := range files {
+		children = append(children, file)
+	}
+
+	for _, child := range children {
+		err = s.walk(ctx, user, path.Join(fullpath, child.Name), child, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sync brings the index of fullpath and everything in it in line with what's actually stored
+func (s *SearchStorageProvider) sync(ctx context.Context, user *models.User, fullpath string) error {
+	info, err := s.proxy.Stat(ctx, user, fullpath)
+	if errors.Is(err, storage.ErrNotExist) {
+		return s.remove(ctx, user, fullpath)
+	} else if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{})
+	err = s.walk(ctx, user, fullpath, info, func(fullpath string, info storage.FileInfo) error {
+		seen[fullpath] = struct{}{}
+		return s.save(s.db.WithContext(ctx), user, fullpath, info)
+	})
+	if err != nil {
+		return err
+	}
+
+	// anything we didn't come across isn't there anymore
+	var entries []Entry
+	err = inTree(s.db.WithContext(ctx), user, fullpath).Select("id", "path").Find(&entries).Error
+	if err != nil {
+		return err
+	}
+	stale := make([]uint64, 0)
+	for _, entry := range entries {
+		if _, ok := seen[entry.Path]; !ok {
+			stale = append(stale, entry.ID)
+		}
+	}
+
+	for len(stale) > 0 {
+		batch := stale
+		if len(batch) > analyzeBatchSize {
+			batch = batch[:analyzeBatchSize]
+		}
+		stale = stale[len(batch):]
+
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Delete(&Hash{}, "entry_id IN ?", batch).Error
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&Entry{}, "id IN ?", batch).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Indexer first brings the index in line with what's actually stored for every user, as anything could have
+// changed while we weren't running. After which it keeps analyzing new and changed files until ctx is done
+func (s *SearchStorageProvider) Indexer(ctx context.Context) {
+	var users []models.User
+	err := s.db.WithContext(ctx).Find(&users).Error
+	if err != nil {
+		logrus.Errorf("Failed to load the users to rebuild the search index of: %s", err)
+	}
+
+	for i := range users {
+		err = s.sync(ctx, &users[i], "/")
+		if err != nil {
+			logrus.Errorf("Failed to rebuild the search index of user %d: %s", users[i].ID, err)
+		}
+	}
+
+	for {
+		s.analyzePending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.pending:
+		}
+	}
+}
+
+// analyzePending analyzes everything waiting to be analyzed
+func (s *SearchStorageProvider) analyzePending(ctx context.Context) {
+	for ctx.Err() == nil {
+		var entries []Entry
+		err := s.db.WithContext(ctx).Where("analyzed = ? AND directory = ?", false, false).
+			Order("id").Limit(analyzeBatchSize).Find(&entries).Error
+		if err != nil {
+			logrus.Errorf("Failed to load the files to analyze: %s", err)
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+
+		for i := range entries {
+			err = s.analyze(ctx, &entries[i])
+			if err != nil {
+				// we'd only keep running into the same error
+				logIndexError(err, entries[i].Path)
+				return
+			}
+		}
+	}
+}
+
+// analyze fills in the mime type and hashes of entry
+func (s *SearchStorageProvider) analyze(ctx context.Context, entry *Entry) error {
+	user := &models.User{ID: entry.UserID}
+
+	mime, hashes, err := s.contents(ctx, user, entry.Path)
+	if errors.Is(err, storage.ErrNotExist) {
+		return s.remove(ctx, user, entry.Path)
+	} else if err != nil {
+		// we still mark it as analyzed below, otherwise we'd keep trying forever
+		logrus.Warnf("Failed to analyze %s of user %d: %s", entry.Path, entry.UserID, err)
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Entry{}).Where("id = ? AND revision = ?", entry.ID, entry.Revision).
+			Updates(map[string]interface{}{"mime": mime, "analyzed": true})
+		if res.Error != nil || res.RowsAffected == 0 {
+			// in the latter case it changed while we were reading it, so it's up for analysis again anyway
+			return res.Error
+		}
+
+		err := tx.Delete(&Hash{}, "entry_id = ?", entry.ID).Error
+		if err != nil || len(hashes) == 0 {
+			return err
+		}
+		for i := range hashes {
+			hashes[i].EntryID = entry.ID
+		}
+		return tx.Create(&hashes).Error
+	})
+}
+
+// contents reads fullpath to determine its mime type and hashes
+func (s *SearchStorageProvider) contents(ctx context.Context, user *models.User, fullpath string) (string, []Hash, error) {
+	// not every provider fails to open a file that doesn't exist
+	_, err := s.proxy.Stat(ctx, user, fullpath)
+	if err != nil {
+		return "", nil, err
+	}
+	file, err := s.proxy.File(ctx, user, fullpath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	if len(s.hashes) == 0 {
+		mime, err := s.fileinfo.MimeType(fullpath, file)
+		if err != nil {
+			return "", nil, err
+		}
+		return mime.String(), nil, nil
+	}
+
+	out, err := s.fileinfo.FileInfo(fullpath, file, &fileinfo.Options{}, s.hashes...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	hashes := make([]Hash, 0, len(s.hashes))
+	for result := range out.Channel {
+		if result.Name == "mime" || result.Err != nil {
+			continue
+		}
+		hashes = append(hashes, Hash{Provider: result.Name, Value: hex.EncodeToString(result.Data)})
+	}
+	return out.MimeType.String(), hashes, nil
+}
@@ -0,0 +1,36 @@
+package search
+
+import "time"
+
+// Entry is a single file or directory of a user as far as the index knows, the times are stored in UTC
+// so they can be compared within the database
+type Entry struct {
+	ID        uint64 `gorm:"primaryKey;autoIncrement"`
+	UserID    uint64 `gorm:"index:search_entry_path_idx,unique"`
+	Path      string `gorm:"index:search_entry_path_idx,unique"`
+	Name      string `gorm:"index:search_entry_name_idx"`
+	Directory bool
+	Size      uint64
+	Mime      string
+	CreatedAt time.Time `gorm:"autoCreateTime:false"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false"`
+	// Analyzed is false as long as the contents still have to be looked at for the mime type and hashes,
+	// Revision goes up with every change so we don't mark a file as analyzed that changed in the meantime
+	Analyzed bool `gorm:"index:search_entry_analyzed_idx"`
+	Revision uint64
+}
+
+func (Entry) TableName() string {
+	return "search_entries"
+}
+
+// Hash is the hex encoded result of one of the fileinfo providers for the contents of an Entry
+type Hash struct {
+	EntryID  uint64 `gorm:"primaryKey"`
+	Provider string `gorm:"primaryKey"`
+	Value    string `gorm:"index:search_hash_value_idx"`
+}
+
+func (Hash) TableName() string {
+	return "search_hashes"
+}
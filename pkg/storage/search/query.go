@@ -0,0 +1,116 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo/types"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+// Query is what to search for, anything left empty matches everything
+type Query struct {
+	// Dir limits the search to everything within this directory
+	Dir string
+	// Name matches any part of the name, ignoring the case
+	Name string
+	// Mime is a pattern as accepted by types.MimeType.Match, like image/png or image/*
+	Mime string
+	// Hash is the hex encoded result of any of the indexed hashes
+	Hash string
+	// MinSize and MaxSize are inclusive, a zero MaxSize means there's no maximum. Directories are never
+	// included when searching by size, as their size means something else for every provider
+	MinSize uint64
+	MaxSize uint64
+	// After and Before limit the time the file was last modified, After is inclusive while Before isn't
+	After  time.Time
+	Before time.Time
+	// Limit is the maximum amount of results to return, 0 means no limit
+	Limit int
+}
+
+// Result is a single match, Mime is empty for directories and files which haven't been analyzed yet
+type Result struct {
+	storage.FileInfo
+	Mime string `json:"mime,omitempty"`
+}
+
+// escapeLike escapes the wildcards of LIKE, so they're matched literally using ESCAPE '\'
+func escapeLike(in string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(in)
+}
+
+// Search returns everything of user matching query, sorted by path
+func (s *SearchStorageProvider) Search(ctx context.Context, user *models.User, query Query) ([]Result, error) {
+	tx := s.db.WithContext(ctx).Where("user_id = ?", user.ID)
+
+	if dir := cleanPath(query.Dir); dir != "/" {
+		// we don't want the directory itself, just what's in it
+		tx = inTree(tx, user, dir).Where("path != ?", dir)
+	}
+	if query.Name != "" {
+		tx = tx.Where(`name LIKE ? ESCAPE '\'`, "%"+escapeLike(query.Name)+"%")
+	}
+	if query.Mime != "" {
+		mime, err := types.ParseMime(query.Mime)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid mime type %s", storage.ErrInvalidPath, query.Mime)
+		}
+		// the database narrows it down to the right type, the rest is up to MimeType.Match
+		tx = tx.Where(`mime LIKE ? ESCAPE '\'`, escapeLike(mime.Type)+"/%")
+	}
+	if query.Hash != "" {
+		hashes := s.db.Model(&Hash{}).Select("entry_id").Where("value = ?", strings.ToLower(query.Hash))
+		tx = tx.Where("id IN (?)", hashes)
+	}
+	if query.MinSize > 0 || query.MaxSize > 0 {
+		tx = tx.Where("directory = ? AND size >= ?", false, query.MinSize)
+		if query.MaxSize > 0 {
+			tx = tx.Where("size <= ?", query.MaxSize)
+		}
+	}
+	if !query.After.IsZero() {
+		tx = tx.Where("updated_at >= ?", query.After.UTC())
+	}
+	if !query.Before.IsZero() {
+		tx = tx.Where("updated_at < ?", query.Before.UTC())
+	}
+	if query.Limit > 0 && query.Mime == "" {
+		tx = tx.Limit(query.Limit)
+	}
+
+	var entries []Entry
+	err := tx.Order("path").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		if query.Limit > 0 && len(out) >= query.Limit {
+			break
+		}
+		if query.Mime != "" {
+			mime, err := types.ParseMime(entry.Mime)
+			if err != nil || !mime.Match(query.Mime) {
+				continue
+			}
+		}
+
+		out = append(out, Result{
+			FileInfo: storage.FileInfo{
+				Name:      entry.Name,
+				FullPath:  entry.Path,
+				CreatedAt: entry.CreatedAt,
+				UpdatedAt: entry.UpdatedAt,
+				Size:      entry.Size,
+				Directory: entry.Directory,
+			},
+			Mime: entry.Mime,
+		})
+	}
+	return out, nil
+}
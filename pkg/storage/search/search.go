@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Config enables the search index, Hashes are the fileinfo providers whose results get indexed next to
+// the mime type. Leaving them empty only indexes the mime type, which is a lot cheaper for large files
+type Config struct {
+	Enabled bool     `yaml:"enabled"`
+	Hashes  []string `yaml:"hashes"`
+}
+
+// SearchStorageProvider keeps an index of the files of every user in the database, so they can be found
+// by name, mime type, size, date and hash without walking the whole tree. Names, sizes and dates are kept
+// up to date right away, the mime type and hashes are filled in by Indexer as they require reading the
+// contents of the file
+type SearchStorageProvider struct {
+	proxy    storage.StorageProvider
+	db       *gorm.DB
+	fileinfo *fileinfo.Manager
+	hashes   []string
+
+	// wakes up the Indexer whenever there's something new to analyze
+	pending chan struct{}
+}
+
+func Index(provider storage.StorageProvider, db *gorm.DB, manager *fileinfo.Manager, config Config) (*SearchStorageProvider, error) {
+	err := db.AutoMigrate(&Entry{}, &Hash{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SearchStorageProvider{
+		proxy:    provider,
+		db:       db,
+		fileinfo: manager,
+		hashes:   config.Hashes,
+		pending:  make(chan struct{}, 1),
+	}, nil
+}
+
+func (s *SearchStorageProvider) Unwrap() storage.StorageProvider {
+	return s.proxy
+}
+
+func cleanPath(fullpath string) string {
+	return path.Join("/", fullpath)
+}
+
+// logIndexError logs err, as the operation itself did succeed we don't want to fail it just because
+// the index is lagging behind. Whatever we missed is picked up again by the next rebuild
+func logIndexError(err error, fullpath string) {
+	if err != nil {
+		logrus.Errorf("Failed to update the search index for %s: %s", fullpath, err)
+	}
+}
+
+func (s *SearchStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return s.proxy.InitUser(ctx, user)
+}
+
+func (s *SearchStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	err := s.proxy.Mkdir(ctx, user, dir)
+	if err != nil {
+		return err
+	}
+
+	// any of the parents may have been created as well
+	for dir = cleanPath(dir); dir != "/"; dir = path.Dir(dir) {
+		logIndexError(s.update(ctx, user, dir), dir)
+	}
+	return nil
+}
+
+func (s *SearchStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := s.proxy.Move(ctx, user, src, dst)
+	if err != nil {
+		return err
+	}
+
+	logIndexError(s.move(ctx, user, cleanPath(src), cleanPath(dst)), dst)
+	return nil
+}
+
+func (s *SearchStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	err := storage.Copy(ctx, s.proxy, user, src, dst)
+	if err != nil {
+		return err
+	}
+
+	// the copies need to be analyzed again anyway, as we can't be sure the index of src is up to date
+	logIndexError(s.sync(ctx, user, cleanPath(dst)), dst)
+	return nil
+}
+
+func (s *SearchStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	return s.proxy.ListDirectory(ctx, user, dir)
+}
+
+func (s *SearchStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	return storage.List(ctx, s.proxy, user, dir, opts)
+}
+
+func (s *SearchStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	return s.proxy.Stat(ctx, user, fullpath)
+}
+
+func (s *SearchStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	file, err := s.proxy.File(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &searchFile{
+		proxy:    file,
+		provider: s,
+		user:     user,
+		fullpath: cleanPath(fullpath),
+	}
+	if seeker, ok := file.(storage.SeekableFile); ok {
+		return &searchSeekableFile{searchFile: out, seeker: seeker}, nil
+	}
+	return out, nil
+}
+
+func (s *SearchStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	err := s.proxy.Delete(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	logIndexError(s.remove(ctx, user, cleanPath(fullpath)), fullpath)
+	return nil
+}
+
+func (s *SearchStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := s.proxy.DeleteTree(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	logIndexError(s.remove(ctx, user, cleanPath(fullpath)), fullpath)
+	return nil
+}
+
+func (s *SearchStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	return storage.Watch(ctx, s.proxy, user)
+}
+
+func (s *SearchStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(s.proxy)
+}
+
+func (s *SearchStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	return storage.GetMetadata(ctx, s.proxy, user, fullpath, key)
+}
+
+func (s *SearchStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	return storage.SetMetadata(ctx, s.proxy, user, fullpath, key, value)
+}
+
+func (s *SearchStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	return storage.ListMetadata(ctx, s.proxy, user, fullpath)
+}
@@ -0,0 +1,193 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/fileinfo"
+	_ "github.com/leicht-cloud/leicht-cloud/pkg/fileinfo/builtin"
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/prometheus"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var promManager, _ = (&prometheus.Config{Enabled: false}).Create()
+
+func setupProvider(t *testing.T, provider storage.StorageProvider) *SearchStorageProvider {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager, err := fileinfo.NewManager(nil, promManager, "gonative", "sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Index(provider, db, manager, Config{Enabled: true, Hashes: []string{"sha256"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestStorage(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		storage.TestStorageProvider(setupProvider(t, local.NewStorageProvider(t.TempDir())), t)
+	})
+	t.Run("Memory", func(t *testing.T) {
+		storage.TestStorageProvider(setupProvider(t, memory.NewStorageProvider()), t)
+	})
+}
+
+func sha256Hex(contents string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(contents)))
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	memfs := memory.NewStorageProvider()
+	provider := setupProvider(t, memfs)
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 24)
+	assert.NoError(t, provider.Mkdir(ctx, user, "/docs"))
+	assert.NoError(t, provider.Mkdir(ctx, user, "/empty"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/docs/report.txt", "quarterly report"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/docs/photo.png", png))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/big.bin", strings.Repeat("x", 1000)))
+	provider.analyzePending(ctx)
+
+	search := func(t *testing.T, query Query) []string {
+		results, err := provider.Search(ctx, user, query)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		paths := []string{}
+		for _, result := range results {
+			paths = append(paths, result.FullPath)
+		}
+		return paths
+	}
+
+	t.Run("Everything", func(t *testing.T) {
+		assert.Equal(t, []string{"/big.bin", "/docs", "/docs/photo.png", "/docs/report.txt", "/empty"}, search(t, Query{}))
+		assert.Equal(t, []string{"/big.bin", "/docs"}, search(t, Query{Limit: 2}))
+		assert.Equal(t, []string{"/docs/photo.png", "/docs/report.txt"}, search(t, Query{Dir: "/docs"}))
+		assert.Empty(t, search(t, Query{Dir: "/do"}))
+	})
+
+	t.Run("Name", func(t *testing.T) {
+		assert.Equal(t, []string{"/docs/report.txt"}, search(t, Query{Name: "REPORT"}))
+		// wildcards are taken literally
+		assert.Empty(t, search(t, Query{Name: "%"}))
+		assert.Empty(t, search(t, Query{Name: "re_ort"}))
+	})
+
+	t.Run("Mime", func(t *testing.T) {
+		results, err := provider.Search(ctx, user, Query{Mime: "image/*"})
+		if assert.NoError(t, err) && assert.Len(t, results, 1) {
+			assert.Equal(t, "/docs/photo.png", results[0].FullPath)
+			assert.Equal(t, "image/png", results[0].Mime)
+		}
+		assert.Equal(t, []string{"/docs/photo.png"}, search(t, Query{Mime: "image/png", Limit: 1}))
+		assert.Empty(t, search(t, Query{Mime: "image/jpeg"}))
+
+		_, err = provider.Search(ctx, user, Query{Mime: "bogus"})
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		assert.Equal(t, []string{"/docs/report.txt"}, search(t, Query{Hash: strings.ToUpper(sha256Hex("quarterly report"))}))
+		assert.Empty(t, search(t, Query{Hash: sha256Hex("something else")}))
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		assert.Equal(t, []string{"/big.bin"}, search(t, Query{MinSize: 100}))
+		assert.Equal(t, []string{"/docs/report.txt"}, search(t, Query{MaxSize: 20}))
+		assert.Equal(t, []string{"/docs/photo.png", "/docs/report.txt"}, search(t, Query{MinSize: 1, MaxSize: 32}))
+	})
+
+	t.Run("Date", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		assert.Empty(t, search(t, Query{After: later}))
+		assert.Len(t, search(t, Query{Before: later.In(time.FixedZone("elsewhere", 5*3600))}), 5)
+		assert.Empty(t, search(t, Query{Before: time.Now().Add(-time.Hour)}))
+	})
+
+	t.Run("Changes", func(t *testing.T) {
+		assert.NoError(t, provider.Move(ctx, user, "/docs", "/papers"))
+		results, err := provider.Search(ctx, user, Query{Name: "report"})
+		if assert.NoError(t, err) && assert.Len(t, results, 1) {
+			// moving doesn't require analyzing it again
+			assert.Equal(t, "/papers/report.txt", results[0].FullPath)
+			assert.Equal(t, "report.txt", results[0].Name)
+			assert.Equal(t, "application/octet-stream", results[0].Mime)
+		}
+
+		assert.NoError(t, provider.Copy(ctx, user, "/papers/report.txt", "/copy.txt"))
+		provider.analyzePending(ctx)
+		assert.Equal(t, []string{"/copy.txt", "/papers/report.txt"}, search(t, Query{Hash: sha256Hex("quarterly report")}))
+
+		assert.NoError(t, provider.DeleteTree(ctx, user, "/papers"))
+		assert.Empty(t, search(t, Query{Name: "report"}))
+		assert.Equal(t, []string{"/copy.txt"}, search(t, Query{Hash: sha256Hex("quarterly report")}))
+
+		// the old hash is gone right away, the new one is there once it's analyzed
+		assert.NoError(t, storagetest.WriteFile(provider, user, "/copy.txt", "rewritten"))
+		assert.Empty(t, search(t, Query{Hash: sha256Hex("quarterly report")}))
+		provider.analyzePending(ctx)
+		assert.Equal(t, []string{"/copy.txt"}, search(t, Query{Hash: sha256Hex("rewritten")}))
+	})
+
+	t.Run("Rebuild", func(t *testing.T) {
+		// changed without going through the index
+		memfs.Data["/sneaky"] = []byte("sneaky")
+		delete(memfs.Data, "/copy.txt")
+
+		assert.NoError(t, provider.sync(ctx, user, "/"))
+		assert.Equal(t, []string{"/sneaky"}, search(t, Query{Name: "sneaky"}))
+		assert.Empty(t, search(t, Query{Name: "copy"}))
+	})
+}
+
+func TestIndexer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	provider := setupProvider(t, memory.NewStorageProvider())
+	assert.NoError(t, models.InitModels(provider.db))
+	assert.NoError(t, provider.db.Create(user).Error)
+
+	// this one was already there before we started indexing
+	assert.NoError(t, storagetest.WriteFile(provider.proxy, user, "/existing", "existing"))
+
+	done := make(chan struct{})
+	go func() {
+		provider.Indexer(ctx)
+		close(done)
+	}()
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/new", "new"))
+	for _, contents := range []string{"existing", "new"} {
+		assert.Eventually(t, func() bool {
+			results, err := provider.Search(ctx, user, Query{Hash: sha256Hex(contents)})
+			return err == nil && len(results) == 1
+		}, 5*time.Second, 10*time.Millisecond, contents)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+)
+
+// ReadSeeker opens fullpath of size bytes for reading with random access, even if the File returned by provider
+// doesn't implement SeekableFile. In that case seeking forward reads up to the requested offset and seeking
+// backward opens the file again, so this is only efficient for reading mostly from the start
+func ReadSeeker(ctx context.Context, provider StorageProvider, user *models.User, fullpath string, size int64) (io.ReadSeekCloser, error) {
+	file, err := provider.File(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return &readSeeker{
+		ctx:      ctx,
+		provider: provider,
+		user:     user,
+		fullpath: fullpath,
+		size:     size,
+		file:     file,
+	}, nil
+}
+
+type readSeeker struct {
+	ctx      context.Context
+	provider StorageProvider
+	user     *models.User
+	fullpath string
+	size     int64
+
+	file File
+	// pos is where the next read should start, offset is where file actually is.
+	// Seeking only moves pos, file catches up once it gets read from
+	pos, offset int64
+}
+
+func (r *readSeeker) Read(p []byte) (int, error) {
+	_, seekable := r.file.(SeekableFile)
+	if r.file != nil && r.pos < r.offset && !seekable {
+		// there's no going back, so we start over
+		err := r.file.Close()
+		r.file = nil
+		if err != nil {
+			return 0, err
+		}
+	}
+	if r.file == nil {
+		file, err := r.provider.File(r.ctx, r.user, r.fullpath)
+		if err != nil {
+			return 0, err
+		}
+		r.file, r.offset = file, 0
+	}
+
+	if r.pos != r.offset {
+		if seeker, ok := r.file.(SeekableFile); ok {
+			offset, err := seeker.Seek(r.pos, io.SeekStart)
+			r.offset = offset
+			if err != nil {
+				return 0, err
+			}
+		} else {
+			n, err := io.CopyN(io.Discard, r.file, r.pos-r.offset)
+			r.offset += n
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err := r.file.Read(p)
+	r.offset += int64(n)
+	r.pos = r.offset
+	return n, err
+}
+
+func (r *readSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return r.pos, fmt.Errorf("Invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return r.pos, fmt.Errorf("Negative offset %d", offset)
+	}
+	r.pos = offset
+	return r.pos, nil
+}
+
+func (r *readSeeker) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
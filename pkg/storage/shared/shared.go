@@ -0,0 +1,602 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"gorm.io/gorm"
+)
+
+// SharedDir is where the shared folders of the groups of a user show up, it only exists for users with
+// access to at least one of them. It hides anything the user stored at this path themselves
+const SharedDir = "/Shared"
+
+// SharedWithMeDir is where the models.UserShare a user accepted show up, it only exists for users that
+// accepted at least one of them. Like SharedDir it hides anything the user stored at this path themselves
+const SharedWithMeDir = "/Shared with me"
+
+// virtualDirs are the directories in the root of every user that only exist in here
+var virtualDirs = []string{SharedDir, SharedWithMeDir}
+
+// Owner is who the shared folders are stored for on the underlying provider, as they belong to a group
+// rather than to any single user. Every folder is a directory named after its id in the root of this user,
+// so renaming one doesn't have to touch its files. Real users never get this id as those start at 1
+var Owner = &models.User{ID: 0}
+
+// IsOwner reports whether user is Owner, whose files belong to the groups rather than to an account
+func IsOwner(user *models.User) bool {
+	return user.ID == Owner.ID
+}
+
+// SharedStorageProvider maps /Shared/<name> onto the files of the models.SharedFolder with that name, for
+// every member of the group it belongs to. Members with the read role get a read-only view of it.
+// It maps "/Shared with me/<name>" onto the files of whoever shared them with the user directly in the same way
+type SharedStorageProvider struct {
+	proxy storage.StorageProvider
+	db    *gorm.DB
+
+	// the folders that are known to exist on proxy, so we only have to create them once
+	mutex   sync.Mutex
+	created map[uint64]bool
+}
+
+func Shared(provider storage.StorageProvider, db *gorm.DB) *SharedStorageProvider {
+	return &SharedStorageProvider{
+		proxy:   provider,
+		db:      db,
+		created: make(map[uint64]bool),
+	}
+}
+
+func (s *SharedStorageProvider) Unwrap() storage.StorageProvider {
+	return s.proxy
+}
+
+// access is a shared folder as a specific user is able to see it
+type access struct {
+	FolderID uint64
+	Name     string
+	Role     models.GroupRole
+}
+
+// folders returns the shared folders user has access to, limited to the one called name if that isn't empty
+func (s *SharedStorageProvider) folders(ctx context.Context, user *models.User, name string) ([]access, error) {
+	tx := s.db.WithContext(ctx).
+		Model(&models.SharedFolder{}).
+		Select("shared_folders.id AS folder_id, shared_folders.name AS name, group_members.role AS role").
+		Joins("JOIN group_members ON group_members.group_id = shared_folders.group_id").
+		Where("group_members.user_id = ?", user.ID)
+	if name != "" {
+		tx = tx.Where("shared_folders.name = ?", name)
+	}
+
+	var out []access
+	err := tx.Order("shared_folders.name").Scan(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// grants returns the shares user accepted, limited to the one called name if that isn't empty
+func (s *SharedStorageProvider) grants(ctx context.Context, user *models.User, name string) ([]models.UserShare, error) {
+	tx := s.db.WithContext(ctx).Where("recipient_id = ? AND accepted = ?", user.ID, true)
+	if name != "" {
+		tx = tx.Where("name = ?", name)
+	}
+
+	var out []models.UserShare
+	err := tx.Order("name").Find(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// names returns the names of the directories in the virtual directory dir for user
+func (s *SharedStorageProvider) names(ctx context.Context, user *models.User, dir string) ([]string, error) {
+	var out []string
+	switch dir {
+	case SharedDir:
+		folders, err := s.folders(ctx, user, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, folder := range folders {
+			out = append(out, folder.Name)
+		}
+	case SharedWithMeDir:
+		shares, err := s.grants(ctx, user, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, share := range shares {
+			out = append(out, share.Name)
+		}
+	}
+	return out, nil
+}
+
+// folderRoot is where the files of the shared folder with id are stored for Owner
+func folderRoot(id uint64) string {
+	return "/" + strconv.FormatUint(id, 10)
+}
+
+// ensure creates the directory of the shared folder with id if it doesn't exist yet
+func (s *SharedStorageProvider) ensure(ctx context.Context, id uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.created[id] {
+		return nil
+	}
+
+	err := s.proxy.InitUser(ctx, Owner)
+	if err != nil {
+		return err
+	}
+	err = s.proxy.Mkdir(ctx, Owner, folderRoot(id))
+	if err != nil && !errors.Is(err, storage.ErrExist) {
+		return err
+	}
+	s.created[id] = true
+	return nil
+}
+
+// DeleteFolder removes the files of a shared folder that's about to be deleted
+func (s *SharedStorageProvider) DeleteFolder(ctx context.Context, folder *models.SharedFolder) error {
+	s.mutex.Lock()
+	delete(s.created, folder.ID)
+	s.mutex.Unlock()
+
+	err := s.proxy.DeleteTree(ctx, Owner, folderRoot(folder.ID))
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// target is where a path ends up on proxy
+type target struct {
+	provider storage.StorageProvider
+	user     *models.User
+	path     string
+	// folder is the path of the shared folder on the outside, empty for the files of the user themselves
+	folder string
+	root   string
+	// readOnly is set if provider doesn't allow any changes
+	readOnly bool
+}
+
+// virtual reports whether fullpath is one of virtualDirs
+func virtual(fullpath string) bool {
+	fullpath = path.Clean("/" + fullpath)
+	for _, dir := range virtualDirs {
+		if fullpath == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether fullpath is stored by the user at one of virtualDirs, which they can't reach anymore
+func hidden(fullpath string) bool {
+	for _, dir := range virtualDirs {
+		if fullpath == dir || strings.HasPrefix(fullpath, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// fixed reports whether fullpath is one of virtualDirs or one of the directories in there, these can't be
+// created, moved or deleted by the users as that's up to the admins or the users sharing them
+func fixed(fullpath string) bool {
+	fullpath = path.Clean("/" + fullpath)
+	return virtual(fullpath) || virtual(path.Dir(fullpath))
+}
+
+// resolve returns where fullpath is stored, this fails for virtualDirs themselves as they only exist virtually
+func (s *SharedStorageProvider) resolve(ctx context.Context, user *models.User, fullpath string) (target, error) {
+	fullpath = path.Clean("/" + fullpath)
+	if virtual(fullpath) {
+		return target{}, fmt.Errorf("%w: %s", storage.ErrReadOnly, fullpath)
+	}
+
+	for _, dir := range virtualDirs {
+		if !strings.HasPrefix(fullpath, dir+"/") {
+			continue
+		}
+
+		rest := strings.TrimPrefix(fullpath, dir+"/")
+		name := rest
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			name, rest = rest[:i], rest[i:]
+		} else {
+			rest = ""
+		}
+
+		if dir == SharedWithMeDir {
+			return s.resolveShare(ctx, user, fullpath, name, rest)
+		}
+		return s.resolveFolder(ctx, user, fullpath, name, rest)
+	}
+	return target{provider: s.proxy, user: user, path: fullpath}, nil
+}
+
+// resolveFolder returns where rest within the shared folder called name is stored
+func (s *SharedStorageProvider) resolveFolder(ctx context.Context, user *models.User, fullpath, name, rest string) (target, error) {
+	folders, err := s.folders(ctx, user, name)
+	if err != nil {
+		return target{}, err
+	}
+	if len(folders) == 0 {
+		return target{}, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+	err = s.ensure(ctx, folders[0].FolderID)
+	if err != nil {
+		return target{}, err
+	}
+
+	out := target{
+		provider: s.proxy,
+		user:     Owner,
+		path:     folderRoot(folders[0].FolderID) + rest,
+		folder:   path.Join(SharedDir, name),
+		root:     folderRoot(folders[0].FolderID),
+		readOnly: folders[0].Role != models.GroupRoleReadWrite,
+	}
+	if out.readOnly {
+		out.provider = storage.ReadOnly(s.proxy)
+	}
+	return out, nil
+}
+
+// resolveShare returns where rest within the accepted share called name is stored, which is with the
+// user that shared it
+func (s *SharedStorageProvider) resolveShare(ctx context.Context, user *models.User, fullpath, name, rest string) (target, error) {
+	shares, err := s.grants(ctx, user, name)
+	if err != nil {
+		return target{}, err
+	}
+	if len(shares) == 0 {
+		return target{}, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+
+	out := target{
+		provider: s.proxy,
+		user:     &models.User{ID: shares[0].UserID},
+		path:     path.Join(shares[0].Path, rest),
+		folder:   path.Join(SharedWithMeDir, name),
+		root:     shares[0].Path,
+		readOnly: shares[0].Role != models.GroupRoleReadWrite,
+	}
+	if out.readOnly {
+		out.provider = storage.ReadOnly(s.proxy)
+	}
+	return out, nil
+}
+
+// outer turns info as returned for t into what it looks like from the outside
+func (t target) outer(info storage.FileInfo) storage.FileInfo {
+	if t.folder == "" {
+		return info
+	}
+	info.FullPath = path.Join(t.folder, strings.TrimPrefix(info.FullPath, t.root))
+	if info.FullPath == t.folder {
+		info.Name = path.Base(t.folder)
+	}
+	return info
+}
+
+func (t target) translate(ctx context.Context, files <-chan storage.FileInfo) <-chan storage.FileInfo {
+	out := make(chan storage.FileInfo)
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+		for info := range files {
+			select {
+			case out <- t.outer(info):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+	return out
+}
+
+// virtualDirInfo describes the virtual directory dir, which exists as long as there's at least one directory in it
+func (s *SharedStorageProvider) virtualDirInfo(ctx context.Context, user *models.User, dir string) ([]string, storage.FileInfo, error) {
+	names, err := s.names(ctx, user, dir)
+	if err != nil {
+		return nil, storage.FileInfo{}, err
+	}
+	if len(names) == 0 {
+		return nil, storage.FileInfo{}, fmt.Errorf("%w: %s", storage.ErrNotExist, dir)
+	}
+	return names, storage.FileInfo{Name: path.Base(dir), FullPath: dir, Directory: true, Mode: 0555}, nil
+}
+
+func (s *SharedStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return s.proxy.InitUser(ctx, user)
+}
+
+func (s *SharedStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	if fixed(dir) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, dir)
+	}
+	t, err := s.resolve(ctx, user, dir)
+	if err != nil {
+		return err
+	}
+	return t.provider.Mkdir(ctx, t.user, t.path)
+}
+
+// Move is left to the underlying provider within a single shared folder, or within the files of the user
+// themselves. Anything else is copied over and deleted afterwards, as those are different users to it
+func (s *SharedStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	if fixed(src) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, src)
+	}
+	if fixed(dst) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, dst)
+	}
+	srcTarget, err := s.resolve(ctx, user, src)
+	if err != nil {
+		return err
+	}
+	dstTarget, err := s.resolve(ctx, user, dst)
+	if err != nil {
+		return err
+	}
+	if srcTarget.folder == dstTarget.folder {
+		return srcTarget.provider.Move(ctx, srcTarget.user, srcTarget.path, dstTarget.path)
+	}
+	if srcTarget.readOnly {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, src)
+	}
+	if dstTarget.readOnly {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, dst)
+	}
+
+	_, err = dstTarget.provider.Stat(ctx, dstTarget.user, dstTarget.path)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	err = storage.StreamCopy(ctx, s, user, src, dst)
+	if err != nil {
+		// dst didn't exist before, so whatever made it over is ours to clean up
+		cleanupErr := dstTarget.provider.DeleteTree(ctx, dstTarget.user, dstTarget.path)
+		if cleanupErr != nil && !errors.Is(cleanupErr, storage.ErrNotExist) {
+			return fmt.Errorf("%w, leaving %s behind: %s", err, dst, cleanupErr)
+		}
+		return err
+	}
+	return srcTarget.provider.DeleteTree(ctx, srcTarget.user, srcTarget.path)
+}
+
+func (s *SharedStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	if fixed(dst) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, dst)
+	}
+	if !fixed(src) {
+		srcTarget, err := s.resolve(ctx, user, src)
+		if err != nil {
+			return err
+		}
+		dstTarget, err := s.resolve(ctx, user, dst)
+		if err != nil {
+			return err
+		}
+		if srcTarget.folder == dstTarget.folder {
+			return storage.Copy(ctx, dstTarget.provider, dstTarget.user, srcTarget.path, dstTarget.path)
+		}
+	}
+	return storage.StreamCopy(ctx, s, user, src, dst)
+}
+
+func (s *SharedStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	dir = path.Clean("/" + dir)
+	if virtual(dir) {
+		return s.listVirtual(ctx, user, dir)
+	}
+
+	t, err := s.resolve(ctx, user, dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := t.provider.ListDirectory(ctx, t.user, t.path)
+	if err != nil {
+		return nil, err
+	}
+	if dir != "/" {
+		return t.translate(ctx, files), nil
+	}
+
+	// virtualDirs show up right in here, hiding whatever the user has stored with the same name
+	var virtualInfos []storage.FileInfo
+	for _, virtualDir := range virtualDirs {
+		_, info, err := s.virtualDirInfo(ctx, user, virtualDir)
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		virtualInfos = append(virtualInfos, info)
+	}
+
+	out := make(chan storage.FileInfo)
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+	files:
+		for info := range files {
+			for _, virtualInfo := range virtualInfos {
+				if info.Name == virtualInfo.Name {
+					continue files
+				}
+			}
+			select {
+			case out <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+		for _, info := range virtualInfos {
+			select {
+			case out <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+	return out, nil
+}
+
+// listVirtual lists the directories user has in the virtual directory dir
+func (s *SharedStorageProvider) listVirtual(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	names, _, err := s.virtualDirInfo(ctx, user, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan storage.FileInfo)
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+		for _, name := range names {
+			fullpath := path.Join(dir, name)
+			info, err := s.Stat(ctx, user, fullpath)
+			if err != nil {
+				info = storage.FileInfo{Name: name, FullPath: fullpath, Directory: true}
+			}
+			select {
+			case out <- info:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+	return out, nil
+}
+
+// List is left to the underlying provider, other than for virtualDirs and the root they show up in
+func (s *SharedStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	dir = path.Clean("/" + dir)
+	if dir == "/" || virtual(dir) {
+		return storage.StreamList(ctx, s, user, dir, opts)
+	}
+
+	t, err := s.resolve(ctx, user, dir)
+	if err != nil {
+		return nil, err
+	}
+	files, err := storage.List(ctx, t.provider, t.user, t.path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return t.translate(ctx, files), nil
+}
+
+func (s *SharedStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	if virtual(fullpath) {
+		_, info, err := s.virtualDirInfo(ctx, user, path.Clean("/"+fullpath))
+		return info, err
+	}
+
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	info, err := t.provider.Stat(ctx, t.user, t.path)
+	if err != nil {
+		return info, err
+	}
+	return t.outer(info), nil
+}
+
+func (s *SharedStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return t.provider.File(ctx, t.user, t.path)
+}
+
+func (s *SharedStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	if fixed(fullpath) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, fullpath)
+	}
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	return t.provider.Delete(ctx, t.user, t.path)
+}
+
+func (s *SharedStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	if fixed(fullpath) {
+		return fmt.Errorf("%w: %s", storage.ErrReadOnly, fullpath)
+	}
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	return t.provider.DeleteTree(ctx, t.user, t.path)
+}
+
+func (s *SharedStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	if virtual(fullpath) {
+		return "", fmt.Errorf("%w: %s of %s", storage.ErrNotExist, key, fullpath)
+	}
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return "", err
+	}
+	return storage.GetMetadata(ctx, t.provider, t.user, t.path, key)
+}
+
+func (s *SharedStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	return storage.SetMetadata(ctx, t.provider, t.user, t.path, key, value)
+}
+
+func (s *SharedStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	if virtual(fullpath) {
+		return map[string]string{}, nil
+	}
+	t, err := s.resolve(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ListMetadata(ctx, t.provider, t.user, t.path)
+}
+
+// Watch only covers the files of the user themselves, changes made to the shared folders by the other
+// members or to the shares by their owners aren't reported
+func (s *SharedStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	events, err := storage.Watch(ctx, s.proxy, user)
+	if err != nil {
+		return nil, err
+	}
+	return storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+		// whatever the user stored at virtualDirs is hidden
+		return fullpath, !hidden(fullpath)
+	}), nil
+}
+
+func (s *SharedStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(s.proxy)
+}
@@ -0,0 +1,196 @@
+package shared
+
+import (
+	"context"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedStorage(t *testing.T) {
+	provider := Shared(memory.NewStorageProvider(), storagetest.DB(t))
+
+	storage.TestStorageProvider(provider, t)
+}
+
+func listNames(t *testing.T, provider storage.StorageProvider, user *models.User, dir string) []string {
+	files, err := provider.ListDirectory(context.Background(), user, dir)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	var out []string
+	for file := range files {
+		out = append(out, file.Name)
+	}
+	return out
+}
+
+func TestSharedFolders(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.DB(t)
+	// the memory provider doesn't keep the files of different users apart
+	localfs := local.NewStorageProvider(t.TempDir())
+	provider := Shared(localfs, db)
+
+	writer := &models.User{Email: "writer@test.com"}
+	reader := &models.User{Email: "reader@test.com"}
+	outsider := &models.User{Email: "outsider@test.com"}
+	group := &models.Group{Name: "team"}
+	for _, model := range []interface{}{writer, reader, outsider, group} {
+		assert.NoError(t, db.Create(model).Error)
+	}
+	folder := &models.SharedFolder{GroupID: group.ID, Name: "docs"}
+	assert.NoError(t, db.Create(folder).Error)
+	assert.NoError(t, db.Create(&models.GroupMember{GroupID: group.ID, UserID: writer.ID, Role: models.GroupRoleReadWrite}).Error)
+	assert.NoError(t, db.Create(&models.GroupMember{GroupID: group.ID, UserID: reader.ID, Role: models.GroupRoleRead}).Error)
+
+	for _, user := range []*models.User{writer, reader, outsider} {
+		assert.NoError(t, provider.InitUser(ctx, user))
+	}
+
+	assert.NoError(t, storagetest.WriteFile(provider, writer, "/Shared/docs/file", "shared"))
+	assert.NoError(t, storagetest.WriteFile(provider, writer, "/own", "own"))
+
+	t.Run("Storage", func(t *testing.T) {
+		// the files of the folder belong to Owner on the underlying provider
+		assert.Equal(t, "shared", storagetest.ReadFile(t, localfs, Owner, folderRoot(folder.ID)+"/file"))
+		assert.Equal(t, "own", storagetest.ReadFile(t, localfs, writer, "/own"))
+	})
+
+	t.Run("Members", func(t *testing.T) {
+		assert.Equal(t, "shared", storagetest.ReadFile(t, provider, reader, "/Shared/docs/file"))
+		assert.ElementsMatch(t, []string{"Shared"}, listNames(t, provider, reader, "/"))
+		assert.ElementsMatch(t, []string{"docs"}, listNames(t, provider, reader, "/Shared"))
+		assert.ElementsMatch(t, []string{"file"}, listNames(t, provider, reader, "/Shared/docs"))
+
+		info, err := provider.Stat(ctx, reader, "/Shared/docs/file")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/Shared/docs/file", info.FullPath)
+			assert.Equal(t, "file", info.Name)
+		}
+		info, err = provider.Stat(ctx, reader, "/Shared/docs")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/Shared/docs", info.FullPath)
+			assert.Equal(t, "docs", info.Name)
+			assert.True(t, info.Directory)
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.ErrorIs(t, storagetest.WriteFile(provider, reader, "/Shared/docs/file", "overwritten"), storage.ErrReadOnly)
+		assert.ErrorIs(t, provider.Mkdir(ctx, reader, "/Shared/docs/dir"), storage.ErrReadOnly)
+		assert.ErrorIs(t, provider.Delete(ctx, reader, "/Shared/docs/file"), storage.ErrReadOnly)
+		assert.ErrorIs(t, provider.Move(ctx, reader, "/Shared/docs/file", "/file"), storage.ErrReadOnly)
+		assert.Equal(t, "shared", storagetest.ReadFile(t, provider, writer, "/Shared/docs/file"))
+
+		// copying out of the folder is fine though
+		assert.NoError(t, provider.Copy(ctx, reader, "/Shared/docs/file", "/copied"))
+		assert.Equal(t, "shared", storagetest.ReadFile(t, localfs, reader, "/copied"))
+	})
+
+	t.Run("Outsiders", func(t *testing.T) {
+		_, err := provider.Stat(ctx, outsider, "/Shared")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+		_, err = provider.Stat(ctx, outsider, "/Shared/docs/file")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Empty(t, listNames(t, provider, outsider, "/"))
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		for _, fullpath := range []string{"/Shared", "/Shared/docs", "/Shared/new"} {
+			assert.ErrorIs(t, provider.DeleteTree(ctx, writer, fullpath), storage.ErrReadOnly, fullpath)
+			assert.ErrorIs(t, provider.Mkdir(ctx, writer, fullpath), storage.ErrReadOnly, fullpath)
+		}
+		assert.ErrorIs(t, provider.Move(ctx, writer, "/Shared/docs", "/docs"), storage.ErrReadOnly)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		assert.NoError(t, provider.Move(ctx, writer, "/own", "/Shared/docs/moved"))
+		assert.Equal(t, "own", storagetest.ReadFile(t, provider, reader, "/Shared/docs/moved"))
+		_, err := provider.Stat(ctx, writer, "/own")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+
+		assert.NoError(t, provider.Move(ctx, writer, "/Shared/docs/moved", "/Shared/docs/renamed"))
+		assert.Equal(t, "own", storagetest.ReadFile(t, localfs, Owner, folderRoot(folder.ID)+"/renamed"))
+	})
+
+	t.Run("DeleteFolder", func(t *testing.T) {
+		assert.NoError(t, provider.DeleteFolder(ctx, folder))
+		_, err := localfs.Stat(ctx, Owner, folderRoot(folder.ID))
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+	})
+}
+
+func TestUserShares(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.DB(t)
+	localfs := local.NewStorageProvider(t.TempDir())
+	provider := Shared(localfs, db)
+
+	owner := &models.User{Email: "owner@test.com"}
+	writer := &models.User{Email: "writer@test.com"}
+	reader := &models.User{Email: "reader@test.com"}
+	for _, user := range []*models.User{owner, writer, reader} {
+		assert.NoError(t, db.Create(user).Error)
+		assert.NoError(t, provider.InitUser(ctx, user))
+	}
+	assert.NoError(t, provider.Mkdir(ctx, owner, "/projects"))
+	assert.NoError(t, storagetest.WriteFile(provider, owner, "/projects/plan", "plan"))
+	assert.NoError(t, storagetest.WriteFile(provider, owner, "/private", "private"))
+
+	writeShare := &models.UserShare{UserID: owner.ID, Path: "/projects", RecipientID: writer.ID, Name: "projects", Role: models.GroupRoleReadWrite, Accepted: true}
+	readShare := &models.UserShare{UserID: owner.ID, Path: "/projects", RecipientID: reader.ID, Name: "projects", Role: models.GroupRoleRead}
+	assert.NoError(t, db.Create(writeShare).Error)
+	assert.NoError(t, db.Create(readShare).Error)
+
+	t.Run("Pending", func(t *testing.T) {
+		_, err := provider.Stat(ctx, reader, "/Shared with me/projects/plan")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+		assert.Empty(t, listNames(t, provider, reader, "/"))
+
+		assert.NoError(t, db.Model(readShare).Update("accepted", true).Error)
+		assert.ElementsMatch(t, []string{"Shared with me"}, listNames(t, provider, reader, "/"))
+	})
+
+	t.Run("Accepted", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"projects"}, listNames(t, provider, reader, "/Shared with me"))
+		assert.ElementsMatch(t, []string{"plan"}, listNames(t, provider, reader, "/Shared with me/projects"))
+		assert.Equal(t, "plan", storagetest.ReadFile(t, provider, reader, "/Shared with me/projects/plan"))
+
+		info, err := provider.Stat(ctx, reader, "/Shared with me/projects/plan")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/Shared with me/projects/plan", info.FullPath)
+		}
+
+		// nothing outside of the shared directory is reachable
+		_, err = provider.Stat(ctx, reader, "/Shared with me/projects/../private")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+	})
+
+	t.Run("ReadWrite", func(t *testing.T) {
+		assert.NoError(t, storagetest.WriteFile(provider, writer, "/Shared with me/projects/notes", "notes"))
+		assert.Equal(t, "notes", storagetest.ReadFile(t, localfs, owner, "/projects/notes"))
+
+		assert.NoError(t, provider.Move(ctx, writer, "/Shared with me/projects/notes", "/notes"))
+		assert.Equal(t, "notes", storagetest.ReadFile(t, localfs, writer, "/notes"))
+		_, err := localfs.Stat(ctx, owner, "/projects/notes")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.ErrorIs(t, storagetest.WriteFile(provider, reader, "/Shared with me/projects/plan", "overwritten"), storage.ErrReadOnly)
+		assert.ErrorIs(t, provider.Delete(ctx, reader, "/Shared with me/projects/plan"), storage.ErrReadOnly)
+		assert.Equal(t, "plan", storagetest.ReadFile(t, localfs, owner, "/projects/plan"))
+	})
+
+	t.Run("Fixed", func(t *testing.T) {
+		for _, fullpath := range []string{"/Shared with me", "/Shared with me/projects"} {
+			assert.ErrorIs(t, provider.DeleteTree(ctx, writer, fullpath), storage.ErrReadOnly, fullpath)
+		}
+	})
+}
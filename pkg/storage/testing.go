@@ -11,9 +11,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"strings"
 	"syscall"
 	"testing"
 
@@ -44,6 +46,15 @@ func TestStorageProvider(provider StorageProvider, t *testing.T) {
 		t.Run("File/32MB", func(t *testing.T) { testFile(t, user, provider, 1024*1024*32) })
 		t.Run("File/64MB", func(t *testing.T) { testFile(t, user, provider, 1024*1024*64) })
 	}
+	t.Run("Overwrite", func(t *testing.T) { testOverwrite(t, user, provider) })
+	t.Run("SeekableFile", func(t *testing.T) { testSeekableFile(t, user, provider) })
+	t.Run("Directories", func(t *testing.T) { testDirectories(t, user, provider) })
+	t.Run("Copy", func(t *testing.T) { testCopy(t, user, provider, Copy) })
+	t.Run("StreamCopy", func(t *testing.T) { testCopy(t, user, provider, StreamCopy) })
+	t.Run("List", func(t *testing.T) { testList(t, user, provider) })
+	t.Run("Metadata", func(t *testing.T) { testMetadata(t, user, provider) })
+	t.Run("Info", func(t *testing.T) { testInfo(t, user, provider) })
+	t.Run("Atomic", func(t *testing.T) { testAtomic(t, user, provider) })
 }
 
 func testInitUser(t *testing.T, user *models.User, storage StorageProvider) {
@@ -53,7 +64,7 @@ func testInitUser(t *testing.T, user *models.User, storage StorageProvider) {
 func testMkdir(t *testing.T, user *models.User, storage StorageProvider) {
 	t.Run("ReadOnly", func(t *testing.T) {
 		readonly := ReadOnly(storage)
-		assert.Error(t, ErrReadOnly, readonly.Mkdir(context.Background(), user, "random/dir"))
+		assert.ErrorIs(t, readonly.Mkdir(context.Background(), user, "random/dir"), ErrReadOnly)
 	})
 	assert.NoError(t, storage.Mkdir(context.Background(), user, "random/dir"))
 }
@@ -73,7 +84,7 @@ func testFile(t *testing.T, user *models.User, storage StorageProvider, size int
 			file, err := readonly.File(context.Background(), user, filename)
 			if assert.NotNil(t, file) && assert.NoError(t, err) {
 				_, err = file.Write(buffer)
-				assert.Error(t, ErrReadOnly, err)
+				assert.ErrorIs(t, err, ErrReadOnly)
 			}
 		})
 
@@ -95,6 +106,19 @@ func testFile(t *testing.T, user *models.User, storage StorageProvider, size int
 	// tests are executed too quickly??
 	syscall.Sync()
 
+	if !t.Run("Stat", func(t *testing.T) {
+		info, err := storage.Stat(context.Background(), user, filename)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, filename, info.Name)
+		assert.Equal(t, uint64(size), info.Size)
+		assert.False(t, info.Directory)
+	}) {
+		return
+	}
+
 	if !t.Run("ListPreMove", func(t *testing.T) {
 		dir, err := storage.ListDirectory(context.Background(), user, "/")
 		if !assert.NoError(t, err) {
@@ -116,7 +140,7 @@ func testFile(t *testing.T, user *models.User, storage StorageProvider, size int
 	if !t.Run("Move", func(t *testing.T) {
 		t.Run("ReadOnly", func(t *testing.T) {
 			readonly := ReadOnly(storage)
-			assert.Error(t, ErrReadOnly, readonly.Move(context.Background(), user, filename, moved))
+			assert.ErrorIs(t, readonly.Move(context.Background(), user, filename, moved), ErrReadOnly)
 		})
 
 		assert.NoError(t, storage.Move(context.Background(), user, filename, moved))
@@ -159,7 +183,7 @@ func testFile(t *testing.T, user *models.User, storage StorageProvider, size int
 	if !t.Run("Delete", func(t *testing.T) {
 		t.Run("ReadOnly", func(t *testing.T) {
 			readonly := ReadOnly(storage)
-			assert.Error(t, ErrReadOnly, readonly.Delete(context.Background(), user, moved))
+			assert.ErrorIs(t, readonly.Delete(context.Background(), user, moved), ErrReadOnly)
 		})
 
 		err := storage.Delete(context.Background(), user, moved)
@@ -171,10 +195,371 @@ func testFile(t *testing.T, user *models.User, storage StorageProvider, size int
 
 		// however the first read call should return an error
 		_, err = io.ReadAll(file)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, ErrNotExist)
+
+		_, err = storage.Stat(context.Background(), user, moved)
+		assert.ErrorIs(t, err, ErrNotExist)
+	}) {
+		return
+	}
+}
+
+func testOverwrite(t *testing.T, user *models.User, storage StorageProvider) {
+	const filename = "overwrite"
+
+	if !writeTestFile(t, user, storage, filename, []byte("hello world")) ||
+		!writeTestFile(t, user, storage, filename, []byte("bye")) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.Delete(context.Background(), user, filename))
+	}()
+
+	// writing from the start replaces the whole file, rather than leaving the rest of the old contents behind
+	file, err := storage.File(context.Background(), user, filename)
+	if assert.NoError(t, err) {
+		data, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("bye"), data)
+		assert.NoError(t, file.Close())
+	}
+}
+
+func testSeekableFile(t *testing.T, user *models.User, storage StorageProvider) {
+	const filename = "seekable"
+	contents := []byte("hello world")
+
+	file, err := storage.File(context.Background(), user, filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if _, ok := file.(SeekableFile); !ok {
+		assert.NoError(t, file.Close())
+		t.Skip("File doesn't implement SeekableFile")
+	}
+
+	_, err = file.Write(contents)
+	assert.NoError(t, err)
+	assert.NoError(t, file.Close())
+
+	defer func() {
+		assert.NoError(t, storage.Delete(context.Background(), user, filename))
+	}()
+
+	if !t.Run("WriteAt", func(t *testing.T) {
+		file, err := storage.File(context.Background(), user, filename)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		n, err := file.(SeekableFile).WriteAt([]byte("W"), 6)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, file.Close())
+	}) {
+		return
+	}
+
+	t.Run("ReadAt", func(t *testing.T) {
+		file, err := storage.File(context.Background(), user, filename)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer file.Close()
+
+		buf := make([]byte, 5)
+		n, err := file.(SeekableFile).ReadAt(buf, 6)
+		if n != len(buf) {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []byte("World"), buf[:n])
+
+		// reading past the end should return whatever is left together with io.EOF
+		buf = make([]byte, 10)
+		n, err = file.(SeekableFile).ReadAt(buf, 8)
+		assert.Equal(t, io.EOF, err)
+		assert.Equal(t, []byte("rld"), buf[:n])
+	})
+
+	t.Run("Seek", func(t *testing.T) {
+		file, err := storage.File(context.Background(), user, filename)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer file.Close()
+
+		seeker := file.(SeekableFile)
+
+		pos, err := seeker.Seek(-5, io.SeekEnd)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), pos)
+
+		pos, err = seeker.Seek(-6, io.SeekCurrent)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), pos)
+
+		pos, err = seeker.Seek(6, io.SeekStart)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), pos)
+
+		data, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("World"), data)
+	})
+}
+
+func writeTestFile(t *testing.T, user *models.User, storage StorageProvider, filename string, contents []byte) bool {
+	file, err := storage.File(context.Background(), user, filename)
+	if !assert.NoError(t, err) {
+		return false
+	}
+	_, err = file.Write(contents)
+	return assert.NoError(t, err) && assert.NoError(t, file.Close())
+}
+
+func testDirectories(t *testing.T, user *models.User, storage StorageProvider) {
+	ctx := context.Background()
+	contents := []byte("hello world")
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "tree/a/b")) ||
+		!writeTestFile(t, user, storage, "tree/a/file", contents) ||
+		!writeTestFile(t, user, storage, "tree/a/b/file", contents) {
+		return
+	}
+
+	t.Run("List", func(t *testing.T) {
+		dir, err := storage.ListDirectory(ctx, user, "tree/a")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		entries := make(map[string]bool)
+		for file := range dir {
+			entries[file.Name] = file.Directory
+		}
+		assert.Equal(t, map[string]bool{"b": true, "file": false}, entries)
+
+		_, err = storage.ListDirectory(ctx, user, "tree/missing")
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("DeleteNotEmpty", func(t *testing.T) {
+		assert.ErrorIs(t, storage.Delete(ctx, user, "tree/a"), ErrNotEmpty)
+		assert.ErrorIs(t, storage.Delete(ctx, user, "tree/missing"), ErrNotExist)
+	})
+
+	t.Run("DeleteEmpty", func(t *testing.T) {
+		assert.NoError(t, storage.Mkdir(ctx, user, "tree/empty"))
+		assert.NoError(t, storage.Delete(ctx, user, "tree/empty"))
+
+		_, err := storage.Stat(ctx, user, "tree/empty")
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	if !t.Run("MoveFile", func(t *testing.T) {
+		if !assert.NoError(t, storage.Move(ctx, user, "tree/a/file", "tree/file")) {
+			return
+		}
+
+		_, err := storage.Stat(ctx, user, "tree/a/file")
+		assert.ErrorIs(t, err, ErrNotExist)
+
+		file, err := storage.File(ctx, user, "tree/file")
+		if assert.NoError(t, err) {
+			data, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			assert.Equal(t, contents, data)
+			assert.NoError(t, file.Close())
+		}
+	}) {
+		return
+	}
+
+	if !t.Run("MoveDirectory", func(t *testing.T) {
+		if !assert.NoError(t, storage.Move(ctx, user, "tree/a", "moved-tree")) {
+			return
+		}
+
+		_, err := storage.Stat(ctx, user, "tree/a")
+		assert.ErrorIs(t, err, ErrNotExist)
+
+		info, err := storage.Stat(ctx, user, "moved-tree/b/file")
+		if assert.NoError(t, err) {
+			assert.Equal(t, uint64(len(contents)), info.Size)
+		}
 	}) {
 		return
 	}
+
+	t.Run("MoveErrors", func(t *testing.T) {
+		assert.ErrorIs(t, storage.Move(ctx, user, "tree/file", "moved-tree/b/file"), ErrExist)
+		assert.ErrorIs(t, storage.Move(ctx, user, "tree/missing", "tree/other"), ErrNotExist)
+		assert.ErrorIs(t, storage.Move(ctx, user, "tree/file", "tree/missing/file"), ErrNotExist)
+	})
+
+	t.Run("DeleteTree", func(t *testing.T) {
+		t.Run("ReadOnly", func(t *testing.T) {
+			readonly := ReadOnly(storage)
+			assert.ErrorIs(t, readonly.DeleteTree(ctx, user, "tree"), ErrReadOnly)
+		})
+
+		assert.NoError(t, storage.DeleteTree(ctx, user, "moved-tree"))
+		assert.NoError(t, storage.DeleteTree(ctx, user, "tree"))
+		assert.ErrorIs(t, storage.DeleteTree(ctx, user, "tree"), ErrNotExist)
+
+		for _, name := range []string{"tree", "tree/file", "moved-tree", "moved-tree/b/file"} {
+			_, err := storage.Stat(ctx, user, name)
+			assert.ErrorIs(t, err, ErrNotExist, name)
+		}
+	})
+}
+
+func testCopy(t *testing.T, user *models.User, storage StorageProvider,
+	copy func(context.Context, StorageProvider, *models.User, string, string) error) {
+	ctx := context.Background()
+	contents := []byte("hello world")
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "copy/dir/nested")) ||
+		!writeTestFile(t, user, storage, "copy/file", contents) ||
+		!writeTestFile(t, user, storage, "copy/dir/nested/file", contents) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.DeleteTree(ctx, user, "copy"))
+	}()
+
+	readFile := func(t *testing.T, filename string) {
+		file, err := storage.File(ctx, user, filename)
+		if assert.NoError(t, err) {
+			data, err := io.ReadAll(file)
+			assert.NoError(t, err)
+			assert.Equal(t, contents, data)
+			assert.NoError(t, file.Close())
+		}
+	}
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.ErrorIs(t, copy(ctx, ReadOnly(storage), user, "copy/file", "copy/readonly"), ErrReadOnly)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		if assert.NoError(t, copy(ctx, storage, user, "copy/file", "copy/dir/copied")) {
+			readFile(t, "copy/file")
+			readFile(t, "copy/dir/copied")
+		}
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		if assert.NoError(t, copy(ctx, storage, user, "copy/dir", "copy/copied-dir")) {
+			readFile(t, "copy/dir/nested/file")
+			readFile(t, "copy/copied-dir/nested/file")
+			readFile(t, "copy/copied-dir/copied")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.ErrorIs(t, copy(ctx, storage, user, "copy/file", "copy/dir/copied"), ErrExist)
+		assert.ErrorIs(t, copy(ctx, storage, user, "copy/missing", "copy/other"), ErrNotExist)
+		assert.ErrorIs(t, copy(ctx, storage, user, "copy/file", "copy/missing/file"), ErrNotExist)
+		assert.ErrorIs(t, copy(ctx, storage, user, "copy/dir", "copy/dir/nested/dir"), ErrInvalidPath)
+	})
+}
+
+func testList(t *testing.T, user *models.User, storage StorageProvider) {
+	ctx := context.Background()
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "list/e")) ||
+		!writeTestFile(t, user, storage, "list/b", []byte("bbb")) ||
+		!writeTestFile(t, user, storage, "list/a", []byte("a")) ||
+		!writeTestFile(t, user, storage, "list/c", []byte("cc")) ||
+		!writeTestFile(t, user, storage, "list/d.txt", []byte("dd")) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.DeleteTree(ctx, user, "list"))
+	}()
+
+	list := func(t *testing.T, opts ListOptions) []string {
+		files, err := List(ctx, storage, user, "list", opts)
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		names := []string{}
+		for file := range files {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+
+	t.Run("Name", func(t *testing.T) {
+		assert.Equal(t, []string{"a", "b", "c", "d.txt", "e"}, list(t, ListOptions{Sort: SortName}))
+		assert.Equal(t, []string{"e", "d.txt", "c", "b", "a"}, list(t, ListOptions{Sort: SortName, Descending: true}))
+	})
+
+	t.Run("Size", func(t *testing.T) {
+		// the size of a directory differs between providers, so we leave it out
+		assert.Equal(t, []string{"a", "c", "d.txt", "b"}, list(t, ListOptions{Sort: SortSize, Filter: "[a-d]*"}))
+		assert.Equal(t, []string{"b", "d.txt", "c", "a"}, list(t, ListOptions{Sort: SortSize, Descending: true, Filter: "[a-d]*"}))
+	})
+
+	t.Run("Time", func(t *testing.T) {
+		assert.Len(t, list(t, ListOptions{Sort: SortCreated}), 5)
+		assert.Len(t, list(t, ListOptions{Sort: SortUpdated, Descending: true}), 5)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		assert.Equal(t, []string{"d.txt"}, list(t, ListOptions{Filter: "*.txt"}))
+		assert.Empty(t, list(t, ListOptions{Filter: "missing"}))
+	})
+
+	t.Run("Paging", func(t *testing.T) {
+		// without a sort key we still page by name
+		assert.Equal(t, []string{"a", "b"}, list(t, ListOptions{Limit: 2}))
+		assert.Equal(t, []string{"c", "d.txt"}, list(t, ListOptions{Sort: SortName, Limit: 2, Cursor: "b"}))
+		assert.Equal(t, []string{"e"}, list(t, ListOptions{Sort: SortName, Limit: 2, Cursor: "d.txt"}))
+		assert.Empty(t, list(t, ListOptions{Sort: SortName, Limit: 2, Cursor: "e"}))
+		assert.Equal(t, []string{"c", "a"}, list(t, ListOptions{Sort: SortSize, Descending: true, Filter: "[a-d]*",
+			Cursor: CursorOf(SortSize, FileInfo{Name: "d.txt", Size: 2})}))
+
+		// paging by time has to work the same for providers without timestamps
+		for _, key := range []SortKey{SortCreated, SortUpdated} {
+			names := []string{}
+			opts := ListOptions{Sort: key, Limit: 2}
+			for page := 0; page < 5; page++ {
+				files, err := List(ctx, storage, user, "list", opts)
+				if !assert.NoError(t, err) {
+					break
+				}
+				var last *FileInfo
+				for file := range files {
+					file := file
+					names = append(names, file.Name)
+					last = &file
+				}
+				if last == nil {
+					break
+				}
+				opts.Cursor = CursorOf(key, *last)
+			}
+			assert.ElementsMatch(t, []string{"a", "b", "c", "d.txt", "e"}, names, key)
+		}
+		info, err := ParseCursor(SortUpdated, CursorOf(SortUpdated, FileInfo{Name: "a"}))
+		if assert.NoError(t, err) {
+			assert.True(t, info.UpdatedAt.IsZero())
+			assert.Equal(t, "a", info.Name)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := List(ctx, storage, user, "list", ListOptions{Sort: "bogus"})
+		assert.ErrorIs(t, err, ErrInvalidPath)
+		_, err = List(ctx, storage, user, "list", ListOptions{Filter: "["})
+		assert.ErrorIs(t, err, ErrInvalidPath)
+		_, err = List(ctx, storage, user, "list", ListOptions{Sort: SortSize, Cursor: "b"})
+		assert.ErrorIs(t, err, ErrInvalidPath)
+		_, err = List(ctx, storage, user, "list/missing", ListOptions{Sort: SortName})
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
 }
 
 func BenchmarkStorageProvider(storage StorageProvider, b *testing.B) {
@@ -251,3 +636,207 @@ func benchmarkFile(b *testing.B, user *models.User, provider StorageProvider, si
 		return
 	}
 }
+
+func testMetadata(t *testing.T, user *models.User, storage StorageProvider) {
+	ctx := context.Background()
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "metadata/dir")) ||
+		!writeTestFile(t, user, storage, "metadata/dir/file", []byte("hello world")) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.DeleteTree(ctx, user, "metadata"))
+	}()
+
+	err := SetMetadata(ctx, storage, user, "metadata/dir/file", "tag", "red")
+	if errors.Is(err, ErrNotSupported) {
+		t.Skip("Metadata isn't supported")
+	}
+	if !assert.NoError(t, err) || !assert.NoError(t, SetMetadata(ctx, storage, user, "metadata/dir", "color", "blue")) {
+		return
+	}
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		assert.ErrorIs(t, SetMetadata(ctx, ReadOnly(storage), user, "metadata/dir/file", "tag", "blue"), ErrReadOnly)
+		value, err := GetMetadata(ctx, ReadOnly(storage), user, "metadata/dir/file", "tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "red", value)
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		value, err := GetMetadata(ctx, storage, user, "metadata/dir/file", "tag")
+		assert.NoError(t, err)
+		assert.Equal(t, "red", value)
+
+		_, err = GetMetadata(ctx, storage, user, "metadata/dir/file", "missing")
+		assert.ErrorIs(t, err, ErrNotExist)
+		_, err = GetMetadata(ctx, storage, user, "metadata/missing", "tag")
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		metadata, err := ListMetadata(ctx, storage, user, "metadata/dir")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"color": "blue"}, metadata)
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		if !assert.NoError(t, Copy(ctx, storage, user, "metadata/dir", "metadata/copy")) {
+			return
+		}
+		metadata, err := ListMetadata(ctx, storage, user, "metadata/copy/file")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"tag": "red"}, metadata)
+		metadata, err = ListMetadata(ctx, storage, user, "metadata/copy")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"color": "blue"}, metadata)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		if !assert.NoError(t, storage.Move(ctx, user, "metadata/copy", "metadata/moved")) {
+			return
+		}
+		metadata, err := ListMetadata(ctx, storage, user, "metadata/moved/file")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"tag": "red"}, metadata)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		assert.NoError(t, SetMetadata(ctx, storage, user, "metadata/dir/file", "tag", ""))
+		// removing it twice is fine
+		assert.NoError(t, SetMetadata(ctx, storage, user, "metadata/dir/file", "tag", ""))
+		metadata, err := ListMetadata(ctx, storage, user, "metadata/dir/file")
+		assert.NoError(t, err)
+		assert.NotNil(t, metadata)
+		assert.Empty(t, metadata)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.ErrorIs(t, SetMetadata(ctx, storage, user, "metadata/dir/file", "", "value"), ErrInvalidPath)
+		assert.ErrorIs(t, SetMetadata(ctx, storage, user, "metadata/dir/file", strings.Repeat("a", MaxMetadataKey+1), "value"), ErrInvalidPath)
+		assert.ErrorIs(t, SetMetadata(ctx, storage, user, "metadata/missing", "tag", "value"), ErrNotExist)
+		_, err := ListMetadata(ctx, storage, user, "metadata/missing")
+		assert.ErrorIs(t, err, ErrNotExist)
+	})
+}
+
+func testInfo(t *testing.T, user *models.User, storage StorageProvider) {
+	ctx := context.Background()
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "info")) ||
+		!writeTestFile(t, user, storage, "info/file", []byte("hello world")) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.DeleteTree(ctx, user, "info"))
+	}()
+
+	info, err := storage.Stat(ctx, user, "info/file")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, info.Symlink)
+	assert.False(t, info.CreatedAt.After(info.UpdatedAt), "created after it was last modified")
+
+	t.Run("List", func(t *testing.T) {
+		files, err := storage.ListDirectory(ctx, user, "info")
+		if !assert.NoError(t, err) {
+			return
+		}
+		for file := range files {
+			// listing should tell the same as Stat does
+			assert.Equal(t, info.ETag, file.ETag)
+			assert.Equal(t, info.Mode, file.Mode)
+			assert.Equal(t, info.Symlink, file.Symlink)
+			assert.True(t, info.UpdatedAt.Equal(file.UpdatedAt), "%s != %s", info.UpdatedAt, file.UpdatedAt)
+		}
+	})
+
+	t.Run("ETag", func(t *testing.T) {
+		if info.ETag == "" {
+			t.Skip("ETags aren't supported")
+		}
+		if !writeTestFile(t, user, storage, "info/file", []byte("something else")) {
+			return
+		}
+		changed, err := storage.Stat(ctx, user, "info/file")
+		if assert.NoError(t, err) {
+			assert.NotEqual(t, info.ETag, changed.ETag)
+		}
+	})
+}
+
+func testAtomic(t *testing.T, user *models.User, storage StorageProvider) {
+	if !AtomicWrites(storage) {
+		t.Skip("Writes aren't atomic")
+	}
+	ctx := context.Background()
+
+	if !assert.NoError(t, storage.Mkdir(ctx, user, "atomic")) ||
+		!writeTestFile(t, user, storage, "atomic/file", []byte("old contents")) {
+		return
+	}
+	defer func() {
+		assert.NoError(t, storage.DeleteTree(ctx, user, "atomic"))
+	}()
+
+	read := func() []byte {
+		file, err := storage.File(ctx, user, "atomic/file")
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		assert.NoError(t, err)
+		return data
+	}
+	list := func() []string {
+		files, err := storage.ListDirectory(ctx, user, "atomic")
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		names := make([]string, 0)
+		for file := range files {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+
+	t.Run("Close", func(t *testing.T) {
+		file, err := storage.File(ctx, user, "atomic/file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = file.Write([]byte("new"))
+		assert.NoError(t, err)
+
+		// until it's closed nobody should be able to tell it's being written to
+		assert.Equal(t, []byte("old contents"), read())
+		assert.Equal(t, []string{"file"}, list())
+
+		assert.NoError(t, file.Close())
+		assert.Equal(t, []byte("new"), read())
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+		file, err := storage.File(ctx, user, "atomic/file")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = file.Write([]byte("interrupted"))
+		assert.NoError(t, err)
+		assert.NoError(t, Abort(file))
+		assert.Equal(t, []byte("new"), read())
+
+		file, err = storage.File(ctx, user, "atomic/aborted")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = file.Write([]byte("interrupted"))
+		assert.NoError(t, err)
+		assert.NoError(t, Abort(file))
+		_, err = storage.Stat(ctx, user, "atomic/aborted")
+		assert.ErrorIs(t, err, ErrNotExist)
+		assert.Equal(t, []string{"file"}, list())
+	})
+}
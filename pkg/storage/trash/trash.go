@@ -0,0 +1,549 @@
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// TrashDir is where deleted files end up, hidden from the user. Every deleted file or directory gets
+// its own directory in here named after its id, containing the item itself and a json file with
+// where it came from. So deleting /some/file results in /.trash/<id>/item and /.trash/<id>/info.json.
+// Every mount has a trash of its own, so deleting /archive/file ends up in /archive/.trash/<id>
+const TrashDir = "/.trash"
+
+// Policy decides how long deleted items are kept around, a zero RetentionDays means forever
+type Policy struct {
+	Enabled       bool `yaml:"enabled"`
+	RetentionDays int  `yaml:"retention_days"`
+}
+
+// Item is a single deleted file or directory, ID is what should be passed to Restore
+type Item struct {
+	ID        string    `json:"id"`
+	Path      string    `json:"path"`
+	DeletedAt time.Time `json:"deleted_at"`
+	Size      uint64    `json:"size"`
+	Directory bool      `json:"directory"`
+}
+
+// TrashStorageProvider turns Delete and DeleteTree into a move to the trash of the user, from where
+// it can be restored until it gets purged. Everything is stored in the underlying provider itself
+// so this works on top of any provider
+type TrashStorageProvider struct {
+	proxy     storage.StorageProvider
+	retention time.Duration
+	// the mount points of proxy, these each get a trash of their own
+	mountPoints []string
+
+	// protects the generation of ids, so two deletes at the same time can't end up with the same one
+	mutex  sync.Mutex
+	lastID int64
+
+	// the users whose deletes are permanent right away, see Bypass
+	bypass func(user *models.User) bool
+
+	clock func() time.Time
+}
+
+func Trash(provider storage.StorageProvider, policy Policy) *TrashStorageProvider {
+	return &TrashStorageProvider{
+		proxy:       provider,
+		retention:   time.Duration(policy.RetentionDays) * 24 * time.Hour,
+		mountPoints: storage.MountPoints(provider),
+		clock:       time.Now,
+	}
+}
+
+func (t *TrashStorageProvider) Unwrap() storage.StorageProvider {
+	return t.proxy
+}
+
+func cleanPath(fullpath string) string {
+	return path.Join("/", fullpath)
+}
+
+// trashDir returns the trash of the mount fullpath is on, so deleting never has to move anything across mounts
+func (t *TrashStorageProvider) trashDir(fullpath string) string {
+	return path.Join(storage.MountPoint(t.mountPoints, fullpath), TrashDir)
+}
+
+// trashDirs returns the trash of every mount
+func (t *TrashStorageProvider) trashDirs() []string {
+	out := make([]string, 0, len(t.mountPoints))
+	for _, mountPoint := range t.mountPoints {
+		out = append(out, path.Join(mountPoint, TrashDir))
+	}
+	return out
+}
+
+func (t *TrashStorageProvider) isHidden(fullpath string) bool {
+	fullpath = cleanPath(fullpath)
+	trash := t.trashDir(fullpath)
+	return fullpath == trash || strings.HasPrefix(fullpath, trash+"/")
+}
+
+func (t *TrashStorageProvider) checkPaths(paths ...string) error {
+	for _, fullpath := range paths {
+		if t.isHidden(fullpath) {
+			return fmt.Errorf("%w: %s", storage.ErrInvalidPath, cleanPath(fullpath))
+		}
+	}
+	return nil
+}
+
+func itemDir(trash, id string) string {
+	return path.Join(trash, id)
+}
+
+func itemPath(trash, id string) string {
+	return path.Join(trash, id, "item")
+}
+
+func infoPath(trash, id string) string {
+	return path.Join(trash, id, "info.json")
+}
+
+// locate returns the trash the item with id is in
+func (t *TrashStorageProvider) locate(ctx context.Context, user *models.User, id string) (string, error) {
+	for _, trash := range t.trashDirs() {
+		_, err := t.proxy.Stat(ctx, user, itemDir(trash, id))
+		if err == nil {
+			return trash, nil
+		} else if !errors.Is(err, storage.ErrNotExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%w: trash item %s", storage.ErrNotExist, id)
+}
+
+// nextID returns a new unique id, these are based on the current time so they sort by deletion time
+func (t *TrashStorageProvider) nextID() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	id := t.clock().UnixNano()
+	if id <= t.lastID {
+		id = t.lastID + 1
+	}
+	t.lastID = id
+	return strconv.FormatInt(id, 10)
+}
+
+// Bypass makes the deletes of every user skip returns true for permanent right away. This is meant for the
+// users nobody would ever restore or purge the trash of, like shared.Owner
+func (t *TrashStorageProvider) Bypass(skip func(user *models.User) bool) {
+	t.bypass = skip
+}
+
+func (t *TrashStorageProvider) skipsTrash(user *models.User) bool {
+	return t.bypass != nil && t.bypass(user)
+}
+
+// moveToTrash moves fullpath into a new item in the trash
+func (t *TrashStorageProvider) moveToTrash(ctx context.Context, user *models.User, fullpath string) error {
+	fullpath = cleanPath(fullpath)
+	if fullpath == "/" {
+		return fmt.Errorf("%w: can't delete the root directory", storage.ErrInvalidPath)
+	}
+
+	info, err := t.proxy.Stat(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	id := t.nextID()
+	trash := t.trashDir(fullpath)
+	err = t.proxy.Mkdir(ctx, user, itemDir(trash, id))
+	if err != nil {
+		return err
+	}
+
+	err = t.writeInfo(ctx, user, trash, id, Item{
+		ID:        id,
+		Path:      fullpath,
+		DeletedAt: t.clock(),
+		Size:      info.Size,
+		Directory: info.Directory,
+	})
+	if err == nil {
+		err = t.proxy.Move(ctx, user, fullpath, itemPath(trash, id))
+	}
+	if err != nil {
+		cleanupErr := t.proxy.DeleteTree(ctx, user, itemDir(trash, id))
+		if cleanupErr != nil {
+			logrus.Errorf("Failed to clean up trash item %s: %s", id, cleanupErr)
+		}
+		return err
+	}
+	return nil
+}
+
+func (t *TrashStorageProvider) writeInfo(ctx context.Context, user *models.User, trash, id string, item Item) error {
+	file, err := t.proxy.File(ctx, user, infoPath(trash, id))
+	if err != nil {
+		return err
+	}
+
+	err = json.NewEncoder(file).Encode(item)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (t *TrashStorageProvider) readInfo(ctx context.Context, user *models.User, trash, id string) (Item, error) {
+	file, err := t.proxy.File(ctx, user, infoPath(trash, id))
+	if err != nil {
+		return Item{}, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return Item{}, err
+	}
+
+	var item Item
+	err = json.Unmarshal(data, &item)
+	if err != nil {
+		return Item{}, err
+	}
+	item.ID = id
+	return item, nil
+}
+
+// Items returns everything in the trash of user across all mounts, most recently deleted first
+func (t *TrashStorageProvider) Items(ctx context.Context, user *models.User) ([]Item, error) {
+	out := make([]Item, 0)
+	for _, trash := range t.trashDirs() {
+		dirs, err := t.proxy.ListDirectory(ctx, user, trash)
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// we drain the listing first, so we're not reading files while still listing
+		ids := make([]string, 0)
+		for dir := range dirs {
+			if dir.Directory {
+				ids = append(ids, dir.Name)
+			}
+		}
+
+		for _, id := range ids {
+			item, err := t.readInfo(ctx, user, trash, id)
+			if err != nil {
+				logrus.Warnf("Skipping trash item %s of user %d: %s", id, user.ID, err)
+				continue
+			}
+			out = append(out, item)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeletedAt.Equal(out[j].DeletedAt) {
+			return out[i].DeletedAt.After(out[j].DeletedAt)
+		}
+		// ids only ever go up, so these still tell us the order when the clock didn't
+		a, _ := strconv.ParseInt(out[i].ID, 10, 64)
+		b, _ := strconv.ParseInt(out[j].ID, 10, 64)
+		return a > b
+	})
+	return out, nil
+}
+
+func checkID(id string) error {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return fmt.Errorf("%w: %s", storage.ErrInvalidPath, id)
+	}
+	return nil
+}
+
+// Restore moves the item back to where it was deleted from, recreating the parent directories if needed.
+// It fails with storage.ErrExist if something else has been put there in the meantime
+func (t *TrashStorageProvider) Restore(ctx context.Context, user *models.User, id string) (Item, error) {
+	err := checkID(id)
+	if err != nil {
+		return Item{}, err
+	}
+
+	trash, err := t.locate(ctx, user, id)
+	if err != nil {
+		return Item{}, err
+	}
+	item, err := t.readInfo(ctx, user, trash, id)
+	if errors.Is(err, storage.ErrNotExist) {
+		return Item{}, fmt.Errorf("%w: trash item %s", storage.ErrNotExist, id)
+	} else if err != nil {
+		return Item{}, err
+	}
+
+	err = t.proxy.Mkdir(ctx, user, path.Dir(item.Path))
+	if err != nil {
+		return Item{}, err
+	}
+
+	err = t.proxy.Move(ctx, user, itemPath(trash, id), item.Path)
+	if err != nil {
+		return Item{}, err
+	}
+	return item, t.proxy.DeleteTree(ctx, user, itemDir(trash, id))
+}
+
+// Remove permanently deletes a single item from the trash
+func (t *TrashStorageProvider) Remove(ctx context.Context, user *models.User, id string) error {
+	err := checkID(id)
+	if err != nil {
+		return err
+	}
+	trash, err := t.locate(ctx, user, id)
+	if err != nil {
+		return err
+	}
+	return t.proxy.DeleteTree(ctx, user, itemDir(trash, id))
+}
+
+// Empty permanently deletes everything in the trash of user
+func (t *TrashStorageProvider) Empty(ctx context.Context, user *models.User) error {
+	for _, trash := range t.trashDirs() {
+		err := t.proxy.DeleteTree(ctx, user, trash)
+		if err != nil && !errors.Is(err, storage.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Purge permanently deletes the items of user that have been in the trash for longer than the retention
+func (t *TrashStorageProvider) Purge(ctx context.Context, user *models.User) error {
+	if t.retention <= 0 {
+		return nil
+	}
+
+	items, err := t.Items(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	cutoff := t.clock().Add(-t.retention)
+	for _, item := range items {
+		if item.DeletedAt.Before(cutoff) {
+			err = t.Remove(ctx, user, item.ID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Purger calls Purge for all users every interval, until ctx is done. This is meant to be run in its own goroutine
+func (t *TrashStorageProvider) Purger(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var users []models.User
+		err := db.WithContext(ctx).Find(&users).Error
+		if err != nil {
+			logrus.Errorf("Failed to load the users to purge the trash of: %s", err)
+		}
+
+		for i := range users {
+			err = t.Purge(ctx, &users[i])
+			if err != nil {
+				logrus.Errorf("Failed to purge the trash of user %d: %s", users[i].ID, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TrashStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return t.proxy.InitUser(ctx, user)
+}
+
+func (t *TrashStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	err := t.checkPaths(dir)
+	if err != nil {
+		return err
+	}
+	return t.proxy.Mkdir(ctx, user, dir)
+}
+
+func (t *TrashStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := t.checkPaths(src, dst)
+	if err != nil {
+		return err
+	}
+	return t.proxy.Move(ctx, user, src, dst)
+}
+
+func (t *TrashStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	err := t.checkPaths(src, dst)
+	if err != nil {
+		return err
+	}
+	return storage.Copy(ctx, t.proxy, user, src, dst)
+}
+
+func (t *TrashStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	return t.List(ctx, user, dir, storage.ListOptions{})
+}
+
+func (t *TrashStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	err := t.checkPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPoint := cleanPath(dir) == storage.MountPoint(t.mountPoints, dir)
+	limit := opts.Limit
+	if mountPoint && limit > 0 {
+		// we might have to leave out the trash below, so we need one more to take its place
+		opts.Limit++
+	}
+
+	files, err := storage.List(ctx, t.proxy, user, dir, opts)
+	if err != nil || !mountPoint {
+		return files, err
+	}
+
+	// the trash is only ever at the root of a mount, so those are the only directories we have to filter
+	out := make(chan storage.FileInfo)
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+		sent := 0
+		for file := range files {
+			if file.Name == path.Base(TrashDir) || (limit > 0 && sent >= limit) {
+				continue
+			}
+			select {
+			case out <- file:
+			case <-ctx.Done():
+				return
+			}
+			sent++
+		}
+	}(out)
+	return out, nil
+}
+
+func (t *TrashStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	return t.proxy.Stat(ctx, user, fullpath)
+}
+
+func (t *TrashStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return t.proxy.File(ctx, user, fullpath)
+}
+
+// Delete moves fullpath to the trash, it keeps the semantics of the underlying provider in the
+// sense that it still refuses to delete directories that aren't empty
+func (t *TrashStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+	if t.skipsTrash(user) {
+		return t.proxy.Delete(ctx, user, fullpath)
+	}
+
+	info, err := t.proxy.Stat(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	if info.Directory {
+		files, err := t.proxy.ListDirectory(ctx, user, fullpath)
+		if err != nil {
+			return err
+		}
+		empty := true
+		for range files {
+			empty = false
+		}
+		if !empty {
+			return fmt.Errorf("%w: %s", storage.ErrNotEmpty, fullpath)
+		}
+	}
+
+	return t.moveToTrash(ctx, user, fullpath)
+}
+
+func (t *TrashStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+	if t.skipsTrash(user) {
+		return t.proxy.DeleteTree(ctx, user, fullpath)
+	}
+	return t.moveToTrash(ctx, user, fullpath)
+}
+
+// Watch passes on the changes of the underlying provider, anything moved into the trash shows up as deleted
+func (t *TrashStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	events, err := storage.Watch(ctx, t.proxy, user)
+	if err != nil {
+		return nil, err
+	}
+	return storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+		return fullpath, !t.isHidden(fullpath)
+	}), nil
+}
+
+func (t *TrashStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(t.proxy)
+}
+
+func (t *TrashStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return "", err
+	}
+	return storage.GetMetadata(ctx, t.proxy, user, fullpath, key)
+}
+
+func (t *TrashStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+	return storage.SetMetadata(ctx, t.proxy, user, fullpath, key, value)
+}
+
+func (t *TrashStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	err := t.checkPaths(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ListMetadata(ctx, t.proxy, user, fullpath)
+}
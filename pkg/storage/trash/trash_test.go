@@ -0,0 +1,311 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		storage.TestStorageProvider(Trash(local.NewStorageProvider(t.TempDir()), Policy{}), t)
+	})
+	t.Run("Memory", func(t *testing.T) {
+		storage.TestStorageProvider(Trash(memory.NewStorageProvider(), Policy{}), t)
+	})
+}
+
+// fakeClock returns a clock that only moves when advanced
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+			return now
+		}, func(d time.Duration) {
+			now = now.Add(d)
+		}
+}
+
+func TestItemsAndRestore(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	provider := Trash(local.NewStorageProvider(t.TempDir()), Policy{})
+	assert.NoError(t, provider.InitUser(ctx, user))
+	now, advance := fakeClock()
+	provider.clock = now
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder/nested"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/folder/nested/file", "nested"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "root"))
+
+	assert.NoError(t, provider.DeleteTree(ctx, user, "/folder"))
+	advance(time.Minute)
+	assert.NoError(t, provider.Delete(ctx, user, "/file"))
+
+	_, err := provider.Stat(ctx, user, "/folder")
+	assert.ErrorIs(t, err, storage.ErrNotExist)
+
+	items, err := provider.Items(ctx, user)
+	if !assert.NoError(t, err) || !assert.Len(t, items, 2) {
+		return
+	}
+	assert.Equal(t, "/file", items[0].Path)
+	assert.Equal(t, uint64(len("root")), items[0].Size)
+	assert.Equal(t, "/folder", items[1].Path)
+	assert.True(t, items[1].Directory)
+
+	t.Run("Hidden", func(t *testing.T) {
+		dir, err := provider.ListDirectory(ctx, user, "/")
+		if assert.NoError(t, err) {
+			for file := range dir {
+				assert.NotEqual(t, ".trash", file.Name)
+			}
+		}
+		_, err = provider.Stat(ctx, user, TrashDir)
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+		assert.ErrorIs(t, provider.Move(ctx, user, "/other", TrashDir+"/other"), storage.ErrInvalidPath)
+	})
+
+	t.Run("NotEmpty", func(t *testing.T) {
+		assert.NoError(t, provider.Mkdir(ctx, user, "/full"))
+		assert.NoError(t, storagetest.WriteFile(provider, user, "/full/file", "data"))
+		assert.ErrorIs(t, provider.Delete(ctx, user, "/full"), storage.ErrNotEmpty)
+		assert.ErrorIs(t, provider.DeleteTree(ctx, user, "/"), storage.ErrInvalidPath)
+		assert.NoError(t, provider.Unwrap().DeleteTree(ctx, user, "/full"))
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		_, err := provider.Restore(ctx, user, items[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "nested", storagetest.ReadFile(t, provider, user, "/folder/nested/file"))
+
+		// something else took its place in the meantime
+		assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "new"))
+		_, err = provider.Restore(ctx, user, items[0].ID)
+		assert.ErrorIs(t, err, storage.ErrExist)
+		assert.Equal(t, "new", storagetest.ReadFile(t, provider, user, "/file"))
+
+		_, err = provider.Restore(ctx, user, "1234")
+		assert.ErrorIs(t, err, storage.ErrNotExist)
+		_, err = provider.Restore(ctx, user, "../file")
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+	})
+
+	t.Run("MissingParent", func(t *testing.T) {
+		assert.NoError(t, provider.Mkdir(ctx, user, "/parent"))
+		assert.NoError(t, storagetest.WriteFile(provider, user, "/parent/file", "data"))
+		assert.NoError(t, provider.Delete(ctx, user, "/parent/file"))
+		assert.NoError(t, provider.Delete(ctx, user, "/parent"))
+
+		items, err := provider.Items(ctx, user)
+		if assert.NoError(t, err) && assert.NotEmpty(t, items) {
+			assert.Equal(t, "/parent", items[0].Path)
+			_, err = provider.Restore(ctx, user, items[1].ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "data", storagetest.ReadFile(t, provider, user, "/parent/file"))
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, provider.Empty(ctx, user))
+		items, err := provider.Items(ctx, user)
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+		assert.NoError(t, provider.Empty(ctx, user))
+	})
+}
+
+func TestMounts(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	root := memory.NewStorageProvider()
+	archive := memory.NewStorageProvider()
+	mounts, err := mount.Mounts(root, []mount.Mount{{Path: "/archive", Provider: archive}}, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	provider := Trash(mounts, Policy{})
+	now, advance := fakeClock()
+	provider.clock = now
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "root"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/archive/file", "archive"))
+
+	// every mount has a trash of its own, so nothing has to be moved across mounts
+	assert.NoError(t, provider.Delete(ctx, user, "/archive/file"))
+	advance(time.Minute)
+	assert.NoError(t, provider.Delete(ctx, user, "/file"))
+	assert.Empty(t, archive.Data["/file"])
+
+	items, err := provider.Items(ctx, user)
+	if !assert.NoError(t, err) || !assert.Len(t, items, 2) {
+		return
+	}
+	assert.Equal(t, "/file", items[0].Path)
+	assert.Equal(t, "/archive/file", items[1].Path)
+	assert.Equal(t, []byte("archive"), archive.Data[itemPath(TrashDir, items[1].ID)])
+
+	t.Run("Hidden", func(t *testing.T) {
+		dir, err := provider.ListDirectory(ctx, user, "/archive")
+		if assert.NoError(t, err) {
+			for file := range dir {
+				assert.NotEqual(t, ".trash", file.Name)
+			}
+		}
+		_, err = provider.Stat(ctx, user, "/archive"+TrashDir)
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		_, err := provider.Restore(ctx, user, items[1].ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "archive", storagetest.ReadFile(t, provider, user, "/archive/file"))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		assert.NoError(t, provider.Empty(ctx, user))
+		items, err := provider.Items(ctx, user)
+		assert.NoError(t, err)
+		assert.Empty(t, items)
+	})
+}
+
+func TestBypass(t *testing.T) {
+	ctx := context.Background()
+	// like the one the shared folders are stored for, nobody would ever empty their trash
+	user := &models.User{ID: 0}
+	other := &models.User{ID: 1}
+
+	memfs := memory.NewStorageProvider()
+	provider := Trash(memfs, Policy{})
+	provider.Bypass(func(bypassed *models.User) bool {
+		return bypassed.ID == user.ID
+	})
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/folder/file", "data"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "data"))
+	assert.NoError(t, provider.DeleteTree(ctx, user, "/folder"))
+	assert.NoError(t, provider.Delete(ctx, user, "/file"))
+
+	assert.Empty(t, memfs.Data)
+	items, err := provider.Items(ctx, user)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+
+	// everybody else keeps their trash
+	assert.NoError(t, storagetest.WriteFile(provider, other, "/file", "data"))
+	assert.NoError(t, provider.Delete(ctx, other, "/file"))
+	items, err = provider.Items(ctx, other)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	provider := Trash(memory.NewStorageProvider(), Policy{RetentionDays: 7})
+	now, advance := fakeClock()
+	provider.clock = now
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/old", "old"))
+	assert.NoError(t, provider.Delete(ctx, user, "/old"))
+	advance(5 * 24 * time.Hour)
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/new", "new"))
+	assert.NoError(t, provider.Delete(ctx, user, "/new"))
+	advance(3 * 24 * time.Hour)
+
+	assert.NoError(t, provider.Purge(ctx, user))
+	items, err := provider.Items(ctx, user)
+	if assert.NoError(t, err) && assert.Len(t, items, 1) {
+		assert.Equal(t, "/new", items[0].Path)
+	}
+
+	// no retention means we keep everything forever
+	provider.retention = 0
+	advance(365 * 24 * time.Hour)
+	assert.NoError(t, provider.Purge(ctx, user))
+	items, err = provider.Items(ctx, user)
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+}
+
+// failingMove makes every Move fail, to check we don't leave anything behind in the trash
+type failingMove struct {
+	storage.StorageProvider
+}
+
+func (f failingMove) Move(ctx context.Context, user *models.User, src, dst string) error {
+	return errors.New("Move failed")
+}
+
+func TestFailedMove(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	memfs := memory.NewStorageProvider()
+	provider := Trash(failingMove{memfs}, Policy{})
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/file", "data"))
+	assert.Error(t, provider.Delete(ctx, user, "/file"))
+	assert.Equal(t, []byte("data"), memfs.Data["/file"])
+
+	items, err := provider.Items(ctx, user)
+	assert.NoError(t, err)
+	assert.Empty(t, items)
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := &models.User{ID: 1}
+
+	provider := Trash(local.NewStorageProvider(t.TempDir()), Policy{})
+	assert.NoError(t, provider.InitUser(ctx, user))
+	assert.NoError(t, provider.Mkdir(ctx, user, "/folder"))
+
+	events, err := storage.Watch(ctx, provider, user)
+	if errors.Is(err, storage.ErrNotSupported) {
+		t.Skip("The local provider can't watch for changes on this platform")
+	} else if !assert.NoError(t, err) {
+		return
+	}
+
+	// none of the bookkeeping within the trash should show up, only that the folder is gone and back again
+	assert.NoError(t, provider.DeleteTree(ctx, user, "/folder"))
+	items, err := provider.Items(ctx, user)
+	if assert.NoError(t, err) && assert.Len(t, items, 1) {
+		_, err = provider.Restore(ctx, user, items[0].ID)
+		assert.NoError(t, err)
+	}
+
+	expected := []storage.Event{
+		{Type: storage.EventDelete, Path: "/folder", Directory: true},
+		{Type: storage.EventCreate, Path: "/folder", Directory: true},
+	}
+	for _, event := range expected {
+		select {
+		case got := <-events:
+			assert.Equal(t, event, got)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for an event")
+		}
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("Unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"bytes"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -10,32 +11,143 @@ import (
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/plugin"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/dedup"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/encryption"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/mount"
 	storagePlugin "github.com/leicht-cloud/leicht-cloud/pkg/storage/plugin"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/quota"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/search"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/trash"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/versioning"
 	"github.com/sirupsen/logrus"
 	"go.uber.org/multierr"
+	"gorm.io/gorm"
 )
 
 type Config struct {
-	Provider string                      `yaml:"provider"`
-	Extra    map[interface{}]interface{} `yaml:"extra"`
+	ProviderConfig `yaml:",inline"`
+	// Mounts serve everything below their path from a provider of their own, the provider above serves the rest
+	Mounts []MountConfig `yaml:"mounts"`
+	// CrossMountCopy makes moves between mounts copy everything over and delete the source afterwards,
+	// otherwise these are refused
+	CrossMountCopy bool `yaml:"cross_mount_copy"`
+
+	Versioning versioning.Policy `yaml:"versioning"`
+	Trash      trash.Policy      `yaml:"trash"`
+	Search     search.Config     `yaml:"search"`
+}
+
+// ProviderConfig configures a single provider along with the wrappers stacked on top of it
+type ProviderConfig struct {
+	Provider   string                      `yaml:"provider"`
+	Extra      map[interface{}]interface{} `yaml:"extra"`
+	Encryption EncryptionConfig            `yaml:"encryption"`
+	// Wrappers are stacked on top of the provider in order, after the encryption
+	Wrappers []WrapperConfig `yaml:"wrappers"`
 }
 
-func (c *Config) CreateProvider(pManager *plugin.Manager) (storage.StorageProvider, error) {
-	out, err := fromConfig(c, pManager)
+// MountConfig puts a provider at Path for every user, like /archive
+type MountConfig struct {
+	Path           string `yaml:"path"`
+	ProviderConfig `yaml:",inline"`
+}
+
+// EncryptionConfig enables at-rest encryption on top of the provider if Key is set,
+// Key should be the base64 encoding of encryption.KeySize random bytes
+type EncryptionConfig struct {
+	Key       string `yaml:"key"`
+	Filenames bool   `yaml:"filenames"`
+}
+
+// WrapperConfig is a single wrapper, Type is either readonly, quota or encryption. The quota limits every
+// user to Limit bytes on just this provider, in addition to the quota over everything. Encryption is
+// configured just like EncryptionConfig
+type WrapperConfig struct {
+	Type      string `yaml:"type"`
+	Limit     int64  `yaml:"limit"`
+	Key       string `yaml:"key"`
+	Filenames bool   `yaml:"filenames"`
+}
+
+func (c *Config) CreateProvider(pManager *plugin.Manager, db *gorm.DB) (storage.StorageProvider, error) {
+	out, err := c.ProviderConfig.create(pManager, db)
 	if err != nil {
 		return nil, err
 	}
+
+	if len(c.Mounts) > 0 {
+		mounts := make([]mount.Mount, 0, len(c.Mounts))
+		for _, cfg := range c.Mounts {
+			provider, err := cfg.create(pManager, db)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to create the provider mounted at %s: %w", cfg.Path, err)
+			}
+			mounts = append(mounts, mount.Mount{Path: cfg.Path, Provider: provider})
+		}
+
+		out, err = mount.Mounts(out, mounts, c.CrossMountCopy)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &ValidateWrapper{proxy: out}, nil
 }
 
-func fromConfig(cfg *Config, pManager *plugin.Manager) (storage.StorageProvider, error) {
+func (c *ProviderConfig) create(pManager *plugin.Manager, db *gorm.DB) (storage.StorageProvider, error) {
+	out, err := fromConfig(c, pManager, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Encryption.Key != "" {
+		out, err = encrypt(out, c.Encryption.Key, c.Encryption.Filenames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, wrapper := range c.Wrappers {
+		switch wrapper.Type {
+		case "readonly":
+			out = storage.ReadOnly(out)
+		case "quota":
+			out, err = quota.Limit(out, db, wrapper.Limit)
+		case "encryption":
+			out, err = encrypt(out, wrapper.Key, wrapper.Filenames)
+		default:
+			err = fmt.Errorf("No storage wrapper found with the name: %s", wrapper.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+func encrypt(provider storage.StorageProvider, encodedKey string, filenames bool) (storage.StorageProvider, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid encryption key: %w", err)
+	}
+	return encryption.Encrypt(provider, key, filenames)
+}
+
+func fromConfig(cfg *ProviderConfig, pManager *plugin.Manager, db *gorm.DB) (storage.StorageProvider, error) {
 	if cfg.Provider == "local" {
 		path, ok := cfg.Extra["path"]
 		if ok {
 			return &local.StorageProvider{RootPath: path.(string)}, nil
 		}
 		return nil, errors.New("No path provided for local storage provider?")
+	} else if cfg.Provider == "dedup" {
+		path, ok := cfg.Extra["path"]
+		if ok {
+			return dedup.NewStorageProvider(path.(string), db)
+		}
+		return nil, errors.New("No path provided for dedup storage provider?")
 	} else if strings.HasPrefix(cfg.Provider, "plugin:") {
 		name := strings.TrimPrefix(cfg.Provider, "plugin:")
 
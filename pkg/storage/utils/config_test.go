@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMountConfig(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	cfg := &Config{}
+	err := yaml.Unmarshal([]byte(fmt.Sprintf(`
+provider: local
+extra:
+  path: %s
+mounts:
+  - path: /archive
+    provider: local
+    extra:
+      path: %s
+    wrappers:
+      - type: readonly
+`, t.TempDir(), t.TempDir())), cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "local", cfg.Provider)
+
+	provider, err := cfg.CreateProvider(nil, nil)
+	if !assert.NoError(t, err) || !assert.NoError(t, provider.InitUser(ctx, user)) {
+		return
+	}
+
+	assert.NoError(t, provider.Mkdir(ctx, user, "/dir"))
+	assert.ErrorIs(t, provider.Mkdir(ctx, user, "/archive/dir"), storage.ErrReadOnly)
+
+	cfg.Mounts[0].Wrappers = []WrapperConfig{{Type: "compression"}}
+	_, err = cfg.CreateProvider(nil, nil)
+	assert.Error(t, err)
+
+	// the quota of a single mount needs a limit of its own
+	cfg.Mounts[0].Wrappers = []WrapperConfig{{Type: "quota"}}
+	_, err = cfg.CreateProvider(nil, nil)
+	assert.Error(t, err)
+}
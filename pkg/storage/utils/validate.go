@@ -2,19 +2,20 @@ package utils
 
 import (
 	"context"
-	"errors"
-	"path/filepath"
+	"fmt"
+	"strings"
 	"unicode"
 
 	"github.com/leicht-cloud/leicht-cloud/pkg/models"
 	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
 )
 
-var ErrDirectoryBack = errors.New("Attempted to go a directory back")
-var ErrInvisibleCharacter = errors.New("Invisible character in input")
+var ErrDirectoryBack = fmt.Errorf("%w: Attempted to go a directory back", storage.ErrInvalidPath)
+var ErrInvisibleCharacter = fmt.Errorf("%w: Invisible character in input", storage.ErrInvalidPath)
 
+// ValidatePath checks every part of path, these are separated by a "/" no matter the platform
 func ValidatePath(path string) error {
-	split := filepath.SplitList(path)
+	split := strings.Split(path, "/")
 	for _, part := range split {
 		err := validatePart(part)
 		if err != nil {
@@ -44,6 +45,10 @@ type ValidateWrapper struct {
 	proxy storage.StorageProvider
 }
 
+func (w *ValidateWrapper) Unwrap() storage.StorageProvider {
+	return w.proxy
+}
+
 func (w *ValidateWrapper) InitUser(ctx context.Context, user *models.User) error {
 	return w.proxy.InitUser(ctx, user)
 }
@@ -68,6 +73,18 @@ func (w *ValidateWrapper) Move(ctx context.Context, user *models.User, src strin
 	return w.proxy.Move(ctx, user, src, dst)
 }
 
+func (w *ValidateWrapper) Copy(ctx context.Context, user *models.User, src string, dst string) error {
+	err := ValidatePath(src)
+	if err != nil {
+		return err
+	}
+	err = ValidatePath(dst)
+	if err != nil {
+		return err
+	}
+	return storage.Copy(ctx, w.proxy, user, src, dst)
+}
+
 func (w *ValidateWrapper) ListDirectory(ctx context.Context, user *models.User, path string) (<-chan storage.FileInfo, error) {
 	err := ValidatePath(path)
 	if err != nil {
@@ -76,6 +93,22 @@ func (w *ValidateWrapper) ListDirectory(ctx context.Context, user *models.User,
 	return w.proxy.ListDirectory(ctx, user, path)
 }
 
+func (w *ValidateWrapper) List(ctx context.Context, user *models.User, path string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	err := ValidatePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return storage.List(ctx, w.proxy, user, path, opts)
+}
+
+func (w *ValidateWrapper) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	err := ValidatePath(fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	return w.proxy.Stat(ctx, user, fullpath)
+}
+
 func (w *ValidateWrapper) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
 	err := ValidatePath(fullpath)
 	if err != nil {
@@ -91,3 +124,43 @@ func (w *ValidateWrapper) Delete(ctx context.Context, user *models.User, fullpat
 	}
 	return w.proxy.Delete(ctx, user, fullpath)
 }
+
+func (w *ValidateWrapper) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := ValidatePath(fullpath)
+	if err != nil {
+		return err
+	}
+	return w.proxy.DeleteTree(ctx, user, fullpath)
+}
+
+func (w *ValidateWrapper) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	return storage.Watch(ctx, w.proxy, user)
+}
+
+func (w *ValidateWrapper) AtomicWrites() bool {
+	return storage.AtomicWrites(w.proxy)
+}
+
+func (w *ValidateWrapper) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	err := ValidatePath(fullpath)
+	if err != nil {
+		return "", err
+	}
+	return storage.GetMetadata(ctx, w.proxy, user, fullpath, key)
+}
+
+func (w *ValidateWrapper) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	err := ValidatePath(fullpath)
+	if err != nil {
+		return err
+	}
+	return storage.SetMetadata(ctx, w.proxy, user, fullpath, key, value)
+}
+
+func (w *ValidateWrapper) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	err := ValidatePath(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ListMetadata(ctx, w.proxy, user, fullpath)
+}
@@ -17,6 +17,11 @@ func TestValidatePath(t *testing.T) {
 		{"..", ErrDirectoryBack},
 		{string("\x06"), ErrInvisibleCharacter},
 		{"this should/be/a/valid path", nil},
+		{"a/../../x", ErrDirectoryBack},
+		{"/..", ErrDirectoryBack},
+		{"a/..", ErrDirectoryBack},
+		{"dir/\x06/file", ErrInvisibleCharacter},
+		{"a:b/..c/d..", nil},
 	}
 
 	for _, unit := range units {
@@ -0,0 +1,105 @@
+package versioning
+
+import (
+	"context"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// versionedFile stores the current contents as a version right before the first write, as that may
+// already change the file. The version is dropped again if the write doesn't make it, unless parts of
+// the file got overwritten in place already
+type versionedFile struct {
+	proxy    storage.File
+	provider *VersioningStorageProvider
+	user     *models.User
+	fullpath string
+
+	snapshotted bool
+	// version is the path of the version we stored, empty if there was nothing to store
+	version string
+	// inPlace is set once the file got written at a position, which providers apply to the file right away
+	inPlace bool
+}
+
+func (f *versionedFile) snapshot() error {
+	if f.snapshotted {
+		return nil
+	}
+	// a file may be written long after the request opening it is over, the version is still needed then
+	version, err := f.provider.snapshot(context.Background(), f.user, f.fullpath)
+	if err != nil {
+		return err
+	}
+	f.snapshotted = true
+	f.version = version
+	return nil
+}
+
+// discard removes the version stored by snapshot, for writes that didn't make it
+func (f *versionedFile) discard() {
+	if f.version == "" || f.inPlace {
+		return
+	}
+	err := f.provider.proxy.Delete(context.Background(), f.user, f.version)
+	if err != nil {
+		logrus.Errorf("Failed to remove the version of %s of a failed write: %s", f.fullpath, err)
+	}
+}
+
+func (f *versionedFile) Read(p []byte) (int, error) {
+	return f.proxy.Read(p)
+}
+
+func (f *versionedFile) Write(p []byte) (int, error) {
+	err := f.snapshot()
+	if err != nil {
+		return 0, err
+	}
+	return f.proxy.Write(p)
+}
+
+// Close only prunes the versions once the write made it, so a failed write can't cost the user any of them
+func (f *versionedFile) Close() error {
+	err := f.proxy.Close()
+	if err != nil {
+		f.discard()
+		return err
+	}
+	if f.version == "" {
+		return nil
+	}
+	return f.provider.prune(context.Background(), f.user, f.fullpath)
+}
+
+func (f *versionedFile) Abort() error {
+	err := storage.Abort(f.proxy)
+	f.discard()
+	return err
+}
+
+type versionedSeekableFile struct {
+	*versionedFile
+	seeker storage.SeekableFile
+}
+
+func (f *versionedSeekableFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.seeker.ReadAt(p, off)
+}
+
+func (f *versionedSeekableFile) WriteAt(p []byte, off int64) (int, error) {
+	err := f.snapshot()
+	if err != nil {
+		return 0, err
+	}
+	f.inPlace = true
+	return f.seeker.WriteAt(p, off)
+}
+
+func (f *versionedSeekableFile) Seek(offset int64, whence int) (int64, error) {
+	// writes after seeking modify the file in place as well
+	f.inPlace = true
+	return f.seeker.Seek(offset, whence)
+}
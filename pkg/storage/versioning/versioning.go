@@ -0,0 +1,427 @@
+package versioning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// VersionDir is where the previous versions are kept, hidden from the user. The versions of a file
+// are stored as files named after the time they were replaced, in a directory mirroring the path
+// of the file itself. So the versions of /some/file end up in /.versions/some/file/<unix nano>.
+// Every mount keeps the versions of its own files, so those of /archive/file are in /archive/.versions/file
+const VersionDir = "/.versions"
+
+// Policy decides how many of the previous versions are kept, a zero value means no limit
+type Policy struct {
+	Enabled      bool `yaml:"enabled"`
+	KeepVersions int  `yaml:"keep_versions"`
+	KeepDays     int  `yaml:"keep_days"`
+}
+
+// Version is a single previous version of a file, ID is what should be passed to Restore
+type Version struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      uint64    `json:"size"`
+}
+
+// VersioningStorageProvider keeps the previous contents of a file around whenever it gets rewritten.
+// These are kept in the underlying provider itself, so this works on top of any provider
+type VersioningStorageProvider struct {
+	proxy  storage.StorageProvider
+	policy Policy
+	// the mount points of proxy, these each keep the versions of their own files
+	mountPoints []string
+
+	clock func() time.Time
+}
+
+func Versioning(provider storage.StorageProvider, policy Policy) *VersioningStorageProvider {
+	return &VersioningStorageProvider{
+		proxy:       provider,
+		policy:      policy,
+		mountPoints: storage.MountPoints(provider),
+		clock:       time.Now,
+	}
+}
+
+func (v *VersioningStorageProvider) Unwrap() storage.StorageProvider {
+	return v.proxy
+}
+
+func cleanPath(fullpath string) string {
+	return path.Join("/", fullpath)
+}
+
+// versionDir returns where the versions of the mount fullpath is on are kept, so these never have to be
+// copied across mounts
+func (v *VersioningStorageProvider) versionDir(fullpath string) string {
+	return path.Join(storage.MountPoint(v.mountPoints, fullpath), VersionDir)
+}
+
+func (v *VersioningStorageProvider) isHidden(fullpath string) bool {
+	fullpath = cleanPath(fullpath)
+	dir := v.versionDir(fullpath)
+	return fullpath == dir || strings.HasPrefix(fullpath, dir+"/")
+}
+
+func (v *VersioningStorageProvider) checkPaths(paths ...string) error {
+	for _, fullpath := range paths {
+		if v.isHidden(fullpath) {
+			return fmt.Errorf("%w: %s", storage.ErrInvalidPath, fullpath)
+		}
+	}
+	return nil
+}
+
+func (v *VersioningStorageProvider) versionsOf(fullpath string) string {
+	fullpath = cleanPath(fullpath)
+	mountPoint := storage.MountPoint(v.mountPoints, fullpath)
+	return path.Join(mountPoint, VersionDir, strings.TrimPrefix(fullpath, mountPoint))
+}
+
+// Versions returns the previous versions of fullpath, newest first
+func (v *VersioningStorageProvider) Versions(ctx context.Context, user *models.User, fullpath string) ([]Version, error) {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := v.proxy.ListDirectory(ctx, user, v.versionsOf(fullpath))
+	if errors.Is(err, storage.ErrNotExist) || errors.Is(err, storage.ErrNotDirectory) {
+		return []Version{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	out := make([]Version, 0)
+	for file := range files {
+		// directories in here are for the files inside of fullpath, in case it's a directory
+		if file.Directory {
+			continue
+		}
+		nano, err := strconv.ParseInt(file.Name, 10, 64)
+		if err != nil {
+			continue
+		}
+		out = append(out, Version{
+			ID:        file.Name,
+			CreatedAt: time.Unix(0, nano),
+			Size:      file.Size,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// snapshot stores the current contents of fullpath as a version if it's an existing file, returning the
+// path of that version. Callers should call prune once they're done
+func (v *VersioningStorageProvider) snapshot(ctx context.Context, user *models.User, fullpath string) (string, error) {
+	info, err := v.proxy.Stat(ctx, user, fullpath)
+	if errors.Is(err, storage.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	} else if info.Directory {
+		return "", nil
+	}
+
+	dir := v.versionsOf(fullpath)
+	err = v.proxy.Mkdir(ctx, user, dir)
+	if err != nil {
+		return "", err
+	}
+
+	version := path.Join(dir, strconv.FormatInt(v.clock().UnixNano(), 10))
+	return version, storage.Copy(ctx, v.proxy, user, fullpath, version)
+}
+
+// prune removes the versions of fullpath the policy says we shouldn't keep
+func (v *VersioningStorageProvider) prune(ctx context.Context, user *models.User, fullpath string) error {
+	versions, err := v.Versions(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	cutoff := v.clock().AddDate(0, 0, -v.policy.KeepDays)
+	for i, version := range versions {
+		if (v.policy.KeepVersions > 0 && i >= v.policy.KeepVersions) ||
+			(v.policy.KeepDays > 0 && version.CreatedAt.Before(cutoff)) {
+			err = v.proxy.Delete(ctx, user, path.Join(v.versionsOf(fullpath), version.ID))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Restore makes version the current contents of fullpath, the current contents become a version themselves
+func (v *VersioningStorageProvider) Restore(ctx context.Context, user *models.User, fullpath, version string) error {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+	if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return fmt.Errorf("%w: %s", storage.ErrInvalidPath, version)
+	}
+
+	src := path.Join(v.versionsOf(fullpath), version)
+	info, err := v.proxy.Stat(ctx, user, src)
+	if errors.Is(err, storage.ErrNotExist) || (err == nil && info.Directory) {
+		return fmt.Errorf("%w: version %s of %s", storage.ErrNotExist, version, fullpath)
+	} else if err != nil {
+		return err
+	}
+
+	// the version is copied next to the other versions first, so fullpath is only replaced once it's all there
+	tmp := src + ".restore"
+	err = storage.Copy(ctx, v.proxy, user, src, tmp)
+	if err != nil {
+		v.removeTemp(ctx, user, tmp)
+		return err
+	}
+
+	_, err = v.snapshot(ctx, user, fullpath)
+	if err != nil {
+		v.removeTemp(ctx, user, tmp)
+		return err
+	}
+
+	err = v.proxy.Delete(ctx, user, fullpath)
+	if err == nil || errors.Is(err, storage.ErrNotExist) {
+		err = v.proxy.Move(ctx, user, tmp, fullpath)
+	}
+	if err != nil {
+		v.removeTemp(ctx, user, tmp)
+		return err
+	}
+
+	// we only prune once we're done, as the version we're restoring could be the one getting pruned
+	return v.prune(ctx, user, fullpath)
+}
+
+// removeTemp cleans up after a failed Restore, Versions skips the copy but it would take up space forever
+func (v *VersioningStorageProvider) removeTemp(ctx context.Context, user *models.User, tmp string) {
+	err := v.proxy.DeleteTree(ctx, user, tmp)
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		logrus.Errorf("Failed to remove %s: %s", tmp, err)
+	}
+}
+
+// moveVersions moves the versions of src along with it, so they stay attached to the file
+func (v *VersioningStorageProvider) moveVersions(ctx context.Context, user *models.User, src, dst string) {
+	_, err := v.proxy.Stat(ctx, user, v.versionsOf(src))
+	if err != nil {
+		return
+	}
+
+	err = v.proxy.Mkdir(ctx, user, path.Dir(v.versionsOf(dst)))
+	if err == nil {
+		err = v.proxy.DeleteTree(ctx, user, v.versionsOf(dst))
+		if errors.Is(err, storage.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err == nil {
+		err = v.proxy.Move(ctx, user, v.versionsOf(src), v.versionsOf(dst))
+	}
+	if err != nil {
+		logrus.Errorf("Failed to move the versions of %s: %s", src, err)
+	}
+}
+
+// deleteVersions removes the versions of fullpath, including those of everything in it
+func (v *VersioningStorageProvider) deleteVersions(ctx context.Context, user *models.User, fullpath string) {
+	err := v.proxy.DeleteTree(ctx, user, v.versionsOf(fullpath))
+	if err != nil && !errors.Is(err, storage.ErrNotExist) {
+		logrus.Errorf("Failed to delete the versions of %s: %s", fullpath, err)
+	}
+}
+
+func (v *VersioningStorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	return v.proxy.InitUser(ctx, user)
+}
+
+func (v *VersioningStorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	err := v.checkPaths(dir)
+	if err != nil {
+		return err
+	}
+	return v.proxy.Mkdir(ctx, user, dir)
+}
+
+func (v *VersioningStorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := v.checkPaths(src, dst)
+	if err != nil {
+		return err
+	}
+
+	err = v.proxy.Move(ctx, user, src, dst)
+	if err != nil {
+		return err
+	}
+	v.moveVersions(ctx, user, src, dst)
+	return nil
+}
+
+func (v *VersioningStorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	err := v.checkPaths(src, dst)
+	if err != nil {
+		return err
+	}
+	return storage.Copy(ctx, v.proxy, user, src, dst)
+}
+
+func (v *VersioningStorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	return v.List(ctx, user, dir, storage.ListOptions{})
+}
+
+func (v *VersioningStorageProvider) List(ctx context.Context, user *models.User, dir string, opts storage.ListOptions) (<-chan storage.FileInfo, error) {
+	err := v.checkPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	mountPoint := cleanPath(dir) == storage.MountPoint(v.mountPoints, dir)
+	limit := opts.Limit
+	if mountPoint && limit > 0 {
+		// we might have to leave out the versions below, so we need one more to take its place
+		opts.Limit++
+	}
+
+	files, err := storage.List(ctx, v.proxy, user, dir, opts)
+	if err != nil || !mountPoint {
+		return files, err
+	}
+
+	// the versions are only ever at the root of a mount, so those are the only directories we have to filter
+	out := make(chan storage.FileInfo)
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+		sent := 0
+		for file := range files {
+			if file.Name == path.Base(VersionDir) || (limit > 0 && sent >= limit) {
+				continue
+			}
+			select {
+			case out <- file:
+			case <-ctx.Done():
+				return
+			}
+			sent++
+		}
+	}(out)
+	return out, nil
+}
+
+func (v *VersioningStorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return storage.FileInfo{}, err
+	}
+	return v.proxy.Stat(ctx, user, fullpath)
+}
+
+func (v *VersioningStorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := v.proxy.File(ctx, user, fullpath)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &versionedFile{
+		proxy:    file,
+		provider: v,
+		user:     user,
+		fullpath: fullpath,
+	}
+	if seekable, ok := file.(storage.SeekableFile); ok {
+		return &versionedSeekableFile{versionedFile: out, seeker: seekable}, nil
+	}
+	return out, nil
+}
+
+func (v *VersioningStorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+
+	err = v.proxy.Delete(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	v.deleteVersions(ctx, user, fullpath)
+	return nil
+}
+
+func (v *VersioningStorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+
+	err = v.proxy.DeleteTree(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+	v.deleteVersions(ctx, user, fullpath)
+	return nil
+}
+
+// Watch passes on the changes of the underlying provider, leaving out everything related to the versions
+func (v *VersioningStorageProvider) Watch(ctx context.Context, user *models.User) (<-chan storage.Event, error) {
+	events, err := storage.Watch(ctx, v.proxy, user)
+	if err != nil {
+		return nil, err
+	}
+	return storage.TranslateEvents(ctx, events, func(fullpath string) (string, bool) {
+		return fullpath, !v.isHidden(fullpath)
+	}), nil
+}
+
+func (v *VersioningStorageProvider) AtomicWrites() bool {
+	return storage.AtomicWrites(v.proxy)
+}
+
+func (v *VersioningStorageProvider) GetMetadata(ctx context.Context, user *models.User, fullpath, key string) (string, error) {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return "", err
+	}
+	return storage.GetMetadata(ctx, v.proxy, user, fullpath, key)
+}
+
+func (v *VersioningStorageProvider) SetMetadata(ctx context.Context, user *models.User, fullpath, key, value string) error {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return err
+	}
+	return storage.SetMetadata(ctx, v.proxy, user, fullpath, key, value)
+}
+
+func (v *VersioningStorageProvider) ListMetadata(ctx context.Context, user *models.User, fullpath string) (map[string]string, error) {
+	err := v.checkPaths(fullpath)
+	if err != nil {
+		return nil, err
+	}
+	return storage.ListMetadata(ctx, v.proxy, user, fullpath)
+}
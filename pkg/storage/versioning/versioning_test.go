@@ -0,0 +1,188 @@
+package versioning
+
+import (
+	"context"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/local"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/builtin/memory"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/internal/storagetest"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage/mount"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersioning(t *testing.T) {
+	t.Run("Local", func(t *testing.T) {
+		storage.TestStorageProvider(Versioning(local.NewStorageProvider(t.TempDir()), Policy{}), t)
+	})
+	t.Run("Memory", func(t *testing.T) {
+		storage.TestStorageProvider(Versioning(memory.NewStorageProvider(), Policy{}), t)
+	})
+}
+
+// fakeClock returns a clock moving forward by a minute every time it's read
+func fakeClock() func() time.Time {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+}
+
+func TestVersionsAndRestore(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	memfs := memory.NewStorageProvider()
+	provider := Versioning(memfs, Policy{})
+	provider.clock = fakeClock()
+
+	assert.NoError(t, storagetest.WriteFile(provider, user, "file", "first"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "file", "second"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "file", "third"))
+
+	versions, err := provider.Versions(ctx, user, "file")
+	if !assert.NoError(t, err) || !assert.Len(t, versions, 2) {
+		return
+	}
+	assert.True(t, versions[0].CreatedAt.After(versions[1].CreatedAt))
+	assert.Equal(t, uint64(len("second")), versions[0].Size)
+
+	t.Run("Hidden", func(t *testing.T) {
+		dir, err := provider.ListDirectory(ctx, user, "/")
+		if assert.NoError(t, err) {
+			for file := range dir {
+				assert.NotEqual(t, ".versions", file.Name)
+			}
+		}
+		_, err = provider.Stat(ctx, user, VersionDir)
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+		_, err = provider.File(ctx, user, VersionDir+"/file/"+versions[0].ID)
+		assert.ErrorIs(t, err, storage.ErrInvalidPath)
+	})
+
+	t.Run("Aborted", func(t *testing.T) {
+		file, err := provider.File(ctx, user, "file")
+		if assert.NoError(t, err) {
+			_, err = file.Write([]byte("partial"))
+			assert.NoError(t, err)
+			assert.NoError(t, storage.Abort(file))
+		}
+		assert.Equal(t, "third", storagetest.ReadFile(t, provider, user, "file"))
+
+		// nothing changed, so there's nothing to keep a version of
+		after, err := provider.Versions(ctx, user, "file")
+		assert.NoError(t, err)
+		assert.Equal(t, versions, after)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		assert.NoError(t, provider.Restore(ctx, user, "file", versions[1].ID))
+		assert.Equal(t, "first", storagetest.ReadFile(t, provider, user, "file"))
+
+		// the copy it's restored from is moved into place
+		for name := range memfs.Data {
+			assert.NotContains(t, name, ".restore")
+		}
+
+		// the contents we restored over are kept as well
+		versions, err := provider.Versions(ctx, user, "file")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 3)
+
+		assert.ErrorIs(t, provider.Restore(ctx, user, "file", "1234"), storage.ErrNotExist)
+		assert.ErrorIs(t, provider.Restore(ctx, user, "file", "../file"), storage.ErrInvalidPath)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		assert.NoError(t, provider.Move(ctx, user, "file", "moved"))
+
+		versions, err := provider.Versions(ctx, user, "moved")
+		assert.NoError(t, err)
+		assert.Len(t, versions, 3)
+
+		versions, err = provider.Versions(ctx, user, "file")
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.NoError(t, provider.Delete(ctx, user, "moved"))
+
+		versions, err := provider.Versions(ctx, user, "moved")
+		assert.NoError(t, err)
+		assert.Empty(t, versions)
+	})
+}
+
+func TestMounts(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	archive := memory.NewStorageProvider()
+	mounts, err := mount.Mounts(memory.NewStorageProvider(), []mount.Mount{{Path: "/archive", Provider: archive}}, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+	provider := Versioning(mounts, Policy{})
+	provider.clock = fakeClock()
+
+	// the versions stay on the mount of the file, rather than being copied over to the root
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/archive/file", "first"))
+	assert.NoError(t, storagetest.WriteFile(provider, user, "/archive/file", "second"))
+
+	versions, err := provider.Versions(ctx, user, "/archive/file")
+	if assert.NoError(t, err) && assert.Len(t, versions, 1) {
+		assert.Equal(t, []byte("first"), archive.Data[path.Join(VersionDir, "file", versions[0].ID)])
+	}
+
+	dir, err := provider.ListDirectory(ctx, user, "/archive")
+	if assert.NoError(t, err) {
+		for file := range dir {
+			assert.NotEqual(t, ".versions", file.Name)
+		}
+	}
+	_, err = provider.Stat(ctx, user, "/archive"+VersionDir)
+	assert.ErrorIs(t, err, storage.ErrInvalidPath)
+}
+
+func TestRetention(t *testing.T) {
+	ctx := context.Background()
+	user := &models.User{ID: 1}
+
+	t.Run("KeepVersions", func(t *testing.T) {
+		provider := Versioning(memory.NewStorageProvider(), Policy{KeepVersions: 2})
+		provider.clock = fakeClock()
+
+		for _, contents := range []string{"1", "2", "3", "4", "5"} {
+			assert.NoError(t, storagetest.WriteFile(provider, user, "file", contents))
+		}
+
+		versions, err := provider.Versions(ctx, user, "file")
+		if assert.NoError(t, err) && assert.Len(t, versions, 2) {
+			assert.NoError(t, provider.Restore(ctx, user, "file", versions[1].ID))
+			assert.Equal(t, "3", storagetest.ReadFile(t, provider, user, "file"))
+		}
+	})
+
+	t.Run("KeepDays", func(t *testing.T) {
+		provider := Versioning(memory.NewStorageProvider(), Policy{KeepDays: 1})
+		now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		provider.clock = func() time.Time { return now }
+
+		assert.NoError(t, storagetest.WriteFile(provider, user, "file", "1"))
+		assert.NoError(t, storagetest.WriteFile(provider, user, "file", "2"))
+		now = now.Add(36 * time.Hour)
+		assert.NoError(t, storagetest.WriteFile(provider, user, "file", "3"))
+
+		// the version from a day and a half ago should be gone by now
+		versions, err := provider.Versions(ctx, user, "file")
+		if assert.NoError(t, err) && assert.Len(t, versions, 1) {
+			assert.Equal(t, now, versions[0].CreatedAt.UTC())
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+)
+
+type EventType string
+
+const (
+	EventCreate EventType = "create"
+	EventModify EventType = "modify"
+	EventMove   EventType = "move"
+	EventDelete EventType = "delete"
+)
+
+// Event describes a single change to the files of a user, OldPath is only set for EventMove
+type Event struct {
+	Type      EventType `json:"type"`
+	Path      string    `json:"path"`
+	OldPath   string    `json:"old_path,omitempty"`
+	Directory bool      `json:"directory"`
+}
+
+// Watcher can optionally be implemented by your StorageProvider if it's able to notice changes itself,
+// including the ones made without going through leicht-cloud. The events should cover every change, also
+// the ones made through the provider itself, as callers won't report those separately anymore. The
+// channel should be closed once ctx is done. Callers should use Watch rather than calling this directly.
+type Watcher interface {
+	Watch(ctx context.Context, user *models.User) (<-chan Event, error)
+}
+
+// Watch returns the changes to the files of user as noticed by provider, ErrNotSupported is
+// returned if provider doesn't implement Watcher
+func Watch(ctx context.Context, provider StorageProvider, user *models.User) (<-chan Event, error) {
+	if watcher, ok := provider.(Watcher); ok {
+		return watcher.Watch(ctx, user)
+	}
+	return nil, fmt.Errorf("%w: watching for changes", ErrNotSupported)
+}
+
+// TranslateEvents passes the events from in along after running their paths through translate, meant for
+// wrappers that change paths or hide some of them. translate should return false for paths the caller isn't
+// supposed to know about, moves from or to such a path are turned into a create or a delete respectively.
+func TranslateEvents(ctx context.Context, in <-chan Event, translate func(fullpath string) (string, bool)) <-chan Event {
+	out := make(chan Event)
+
+	go func(out chan<- Event) {
+		defer close(out)
+
+		for event := range in {
+			event, ok := translateEvent(event, translate)
+			if !ok {
+				continue
+			}
+
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+
+	return out
+}
+
+func translateEvent(event Event, translate func(string) (string, bool)) (Event, bool) {
+	fullpath, visible := translate(event.Path)
+	if event.Type != EventMove {
+		event.Path = fullpath
+		return event, visible
+	}
+
+	oldPath, oldVisible := translate(event.OldPath)
+	switch {
+	case visible && oldVisible:
+		event.Path = fullpath
+		event.OldPath = oldPath
+	case visible:
+		event = Event{Type: EventCreate, Path: fullpath, Directory: event.Directory}
+	case oldVisible:
+		event = Event{Type: EventDelete, Path: oldPath, Directory: event.Directory}
+	}
+	return event, visible || oldVisible
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// toStorageError turns the error responses of the S3 api into the matching storage errors,
+// fullpath is the path as seen by the user so we don't leak how we're laying out the bucket
+func toStorageError(err error, fullpath string) error {
+	if err == nil {
+		return nil
+	}
+
+	var target error
+	resp := minio.ToErrorResponse(err)
+	switch {
+	case resp.Code == "NoSuchKey", resp.Code == "NotFound", resp.StatusCode == http.StatusNotFound:
+		target = storage.ErrNotExist
+	case resp.Code == "EntityTooLarge", resp.Code == "QuotaExceeded":
+		target = storage.ErrQuotaExceeded
+	case resp.Code == "KeyTooLongError", resp.Code == "XMinioInvalidObjectName":
+		target = storage.ErrInvalidPath
+	default:
+		return err
+	}
+	return fmt.Errorf("%w: %s", target, fullpath)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/minio/minio-go/v7"
+)
+
+// errAborted is what the upload fails with when the file gets aborted, so the object is never created
+var errAborted = errors.New("Upload was aborted")
+
+// File streams reads straight from the object, writes are piped into a multipart upload
+// that only completes once the file is closed. Objects can't be modified in place, so
+// random access isn't supported.
+type File struct {
+	provider *StorageProvider
+	user     *models.User
+
+	// the path as the user sees it, used in errors
+	name string
+	key  string
+
+	object *minio.Object
+
+	writer *io.PipeWriter
+	done   chan error
+}
+
+func (f *File) openRead() error {
+	if f.writer != nil {
+		return errors.New("File is already opened in write mode")
+	}
+	if f.object == nil {
+		// the request outlives whatever context File was called with, so we don't use that one
+		object, err := f.provider.client.GetObject(context.Background(), f.provider.Bucket, f.key, minio.GetObjectOptions{})
+		if err != nil {
+			return toStorageError(err, f.name)
+		}
+		f.object = object
+	}
+	return nil
+}
+
+func (f *File) openWrite() error {
+	if f.object != nil {
+		return errors.New("File is already opened in read mode")
+	}
+	if f.writer == nil {
+		parent, err := f.provider.stat(context.Background(), f.user, path.Dir(f.name))
+		if err != nil {
+			return err
+		} else if !parent.Directory {
+			return fmt.Errorf("%w: %s", storage.ErrNotDirectory, path.Dir(f.name))
+		}
+
+		reader, writer := io.Pipe()
+		f.writer = writer
+		f.done = make(chan error, 1)
+
+		go func() {
+			// as we don't know the size up front this is always uploaded in parts of PartSize
+			_, err := f.provider.client.PutObject(context.Background(), f.provider.Bucket, f.key, reader, -1,
+				minio.PutObjectOptions{PartSize: f.provider.PartSize},
+			)
+			reader.CloseWithError(err)
+			f.done <- err
+		}()
+	}
+	return nil
+}
+
+func (f *File) Read(p []byte) (int, error) {
+	err := f.openRead()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.object.Read(p)
+	if err != nil && err != io.EOF {
+		return n, toStorageError(err, f.name)
+	}
+	return n, err
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	err := f.openWrite()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := f.writer.Write(p)
+	return n, toStorageError(err, f.name)
+}
+
+func (f *File) Close() error {
+	if f.object != nil {
+		return f.object.Close()
+	}
+	if f.writer != nil {
+		// closing the pipe finishes the upload, after which the object actually exists
+		err := f.writer.Close()
+		if err != nil {
+			return err
+		}
+		return toStorageError(<-f.done, f.name)
+	}
+	return nil
+}
+
+// Abort fails the upload instead of finishing it, which leaves any existing object as it was
+func (f *File) Abort() error {
+	if f.writer == nil {
+		return f.Close()
+	}
+	f.writer.CloseWithError(errAborted)
+	<-f.done
+	return nil
+}
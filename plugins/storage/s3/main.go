@@ -0,0 +1,13 @@
+package main
+
+import (
+	storagePlugin "github.com/leicht-cloud/leicht-cloud/pkg/storage/plugin"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	err := storagePlugin.Start(&StorageProvider{})
+	if err != nil {
+		logrus.Fatal(err)
+	}
+}
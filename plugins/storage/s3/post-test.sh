@@ -0,0 +1,10 @@
+#!/bin/sh
+
+NAME="leicht-cloud-s3-test"
+
+if [ -f .minio.pid ]; then
+	kill "$(cat .minio.pid)"
+	rm .minio.pid
+elif command -v docker >/dev/null 2>&1; then
+	docker stop "$NAME" >/dev/null
+fi
@@ -0,0 +1,35 @@
+#!/bin/sh
+# Starts a local S3 compatible stand-in on the endpoint from config.test.yml, using a minio
+# binary if there's one in the PATH and falling back to a minio docker container otherwise.
+set -e
+
+ADDRESS="127.0.0.1:9100"
+NAME="leicht-cloud-s3-test"
+
+export MINIO_ROOT_USER="leicht-cloud"
+export MINIO_ROOT_PASSWORD="leicht-cloud-secret"
+
+if command -v minio >/dev/null 2>&1; then
+	mkdir -p "$TMPDIR/minio"
+	minio server --quiet --address "$ADDRESS" "$TMPDIR/minio" >/dev/null 2>&1 &
+	echo $! > .minio.pid
+elif command -v docker >/dev/null 2>&1; then
+	docker run -d --rm --name "$NAME" -p "$ADDRESS:9000" \
+		-u "$(id -u):$(id -g)" \
+		-e MINIO_ROOT_USER -e MINIO_ROOT_PASSWORD \
+		minio/minio server /data >/dev/null
+else
+	echo "Neither minio nor docker is available" >&2
+	exit 1
+fi
+
+# wait for it to actually accept requests
+for i in $(seq 1 30); do
+	if curl -sf "http://$ADDRESS/minio/health/live" >/dev/null 2>&1; then
+		exit 0
+	fi
+	sleep 1
+done
+
+echo "S3 stand-in didn't come up in time" >&2
+exit 1
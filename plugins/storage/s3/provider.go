@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/models"
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sirupsen/logrus"
+)
+
+// the default size of the parts we upload files in, S3 requires at least 5MiB for all but the last part
+const defaultPartSize = 16 * 1024 * 1024
+
+// the largest object S3 is able to copy in a single request
+const maxCopySize = 5 * 1024 * 1024 * 1024
+
+// StorageProvider stores all files in a single bucket of an S3 compatible object store.
+// Every user gets their own prefix named after their id, directories are represented by
+// empty marker objects ending with a slash. Prefixes without a marker object (created by
+// other tools for example) are treated as directories as well.
+type StorageProvider struct {
+	Endpoint  string `yaml:"endpoint"`
+	Bucket    string `yaml:"bucket"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	UseSSL    bool   `yaml:"use_ssl"`
+	PartSize  uint64 `yaml:"part_size"`
+
+	client *minio.Client
+}
+
+func (s *StorageProvider) OnConfigure() error {
+	if s.Endpoint == "" {
+		return errors.New("No endpoint provided for s3 storage provider?")
+	}
+	if s.Bucket == "" {
+		return errors.New("No bucket provided for s3 storage provider?")
+	}
+	if s.PartSize == 0 {
+		s.PartSize = defaultPartSize
+	}
+
+	client, err := minio.New(s.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(s.AccessKey, s.SecretKey, ""),
+		Secure: s.UseSSL,
+		Region: s.Region,
+	})
+	if err != nil {
+		return err
+	}
+	s.client = client
+	return nil
+}
+
+func cleanPath(fullpath string) string {
+	return path.Join("/", fullpath)
+}
+
+// objectName returns the key of the object storing the file at fullpath
+func objectName(user *models.User, fullpath string) string {
+	return strconv.FormatUint(user.ID, 10) + cleanPath(fullpath)
+}
+
+// dirPrefix returns the prefix all the children of the directory fullpath share,
+// which is also the key of its marker object
+func dirPrefix(user *models.User, fullpath string) string {
+	return strings.TrimSuffix(objectName(user, fullpath), "/") + "/"
+}
+
+func (s *StorageProvider) InitUser(ctx context.Context, user *models.User) error {
+	exists, err := s.client.BucketExists(ctx, s.Bucket)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		err = s.client.MakeBucket(ctx, s.Bucket, minio.MakeBucketOptions{Region: s.Region})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.putMarker(ctx, dirPrefix(user, "/"))
+}
+
+func (s *StorageProvider) putMarker(ctx context.Context, key string) error {
+	_, err := s.client.PutObject(ctx, s.Bucket, key, strings.NewReader(""), 0, minio.PutObjectOptions{})
+	return err
+}
+
+// hasObjects checks whether there is at least one object starting with prefix, other than except
+func (s *StorageProvider) hasObjects(ctx context.Context, prefix, except string) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	for obj := range s.client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return false, obj.Err
+		}
+		if obj.Key != except {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// listObjects returns all the objects starting with prefix
+func (s *StorageProvider) listObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	var out []minio.ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+func (s *StorageProvider) stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	fullpath = cleanPath(fullpath)
+	info := storage.FileInfo{
+		Name:      path.Base(fullpath),
+		FullPath:  fullpath,
+		Directory: true,
+	}
+
+	// the root directory always exists, even if InitUser didn't create the marker
+	if fullpath == "/" {
+		return info, nil
+	}
+
+	obj, err := s.client.StatObject(ctx, s.Bucket, objectName(user, fullpath), minio.StatObjectOptions{})
+	if err == nil {
+		info.CreatedAt = obj.LastModified
+		info.UpdatedAt = obj.LastModified
+		info.Size = uint64(obj.Size)
+		info.Directory = false
+		info.ETag = strings.Trim(obj.ETag, `"`)
+		return info, nil
+	} else if err = toStorageError(err, fullpath); !errors.Is(err, storage.ErrNotExist) {
+		return storage.FileInfo{}, err
+	}
+
+	obj, err = s.client.StatObject(ctx, s.Bucket, dirPrefix(user, fullpath), minio.StatObjectOptions{})
+	if err == nil {
+		info.CreatedAt = obj.LastModified
+		info.UpdatedAt = obj.LastModified
+		return info, nil
+	} else if err = toStorageError(err, fullpath); !errors.Is(err, storage.ErrNotExist) {
+		return storage.FileInfo{}, err
+	}
+
+	found, err := s.hasObjects(ctx, dirPrefix(user, fullpath), "")
+	if err != nil {
+		return storage.FileInfo{}, err
+	} else if !found {
+		return storage.FileInfo{}, fmt.Errorf("%w: %s", storage.ErrNotExist, fullpath)
+	}
+	return info, nil
+}
+
+// checkTarget makes sure dst doesn't exist yet and its parent is a directory
+func (s *StorageProvider) checkTarget(ctx context.Context, user *models.User, dst string) error {
+	_, err := s.stat(ctx, user, dst)
+	if err == nil {
+		return fmt.Errorf("%w: %s", storage.ErrExist, dst)
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+
+	parent, err := s.stat(ctx, user, path.Dir(dst))
+	if err != nil {
+		return err
+	} else if !parent.Directory {
+		return fmt.Errorf("%w: %s", storage.ErrNotDirectory, path.Dir(dst))
+	}
+	return nil
+}
+
+func (s *StorageProvider) Mkdir(ctx context.Context, user *models.User, dir string) error {
+	current := "/"
+	for _, part := range strings.Split(cleanPath(dir), "/") {
+		if part == "" {
+			continue
+		}
+		current = path.Join(current, part)
+
+		info, err := s.stat(ctx, user, current)
+		if errors.Is(err, storage.ErrNotExist) {
+			err = s.putMarker(ctx, dirPrefix(user, current))
+			if err != nil {
+				return toStorageError(err, current)
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !info.Directory {
+			return fmt.Errorf("%w: %s", storage.ErrNotDirectory, current)
+		}
+	}
+	return nil
+}
+
+// copyTree copies src along with everything inside of it to dst
+func (s *StorageProvider) copyTree(ctx context.Context, user *models.User, src, dst string) error {
+	info, err := s.stat(ctx, user, src)
+	if err != nil {
+		return err
+	}
+	if cleanPath(src) == "/" || strings.HasPrefix(cleanPath(dst), cleanPath(src)+"/") {
+		return fmt.Errorf("%w: %s is inside of %s", storage.ErrInvalidPath, dst, src)
+	}
+	err = s.checkTarget(ctx, user, dst)
+	if err != nil {
+		return err
+	}
+
+	if !info.Directory {
+		return toStorageError(s.copyObject(ctx, objectName(user, src), objectName(user, dst), int64(info.Size)), src)
+	}
+
+	srcPrefix := dirPrefix(user, src)
+	dstPrefix := dirPrefix(user, dst)
+
+	// the source may not have a marker object, so we always create one for the destination
+	err = s.putMarker(ctx, dstPrefix)
+	if err != nil {
+		return toStorageError(err, dst)
+	}
+
+	objects, err := s.listObjects(ctx, srcPrefix)
+	if err != nil {
+		return toStorageError(err, src)
+	}
+	for _, obj := range objects {
+		if obj.Key == srcPrefix {
+			continue
+		}
+		err = s.copyObject(ctx, obj.Key, dstPrefix+strings.TrimPrefix(obj.Key, srcPrefix), obj.Size)
+		if err != nil {
+			return toStorageError(err, src)
+		}
+	}
+	return nil
+}
+
+// copyObject copies a single object server side. S3 can only copy objects of up to 5GiB
+// in one go, anything larger is copied in parts using ComposeObject
+func (s *StorageProvider) copyObject(ctx context.Context, src, dst string, size int64) error {
+	dstOpts := minio.CopyDestOptions{Bucket: s.Bucket, Object: dst}
+	srcOpts := minio.CopySrcOptions{Bucket: s.Bucket, Object: src}
+
+	var err error
+	if size <= maxCopySize {
+		_, err = s.client.CopyObject(ctx, dstOpts, srcOpts)
+	} else {
+		_, err = s.client.ComposeObject(ctx, dstOpts, srcOpts)
+	}
+	return err
+}
+
+// S3 has no way to rename objects, so a Move is a copy followed by removing the source
+func (s *StorageProvider) Move(ctx context.Context, user *models.User, src, dst string) error {
+	err := s.copyTree(ctx, user, src, dst)
+	if err != nil {
+		return err
+	}
+	return s.DeleteTree(ctx, user, src)
+}
+
+func (s *StorageProvider) Copy(ctx context.Context, user *models.User, src, dst string) error {
+	return s.copyTree(ctx, user, src, dst)
+}
+
+func (s *StorageProvider) ListDirectory(ctx context.Context, user *models.User, dir string) (<-chan storage.FileInfo, error) {
+	dir = cleanPath(dir)
+
+	info, err := s.stat(ctx, user, dir)
+	if err != nil {
+		return nil, err
+	} else if !info.Directory {
+		return nil, fmt.Errorf("%w: %s", storage.ErrNotDirectory, dir)
+	}
+
+	prefix := dirPrefix(user, dir)
+	objects := s.client.ListObjects(ctx, s.Bucket, minio.ListObjectsOptions{Prefix: prefix})
+
+	out := make(chan storage.FileInfo)
+
+	go func(out chan<- storage.FileInfo) {
+		defer close(out)
+
+		for obj := range objects {
+			if obj.Err != nil {
+				logrus.Errorf("Failed to list %s: %s", dir, obj.Err)
+				return
+			}
+			// this is the marker of the directory itself
+			if obj.Key == prefix {
+				continue
+			}
+
+			name := strings.TrimPrefix(obj.Key, prefix)
+			directory := strings.HasSuffix(name, "/")
+			name = strings.TrimSuffix(name, "/")
+			// the marker of a directory doesn't change along with its contents
+			etag := ""
+			if !directory {
+				etag = strings.Trim(obj.ETag, `"`)
+			}
+
+			select {
+			case out <- storage.FileInfo{
+				Name:      name,
+				FullPath:  path.Join(dir, name),
+				CreatedAt: obj.LastModified,
+				UpdatedAt: obj.LastModified,
+				Size:      uint64(obj.Size),
+				Directory: directory,
+				ETag:      etag,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}(out)
+
+	return out, nil
+}
+
+func (s *StorageProvider) Stat(ctx context.Context, user *models.User, fullpath string) (storage.FileInfo, error) {
+	return s.stat(ctx, user, fullpath)
+}
+
+func (s *StorageProvider) File(ctx context.Context, user *models.User, fullpath string) (storage.File, error) {
+	return &File{
+		provider: s,
+		user:     user,
+		name:     cleanPath(fullpath),
+		key:      objectName(user, fullpath),
+	}, nil
+}
+
+// AtomicWrites is always true, as an object is only created once its upload completes
+func (s *StorageProvider) AtomicWrites() bool {
+	return true
+}
+
+func (s *StorageProvider) Delete(ctx context.Context, user *models.User, fullpath string) error {
+	fullpath = cleanPath(fullpath)
+
+	info, err := s.stat(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	if !info.Directory {
+		return toStorageError(s.client.RemoveObject(ctx, s.Bucket, objectName(user, fullpath), minio.RemoveObjectOptions{}), fullpath)
+	}
+
+	prefix := dirPrefix(user, fullpath)
+	found, err := s.hasObjects(ctx, prefix, prefix)
+	if err != nil {
+		return toStorageError(err, fullpath)
+	} else if found || fullpath == "/" {
+		return fmt.Errorf("%w: %s", storage.ErrNotEmpty, fullpath)
+	}
+
+	return toStorageError(s.client.RemoveObject(ctx, s.Bucket, prefix, minio.RemoveObjectOptions{}), fullpath)
+}
+
+func (s *StorageProvider) DeleteTree(ctx context.Context, user *models.User, fullpath string) error {
+	fullpath = cleanPath(fullpath)
+
+	info, err := s.stat(ctx, user, fullpath)
+	if err != nil {
+		return err
+	}
+
+	if !info.Directory {
+		return toStorageError(s.client.RemoveObject(ctx, s.Bucket, objectName(user, fullpath), minio.RemoveObjectOptions{}), fullpath)
+	}
+
+	objects, err := s.listObjects(ctx, dirPrefix(user, fullpath))
+	if err != nil {
+		return toStorageError(err, fullpath)
+	}
+
+	toRemove := make(chan minio.ObjectInfo, len(objects))
+	for _, obj := range objects {
+		toRemove <- obj
+	}
+	close(toRemove)
+
+	// we have to drain all the results, otherwise RemoveObjects will block forever
+	for result := range s.client.RemoveObjects(ctx, s.Bucket, toRemove, minio.RemoveObjectsOptions{}) {
+		if result.Err != nil && err == nil {
+			err = toStorageError(result.Err, fullpath)
+		}
+	}
+	return err
+}
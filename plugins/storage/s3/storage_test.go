@@ -0,0 +1,21 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/leicht-cloud/leicht-cloud/pkg/storage"
+)
+
+func TestS3(t *testing.T) {
+	provider, err := storage.SetupTestEnv(&StorageProvider{}, t.TempDir())
+	if err != nil {
+		t.Skipf("Couldn't start the S3 stand-in: %s", err)
+	}
+	defer func() {
+		if err := storage.TeardownTestEnv(); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	storage.TestStorageProvider(provider, t)
+}